@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	"encoding/json"
+
 	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -33,11 +35,63 @@ type SlackBot struct {
 
 // SlackBotSpec provides details of a Slack Bot
 type SlackBotSpec struct {
-	Namespace      string                      `json:"namespace,omitempty" protobuf:"bytes,1,name=namespace"`
-	TokenReference jenkinsv1.ResourceReference `json:"tokenReference,omitempty" protobuf:"bytes,5,name=tokenReference"`
-	PullRequests   []SlackBotMode              `json:"pullRequests,omitempty" protobuf:"bytes,6,name=pullRequests"`
-	Pipelines      []SlackBotMode              `json:"pipelines,omitempty" protobuf:"bytes,7,name=pipelines"`
-	Statuses       Statuses                    `json:"statuses,omitempty" protobuf:"bytes,2,name=statuses"`
+	Namespace                   string                      `json:"namespace,omitempty" protobuf:"bytes,1,name=namespace"`
+	TokenReference              jenkinsv1.ResourceReference `json:"tokenReference,omitempty" protobuf:"bytes,5,name=tokenReference"`
+	PullRequests                []SlackBotMode              `json:"pullRequests,omitempty" protobuf:"bytes,6,name=pullRequests"`
+	Pipelines                   []SlackBotMode              `json:"pipelines,omitempty" protobuf:"bytes,7,name=pipelines"`
+	Statuses                    Statuses                    `json:"statuses,omitempty" protobuf:"bytes,2,name=statuses"`
+	UseBlockKit                 bool                        `json:"useBlockKit,omitempty" protobuf:"bytes,8,name=useBlockKit"`
+	ThreadStages                bool                        `json:"threadStages,omitempty" protobuf:"bytes,9,name=threadStages"`
+	MaxRetries                  *int                        `json:"maxRetries,omitempty" protobuf:"bytes,10,opt,name=maxRetries"`
+	DryRun                      bool                        `json:"dryRun,omitempty" protobuf:"bytes,11,name=dryRun"`
+	LookupUsersByEmail          *bool                       `json:"lookupUsersByEmail,omitempty" protobuf:"bytes,12,opt,name=lookupUsersByEmail"`
+	MessagesPerMinute           int                         `json:"messagesPerMinute,omitempty" protobuf:"bytes,13,opt,name=messagesPerMinute"`
+	ReviewMessageTemplate       string                      `json:"reviewMessageTemplate,omitempty" protobuf:"bytes,14,opt,name=reviewMessageTemplate"`
+	ShowCommitInfo              bool                        `json:"showCommitInfo,omitempty" protobuf:"bytes,15,opt,name=showCommitInfo"`
+	QuietHours                  *QuietHours                 `json:"quietHours,omitempty" protobuf:"bytes,16,opt,name=quietHours"`
+	ReactOnComplete             bool                        `json:"reactOnComplete,omitempty" protobuf:"bytes,17,opt,name=reactOnComplete"`
+	CreateIfMissingWindow       *metav1.Duration            `json:"createIfMissingWindow,omitempty" protobuf:"bytes,18,opt,name=createIfMissingWindow"`
+	UpdateDebounce              *metav1.Duration            `json:"updateDebounce,omitempty" protobuf:"bytes,19,opt,name=updateDebounce"`
+	UserGroups                  map[string]string           `json:"userGroups,omitempty" protobuf:"bytes,20,rep,name=userGroups"`
+	Promotions                  []PromotionMode             `json:"promotions,omitempty" protobuf:"bytes,21,rep,name=promotions"`
+	TimestampTTL                *metav1.Duration            `json:"timestampTTL,omitempty" protobuf:"bytes,22,opt,name=timestampTTL"`
+	StageEmojis                 map[string]string           `json:"stageEmojis,omitempty" protobuf:"bytes,23,rep,name=stageEmojis"`
+	CollapseSucceededStages     bool                        `json:"collapseSucceededStages,omitempty" protobuf:"bytes,24,opt,name=collapseSucceededStages"`
+	Alerting                    *Alerting                   `json:"alerting,omitempty" protobuf:"bytes,25,opt,name=alerting"`
+	ClosedReviewMessageTemplate string                      `json:"closedReviewMessageTemplate,omitempty" protobuf:"bytes,26,opt,name=closedReviewMessageTemplate"`
+	TokenSecretRef              *SecretKeyReference         `json:"tokenSecretRef,omitempty" protobuf:"bytes,27,opt,name=tokenSecretRef"`
+	MentionAuthorOnRebase       bool                        `json:"mentionAuthorOnRebase,omitempty" protobuf:"bytes,28,opt,name=mentionAuthorOnRebase"`
+	ExternalCallTimeout         *metav1.Duration            `json:"externalCallTimeout,omitempty" protobuf:"bytes,29,opt,name=externalCallTimeout"`
+	ShowTestResults             bool                        `json:"showTestResults,omitempty" protobuf:"bytes,30,opt,name=showTestResults"`
+	MessagePrefix               string                      `json:"messagePrefix,omitempty" protobuf:"bytes,31,opt,name=messagePrefix"`
+	MessageSuffix               string                      `json:"messageSuffix,omitempty" protobuf:"bytes,32,opt,name=messageSuffix"`
+	UserMappings                map[string]string           `json:"userMappings,omitempty" protobuf:"bytes,33,rep,name=userMappings"`
+	Webhooks                    []WebhookConfig             `json:"webhooks,omitempty" protobuf:"bytes,34,rep,name=webhooks"`
+	PinAfterFailures            int                         `json:"pinAfterFailures,omitempty" protobuf:"bytes,35,opt,name=pinAfterFailures"`
+	PRCacheTTL                  *metav1.Duration            `json:"prCacheTTL,omitempty" protobuf:"bytes,36,opt,name=prCacheTTL"`
+	Locale                      string                      `json:"locale,omitempty" protobuf:"bytes,37,opt,name=locale"`
+	UpdateJitter                *metav1.Duration            `json:"updateJitter,omitempty" protobuf:"bytes,38,opt,name=updateJitter"`
+	RerunButton                 bool                        `json:"rerunButton,omitempty" protobuf:"bytes,39,opt,name=rerunButton"`
+	SlowBuildThreshold          *metav1.Duration            `json:"slowBuildThreshold,omitempty" protobuf:"bytes,40,opt,name=slowBuildThreshold"`
+	PingSlowBuilds              bool                        `json:"pingSlowBuilds,omitempty" protobuf:"bytes,41,opt,name=pingSlowBuilds"`
+	Workspaces                  []Workspace                 `json:"workspaces,omitempty" protobuf:"bytes,42,rep,name=workspaces"`
+	ReviewDigest                *ReviewDigest               `json:"reviewDigest,omitempty" protobuf:"bytes,43,opt,name=reviewDigest"`
+	BroadcastTerminalToChannel  bool                        `json:"broadcastTerminalToChannel,omitempty" protobuf:"bytes,44,opt,name=broadcastTerminalToChannel"`
+	IssueTracker                *IssueTracker               `json:"issueTracker,omitempty" protobuf:"bytes,45,opt,name=issueTracker"`
+	MaintenanceWindows          []MaintenanceWindow         `json:"maintenanceWindows,omitempty" protobuf:"bytes,46,rep,name=maintenanceWindows"`
+	GroupSucceededSteps         bool                        `json:"groupSucceededSteps,omitempty" protobuf:"bytes,47,opt,name=groupSucceededSteps"`
+	ReleaseBranches             []string                    `json:"releaseBranches,omitempty" protobuf:"bytes,48,rep,name=releaseBranches"`
+	RawPipelineNames            bool                        `json:"rawPipelineNames,omitempty" protobuf:"bytes,49,opt,name=rawPipelineNames"`
+	DeliveryMode                string                      `json:"deliveryMode,omitempty" protobuf:"bytes,50,opt,name=deliveryMode"`
+	IncomingWebhooksSecret      string                      `json:"incomingWebhooksSecret,omitempty" protobuf:"bytes,51,opt,name=incomingWebhooksSecret"`
+	MaxTitleLength              int                         `json:"maxTitleLength,omitempty" protobuf:"bytes,52,opt,name=maxTitleLength"`
+	ShowBuildDuration           bool                        `json:"showBuildDuration,omitempty" protobuf:"bytes,53,opt,name=showBuildDuration"`
+	PipelineButtons             []PipelineButton            `json:"pipelineButtons,omitempty" protobuf:"bytes,54,rep,name=pipelineButtons"`
+	SkipPendingState            bool                        `json:"skipPendingState,omitempty" protobuf:"bytes,55,opt,name=skipPendingState"`
+	ShowCommitMessage           bool                        `json:"showCommitMessage,omitempty" protobuf:"bytes,56,opt,name=showCommitMessage"`
+	ShowReviewerAvatars         bool                        `json:"showReviewerAvatars,omitempty" protobuf:"bytes,57,opt,name=showReviewerAvatars"`
+	ApprovalsLabel              string                      `json:"approvalsLabel,omitempty" protobuf:"bytes,58,opt,name=approvalsLabel"`
+	RequiredApprovalsLabel      string                      `json:"requiredApprovalsLabel,omitempty" protobuf:"bytes,59,opt,name=requiredApprovalsLabel"`
 }
 
 type SlackBotMode struct {
@@ -46,11 +100,198 @@ type SlackBotMode struct {
 	Channel         string   `json:"channel" protobuf:"bytes,3,name=channel"`
 	Orgs            []Org    `json:"orgs" protobuf:"bytes,4,name=orgs"`
 	IgnoreLabels    []string `json:"ignoreLabels" protobuf:"bytes,5,name=ignoreLabels"`
+	IncludeLabels   []string `json:"includeLabels,omitempty" protobuf:"bytes,6,name=includeLabels"`
+	Channels        []string `json:"channels,omitempty" protobuf:"bytes,7,name=channels"`
+	// ReviewerThreadReplies mentions the reviewers in a threaded reply to the channel message,
+	// requires NotifyReviewers
+	ReviewerThreadReplies bool `json:"reviewerThreadReplies,omitempty" protobuf:"bytes,8,name=reviewerThreadReplies"`
+	// DeleteOnClose deletes the review request messages once the PR is merged or closed
+	DeleteOnClose bool `json:"deleteOnClose,omitempty" protobuf:"bytes,9,name=deleteOnClose"`
+	// MentionAuthorOnFailure mentions the author of the pull request in the channel message when the pipeline fails
+	MentionAuthorOnFailure bool `json:"mentionAuthorOnFailure,omitempty" protobuf:"bytes,10,name=mentionAuthorOnFailure"`
+	// IgnoreAuthors skips the pull requests opened by these logins, which may be globs such as *-bot. A pull request
+	// is skipped if either its author matches or it has one of the IgnoreLabels
+	IgnoreAuthors []string `json:"ignoreAuthors,omitempty" protobuf:"bytes,11,rep,name=ignoreAuthors"`
+	// Contexts only sends pipeline messages for these contexts, such as pr-build, all the contexts if empty
+	Contexts []string `json:"contexts,omitempty" protobuf:"bytes,12,rep,name=contexts"`
+	// IgnoreContexts doesn't send pipeline messages for these contexts
+	IgnoreContexts []string `json:"ignoreContexts,omitempty" protobuf:"bytes,13,rep,name=ignoreContexts"`
+	// ShowPRSize shows the size of the pull request, from its size/* label, in the review message
+	ShowPRSize bool `json:"showPRSize,omitempty" protobuf:"bytes,14,name=showPRSize"`
+	// NotifyOnFirstFailureOnly only posts a new pipeline message for the first failure of a pipeline, the next builds
+	// update that message silently until one succeeds
+	NotifyOnFirstFailureOnly bool `json:"notifyOnFirstFailureOnly,omitempty" protobuf:"bytes,15,name=notifyOnFirstFailureOnly"`
+	// IgnoreDrafts doesn't send review messages for the draft pull requests, the message is sent once the pull request
+	// is ready for review
+	IgnoreDrafts bool `json:"ignoreDrafts,omitempty" protobuf:"bytes,16,name=ignoreDrafts"`
+	// Workspace is the name of the workspace the messages are posted to, the workspace of the token of the SlackBot if
+	// empty. Direct messages are always sent in the workspace of the token of the SlackBot
+	Workspace string `json:"workspace,omitempty" protobuf:"bytes,17,name=workspace"`
+	// RepoPattern is a regular expression matching the names of the repositories the mode is enabled for, in addition
+	// to the repositories of the Orgs. Within an org without repositories only the matching repositories are enabled
+	RepoPattern string `json:"repoPattern,omitempty" protobuf:"bytes,18,name=repoPattern"`
+	// ShowBuildHistory lists the builds of the pull request, with their status, in the review message
+	ShowBuildHistory bool `json:"showBuildHistory,omitempty" protobuf:"bytes,19,name=showBuildHistory"`
+	// Private hides the names of the repositories, the titles of the pull requests and the commits from the messages,
+	// for the channels shared with people who shouldn't see them. The messages still link to the repositories
+	Private bool `json:"private,omitempty" protobuf:"bytes,20,name=private"`
+	// CreateOnStatuses are the pipeline statuses, such as running, a new message is posted for. The messages of the
+	// other statuses only update the messages already posted. New messages are posted for any status if empty
+	CreateOnStatuses []string `json:"createOnStatuses,omitempty" protobuf:"bytes,21,rep,name=createOnStatuses"`
+	// MentionPolicy is when the messages mention the reviewers and the authors: always, never or on-failure. The
+	// users are still named, with a link, when they aren't mentioned. NotifyReviewers and MentionAuthorOnFailure
+	// decide if empty
+	MentionPolicy string `json:"mentionPolicy,omitempty" protobuf:"bytes,22,name=mentionPolicy"`
+	// StatusChannels maps pipeline statuses, such as failure, to the channels their pipeline messages are also posted
+	// to, in addition to the channels of the mode
+	StatusChannels map[string]string `json:"statusChannels,omitempty" protobuf:"bytes,23,rep,name=statusChannels"`
+	// EscalateAfterFailures notifies the channel with a reply to the pipeline message, also sent to the channel, once
+	// the pipeline failed for this many consecutive builds, until it succeeds. Disabled if 0
+	EscalateAfterFailures int `json:"escalateAfterFailures,omitempty" protobuf:"bytes,24,opt,name=escalateAfterFailures"`
+	// EscalationMention is who the escalation notifies: here for the active members of the channel, or channel for
+	// all of them. Defaults to here
+	EscalationMention string `json:"escalationMention,omitempty" protobuf:"bytes,25,opt,name=escalationMention"`
+	// NotifyReviewersAfterGreen only mentions the reviewers, and sends them direct messages, once the latest build of
+	// the pull request succeeded. The review message names them without a mention until then, they are then also
+	// mentioned in a threaded reply to notify them
+	NotifyReviewersAfterGreen bool `json:"notifyReviewersAfterGreen,omitempty" protobuf:"bytes,26,opt,name=notifyReviewersAfterGreen"`
+}
+
+// SecretKeyReference references a key of a Secret in the namespace of the SlackBot
+type SecretKeyReference struct {
+	Name string `json:"name" protobuf:"bytes,1,name=name"`
+	// Key is the key of the Secret data, defaults to token
+	Key string `json:"key,omitempty" protobuf:"bytes,2,opt,name=key"`
+}
+
+// Workspace is another Slack workspace the messages can be posted to, with its own token
+type Workspace struct {
+	// Name is the name the modes reference the workspace by
+	Name string `json:"name" protobuf:"bytes,1,name=name"`
+	// TokenSecretRef is the key of the Secret holding the Slack token of the workspace
+	TokenSecretRef SecretKeyReference `json:"tokenSecretRef" protobuf:"bytes,2,name=tokenSecretRef"`
+}
+
+// ReviewDigest posts a daily summary of the open pull requests waiting for a review
+type ReviewDigest struct {
+	// Time is the time of day the digest is posted at, such as 09:00
+	Time string `json:"time" protobuf:"bytes,1,name=time"`
+	// TimeZone is the IANA time zone of the time, such as Europe/Paris, defaults to UTC
+	TimeZone string `json:"timeZone,omitempty" protobuf:"bytes,2,opt,name=timeZone"`
+	// Channels the digest is posted to, listing the pull requests of all the modes. Each mode posts the digest of its
+	// repositories to its own channels if empty
+	Channels []string `json:"channels,omitempty" protobuf:"bytes,3,rep,name=channels"`
+	// GitServer is the URL of the Git server hosting the repositories, defaults to https://github.com
+	GitServer string `json:"gitServer,omitempty" protobuf:"bytes,4,opt,name=gitServer"`
+}
+
+// MaintenanceWindow is a period during which the new messages are scheduled to be posted once it ends
+type MaintenanceWindow struct {
+	// Start is when the window starts, such as 2020-06-01T22:00:00Z
+	Start metav1.Time `json:"start" protobuf:"bytes,1,name=start"`
+	// End is when the window ends and the scheduled messages are posted
+	End metav1.Time `json:"end" protobuf:"bytes,2,name=end"`
+}
+
+// PipelineButton is a button of the pipeline messages
+type PipelineButton struct {
+	// Name is the link of the button: repository, pipeline, logs or artifacts
+	Name string `json:"name" protobuf:"bytes,1,name=name"`
+	// Text is the label of the button, the default label of the link if empty
+	Text string `json:"text,omitempty" protobuf:"bytes,2,opt,name=text"`
+}
+
+// IssueTracker links the issue keys found in the titles of the pull requests to the issue tracker
+type IssueTracker struct {
+	// URL is prepended to the issue keys to link to the issues, such as https://issues.example.com/browse/
+	URL string `json:"url" protobuf:"bytes,1,name=url"`
+	// KeyPattern is the regular expression matching the issue keys, defaults to keys such as JX-123
+	KeyPattern string `json:"keyPattern,omitempty" protobuf:"bytes,2,opt,name=keyPattern"`
+}
+
+// WebhookConfig forwards the messages sent to Slack to another system, such as a dashboard
+type WebhookConfig struct {
+	// URL is the endpoint the messages are POSTed to as JSON
+	URL string `json:"url" protobuf:"bytes,1,name=url"`
+	// MessageTypes restricts the messages forwarded to these types, pipeline, pr or promotion, all if empty
+	MessageTypes []string `json:"messageTypes,omitempty" protobuf:"bytes,2,rep,name=messageTypes"`
+}
+
+// Alerting opens incidents when promotions to some environments fail
+type Alerting struct {
+	// Environments are the environments a failed promotion to opens an incident for, defaults to production
+	Environments []string `json:"environments,omitempty" protobuf:"bytes,1,rep,name=environments"`
+	// PagerDuty opens the incidents in PagerDuty using the Events API v2
+	PagerDuty *PagerDuty `json:"pagerDuty,omitempty" protobuf:"bytes,2,opt,name=pagerDuty"`
+}
+
+// PagerDuty configures the PagerDuty Events API v2 integration
+type PagerDuty struct {
+	// RoutingKeyReference is the Secret with the integration key of the PagerDuty service in its routingKey field
+	RoutingKeyReference jenkinsv1.ResourceReference `json:"routingKeyReference" protobuf:"bytes,1,name=routingKeyReference"`
+}
+
+// PromotionMode configures the messages posted when a release is promoted to an environment
+type PromotionMode struct {
+	SlackBotMode `json:",inline" protobuf:"bytes,1,opt,name=mode"`
+	// Environments restricts the messages to promotions to these environments, all environments if empty
+	Environments []string `json:"environments,omitempty" protobuf:"bytes,2,rep,name=environments"`
+	// Statuses overrides the statuses of the SlackBot for the promotion messages
+	Statuses Statuses `json:"statuses,omitempty" protobuf:"bytes,3,opt,name=statuses"`
+	// UpdateTopic sets the topic of the channels to the latest release once its promotion succeeds
+	UpdateTopic bool `json:"updateTopic,omitempty" protobuf:"bytes,4,opt,name=updateTopic"`
+}
+
+// QuietHours is a daily window during which new messages aren't posted for some pipeline statuses
+type QuietHours struct {
+	// TimeZone is the IANA name of the time zone of Start and End, e.g. Europe/Paris, defaults to UTC
+	TimeZone string `json:"timeZone,omitempty" protobuf:"bytes,1,opt,name=timeZone"`
+	// Start is the time of day the window starts, as HH:MM
+	Start string `json:"start" protobuf:"bytes,2,name=start"`
+	// End is the time of day the window ends, as HH:MM, which may be before Start for a window spanning midnight
+	End string `json:"end" protobuf:"bytes,3,name=end"`
+	// Statuses are the pipeline statuses to suppress, defaults to Pending and Running
+	Statuses []string `json:"statuses,omitempty" protobuf:"bytes,4,opt,name=statuses"`
 }
 
 type Org struct {
-	Name  string   `json:"name,omitempty" protobuf:"bytes,1,name=name"`
-	Repos []string `json:"repos" protobuf:"bytes,2,name=repos"`
+	Name  string `json:"name,omitempty" protobuf:"bytes,1,name=name"`
+	Repos []Repo `json:"repos" protobuf:"bytes,2,name=repos"`
+	// Statuses overrides the statuses of the SlackBot for the messages of the repositories of the org
+	Statuses Statuses `json:"statuses,omitempty" protobuf:"bytes,3,opt,name=statuses"`
+}
+
+// Repo is a repository of an org, configured either as the plain name of the repository or as an object which
+// can override the channel the messages are posted to
+type Repo struct {
+	Name string `json:"name" protobuf:"bytes,1,name=name"`
+	// Channel is posted to instead of the channels of the mode for the repository
+	Channel string `json:"channel,omitempty" protobuf:"bytes,2,opt,name=channel"`
+}
+
+// UnmarshalJSON accepts both the plain string and the object forms of a repository
+func (r *Repo) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*r = Repo{Name: name}
+		return nil
+	}
+	type repo Repo
+	var answer repo
+	if err := json.Unmarshal(data, &answer); err != nil {
+		return err
+	}
+	*r = Repo(answer)
+	return nil
+}
+
+// MarshalJSON uses the plain string form for repositories without a channel
+func (r Repo) MarshalJSON() ([]byte, error) {
+	if r.Channel == "" {
+		return json.Marshal(r.Name)
+	}
+	type repo Repo
+	return json.Marshal(repo(r))
 }
 
 type Statuses struct {
@@ -67,10 +308,16 @@ type Statuses struct {
 	Aborted       *Status `json:"aborted,omitempty" protobuf:"bytes,11,name=aborted"`
 	LGTM          *Status `json:"lgtm,omitempty" protobuf:"bytes,12,name=lgtm"`
 	Unknown       *Status `json:"unknown,omitempty" protobuf:"bytes,13,name=unknown"`
-	Closed        *Status `json:"closed,omitempty" protobuf:"bytes,14,name=closed"` // Closed means the PR is closed but not merged
+	Closed        *Status `json:"closed,omitempty" protobuf:"bytes,14,name=closed"`   // Closed means the PR is closed but not merged
+	Merging       *Status `json:"merging,omitempty" protobuf:"bytes,15,name=merging"` // Merging means the PR has a tide/merge-method label
+	Rebase        *Status `json:"rebase,omitempty" protobuf:"bytes,16,name=rebase"`   // Rebase means the PR has the needs-rebase label
+	Queued        *Status `json:"queued,omitempty" protobuf:"bytes,17,name=queued"`   // Queued means the PR is in the Keeper merge pool
+	Skipped       *Status `json:"skipped,omitempty" protobuf:"bytes,18,name=skipped"` // Skipped means the pipeline was skipped on purpose
 }
 
 type Status struct {
 	Emoji string `json:"emoji,omitempty" protobuf:"bytes,1,name=emoji"`
 	Text  string `json:"text,omitempty" protobuf:"bytes,2,name=text"`
+	// Color is the color of the attachments for the status, either good, warning, danger or a hex code such as #3AA3E3
+	Color string `json:"color,omitempty" protobuf:"bytes,3,opt,name=color"`
 }
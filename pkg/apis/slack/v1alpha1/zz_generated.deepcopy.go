@@ -5,17 +5,79 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Alerting) DeepCopyInto(out *Alerting) {
+	*out = *in
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PagerDuty != nil {
+		in, out := &in.PagerDuty, &out.PagerDuty
+		*out = new(PagerDuty)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Alerting.
+func (in *Alerting) DeepCopy() *Alerting {
+	if in == nil {
+		return nil
+	}
+	out := new(Alerting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssueTracker) DeepCopyInto(out *IssueTracker) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssueTracker.
+func (in *IssueTracker) DeepCopy() *IssueTracker {
+	if in == nil {
+		return nil
+	}
+	out := new(IssueTracker)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Org) DeepCopyInto(out *Org) {
 	*out = *in
 	if in.Repos != nil {
 		in, out := &in.Repos, &out.Repos
-		*out = make([]string, len(*in))
+		*out = make([]Repo, len(*in))
 		copy(*out, *in)
 	}
+	in.Statuses.DeepCopyInto(&out.Statuses)
 	return
 }
 
@@ -29,6 +91,136 @@ func (in *Org) DeepCopy() *Org {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PagerDuty) DeepCopyInto(out *PagerDuty) {
+	*out = *in
+	out.RoutingKeyReference = in.RoutingKeyReference
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PagerDuty.
+func (in *PagerDuty) DeepCopy() *PagerDuty {
+	if in == nil {
+		return nil
+	}
+	out := new(PagerDuty)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineButton) DeepCopyInto(out *PipelineButton) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineButton.
+func (in *PipelineButton) DeepCopy() *PipelineButton {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineButton)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionMode) DeepCopyInto(out *PromotionMode) {
+	*out = *in
+	in.SlackBotMode.DeepCopyInto(&out.SlackBotMode)
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Statuses.DeepCopyInto(&out.Statuses)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionMode.
+func (in *PromotionMode) DeepCopy() *PromotionMode {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionMode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuietHours) DeepCopyInto(out *QuietHours) {
+	*out = *in
+	if in.Statuses != nil {
+		in, out := &in.Statuses, &out.Statuses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuietHours.
+func (in *QuietHours) DeepCopy() *QuietHours {
+	if in == nil {
+		return nil
+	}
+	out := new(QuietHours)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Repo) DeepCopyInto(out *Repo) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Repo.
+func (in *Repo) DeepCopy() *Repo {
+	if in == nil {
+		return nil
+	}
+	out := new(Repo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReviewDigest) DeepCopyInto(out *ReviewDigest) {
+	*out = *in
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReviewDigest.
+func (in *ReviewDigest) DeepCopy() *ReviewDigest {
+	if in == nil {
+		return nil
+	}
+	out := new(ReviewDigest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SlackBot) DeepCopyInto(out *SlackBot) {
 	*out = *in
@@ -104,6 +296,43 @@ func (in *SlackBotMode) DeepCopyInto(out *SlackBotMode) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.IncludeLabels != nil {
+		in, out := &in.IncludeLabels, &out.IncludeLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IgnoreAuthors != nil {
+		in, out := &in.IgnoreAuthors, &out.IgnoreAuthors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Contexts != nil {
+		in, out := &in.Contexts, &out.Contexts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IgnoreContexts != nil {
+		in, out := &in.IgnoreContexts, &out.IgnoreContexts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CreateOnStatuses != nil {
+		in, out := &in.CreateOnStatuses, &out.CreateOnStatuses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StatusChannels != nil {
+		in, out := &in.StatusChannels, &out.StatusChannels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -136,6 +365,133 @@ func (in *SlackBotSpec) DeepCopyInto(out *SlackBotSpec) {
 		}
 	}
 	in.Statuses.DeepCopyInto(&out.Statuses)
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int)
+		**out = **in
+	}
+	if in.LookupUsersByEmail != nil {
+		in, out := &in.LookupUsersByEmail, &out.LookupUsersByEmail
+		*out = new(bool)
+		**out = **in
+	}
+	if in.QuietHours != nil {
+		in, out := &in.QuietHours, &out.QuietHours
+		*out = new(QuietHours)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CreateIfMissingWindow != nil {
+		in, out := &in.CreateIfMissingWindow, &out.CreateIfMissingWindow
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.UpdateDebounce != nil {
+		in, out := &in.UpdateDebounce, &out.UpdateDebounce
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.UserGroups != nil {
+		in, out := &in.UserGroups, &out.UserGroups
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Promotions != nil {
+		in, out := &in.Promotions, &out.Promotions
+		*out = make([]PromotionMode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TimestampTTL != nil {
+		in, out := &in.TimestampTTL, &out.TimestampTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.StageEmojis != nil {
+		in, out := &in.StageEmojis, &out.StageEmojis
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Alerting != nil {
+		in, out := &in.Alerting, &out.Alerting
+		*out = new(Alerting)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.ExternalCallTimeout != nil {
+		in, out := &in.ExternalCallTimeout, &out.ExternalCallTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.UserMappings != nil {
+		in, out := &in.UserMappings, &out.UserMappings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Webhooks != nil {
+		in, out := &in.Webhooks, &out.Webhooks
+		*out = make([]WebhookConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PRCacheTTL != nil {
+		in, out := &in.PRCacheTTL, &out.PRCacheTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.UpdateJitter != nil {
+		in, out := &in.UpdateJitter, &out.UpdateJitter
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.SlowBuildThreshold != nil {
+		in, out := &in.SlowBuildThreshold, &out.SlowBuildThreshold
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Workspaces != nil {
+		in, out := &in.Workspaces, &out.Workspaces
+		*out = make([]Workspace, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReviewDigest != nil {
+		in, out := &in.ReviewDigest, &out.ReviewDigest
+		*out = new(ReviewDigest)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IssueTracker != nil {
+		in, out := &in.IssueTracker, &out.IssueTracker
+		*out = new(IssueTracker)
+		**out = **in
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReleaseBranches != nil {
+		in, out := &in.ReleaseBranches, &out.ReleaseBranches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PipelineButtons != nil {
+		in, out := &in.PipelineButtons, &out.PipelineButtons
+		*out = make([]PipelineButton, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -238,6 +594,26 @@ func (in *Statuses) DeepCopyInto(out *Statuses) {
 		*out = new(Status)
 		**out = **in
 	}
+	if in.Merging != nil {
+		in, out := &in.Merging, &out.Merging
+		*out = new(Status)
+		**out = **in
+	}
+	if in.Rebase != nil {
+		in, out := &in.Rebase, &out.Rebase
+		*out = new(Status)
+		**out = **in
+	}
+	if in.Queued != nil {
+		in, out := &in.Queued, &out.Queued
+		*out = new(Status)
+		**out = **in
+	}
+	if in.Skipped != nil {
+		in, out := &in.Skipped, &out.Skipped
+		*out = new(Status)
+		**out = **in
+	}
 	return
 }
 
@@ -250,3 +626,41 @@ func (in *Statuses) DeepCopy() *Statuses {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookConfig) DeepCopyInto(out *WebhookConfig) {
+	*out = *in
+	if in.MessageTypes != nil {
+		in, out := &in.MessageTypes, &out.MessageTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookConfig.
+func (in *WebhookConfig) DeepCopy() *WebhookConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Workspace) DeepCopyInto(out *Workspace) {
+	*out = *in
+	out.TokenSecretRef = in.TokenSecretRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Workspace.
+func (in *Workspace) DeepCopy() *Workspace {
+	if in == nil {
+		return nil
+	}
+	out := new(Workspace)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -1,14 +1,77 @@
 package slackbot
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	"github.com/jenkins-x/jx-logging/pkg/log"
 	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
-func (c *GlobalClients) getPipelineActivities(org string, repo string, prn int) (*jenkinsv1.PipelineActivityList, error) {
-	return c.JXClient.JenkinsV1().PipelineActivities(c.Namespace).List(metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("owner=%s, branch=PR-%d, repository=%s", org, prn, repo),
+func (c *GlobalClients) getPipelineActivities(ctx context.Context, org string, repo string,
+	prn int) (*jenkinsv1.PipelineActivityList, error) {
+	var acts *jenkinsv1.PipelineActivityList
+	err := callWithContext(ctx, fmt.Sprintf("listing PipelineActivities of %s/%s PR-%d", org, repo, prn),
+		func() error {
+			var err error
+			acts, err = c.JXClient.JenkinsV1().PipelineActivities(c.Namespace).List(metav1.ListOptions{
+				LabelSelector: fmt.Sprintf("owner=%s, branch=PR-%d, repository=%s", org, prn, repo),
+			})
+			return err
+		})
+	if err != nil {
+		return nil, err
+	}
+	return acts, nil
+}
+
+// getRepositoryPipelineActivities lists the PipelineActivities of the repository. The org and repository come from
+// the users of the slash commands, so they are validated: an invalid label value would select every PipelineActivity.
+func (c *GlobalClients) getRepositoryPipelineActivities(ctx context.Context, org string,
+	repo string) (*jenkinsv1.PipelineActivityList, error) {
+	set := labels.Set{"owner": org, "repository": repo}
+	for key, value := range set {
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s %q: %s", key, value, strings.Join(errs, "; "))
+		}
+	}
+	var acts *jenkinsv1.PipelineActivityList
+	err := callWithContext(ctx, fmt.Sprintf("listing PipelineActivities of %s/%s", org, repo), func() error {
+		var err error
+		acts, err = c.JXClient.JenkinsV1().PipelineActivities(c.Namespace).List(metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(set).String(),
+		})
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+	return acts, nil
+}
+
+func (c *GlobalClients) getPipelineActivity(name string) (*jenkinsv1.PipelineActivity, error) {
+	return c.JXClient.JenkinsV1().PipelineActivities(c.Namespace).Get(name, metav1.GetOptions{})
+}
+
+// pipelineActivityFor returns the PipelineActivity of the activity, or nil if it can't be read. The activity record
+// doesn't carry its annotations, attachments, preview steps or commit message, so it is read once per event and
+// passed to the parts of the messages showing them.
+func (o *SlackBotOptions) pipelineActivityFor(activity *record.ActivityRecord) *jenkinsv1.PipelineActivity {
+	if o.GlobalClients == nil || o.JXClient == nil {
+		return nil
+	}
+	pa, err := o.getPipelineActivity(activity.Name)
+	if err != nil {
+		if !kubeerrors.IsNotFound(err) {
+			log.Logger().Warnf("failed to get the PipelineActivity %s: %v", activity.Name, err)
+		}
+		return nil
+	}
+	return pa
 }
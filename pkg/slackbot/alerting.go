@@ -0,0 +1,178 @@
+package slackbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/jx"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/pkg/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// DefaultAlertEnvironment is the environment failed promotions open an incident for when none are configured
+	DefaultAlertEnvironment = "production"
+	// PagerDutyEventsURL is the endpoint of the PagerDuty Events API v2
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	// alertsReferenceChannel is the key of the references recording the alerts sent, which aren't posted to a
+	// channel. The channels are keyed by their #name or ID, so it doesn't clash with them
+	alertsReferenceChannel = "alerts"
+)
+
+// Alerter opens an incident for a pipeline, in addition to the Slack messages
+type Alerter interface {
+	Alert(ctx context.Context, activity *record.ActivityRecord, status v1alpha1.PipelineState) error
+}
+
+// PagerDutyAlerter triggers PagerDuty incidents using the Events API v2
+type PagerDutyAlerter struct {
+	RoutingKey string
+	// URL is the endpoint the events are sent to, PagerDutyEventsURL if empty
+	URL string
+	// HTTPClient sends the events, the bot alerting uses its own HTTP client if nil
+	HTTPClient *http.Client
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key,omitempty"`
+	Payload     pagerDutyPayload `json:"payload"`
+	Links       []pagerDutyLink  `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// Alert triggers an incident for the activity, incidents are de-duplicated by activity name so that alerting again
+// for the same activity doesn't open a new incident
+func (a *PagerDutyAlerter) Alert(ctx context.Context, activity *record.ActivityRecord,
+	status v1alpha1.PipelineState) error {
+	source := fmt.Sprintf("%s/%s", activity.Owner, activity.Repo)
+	event := pagerDutyEvent{
+		RoutingKey:  a.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    activity.Name,
+		Payload: pagerDutyPayload{
+			Summary:  fmt.Sprintf("%s %s: %s", source, activity.Name, status),
+			Source:   source,
+			Severity: "critical",
+			CustomDetails: map[string]string{
+				"activity": activity.Name,
+				"branch":   activity.Branch,
+				"build":    activity.BuildIdentifier,
+				"status":   string(status),
+			},
+		},
+	}
+	if activity.LinkURL != "" {
+		event.Links = append(event.Links, pagerDutyLink{Href: activity.LinkURL, Text: "Pipeline"})
+	}
+	if activity.LogURL != "" {
+		event.Links = append(event.Links, pagerDutyLink{Href: activity.LogURL, Text: "Build logs"})
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "marshalling PagerDuty event for %s", activity.Name)
+	}
+	url := a.URL
+	if url == "" {
+		url = PagerDutyEventsURL
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "creating PagerDuty request for %s", activity.Name)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "sending PagerDuty event for %s", activity.Name)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("PagerDuty rejected the event for %s with status %d: %s", activity.Name, resp.StatusCode,
+			string(data))
+	}
+	return nil
+}
+
+// alertOnFailedPromotion alerts once for each failed promotion of the activity to one of the alert environments, read
+// from its PipelineActivity. Nothing is done if no alerter is configured.
+func (o *SlackBotOptions) alertOnFailedPromotion(activity *record.ActivityRecord,
+	pa *jenkinsv1.PipelineActivity) error {
+	if o.Alerter == nil || !isFailedState(pipelineStatus(activity)) ||
+		!(hasPromoteStage(activity.Stages) || hasPromoteStage(activity.Steps)) {
+		return nil
+	}
+	if pa == nil {
+		return fmt.Errorf("failed to get the PipelineActivity %s to alert on its failed promotions", activity.Name)
+	}
+	environments := o.AlertEnvironments
+	if len(environments) == 0 {
+		environments = []string{DefaultAlertEnvironment}
+	}
+	var errs []error
+	for _, promote := range promoteSteps(pa) {
+		state := jx.ToPipelineState(promote.Status)
+		if !isFailedState(state) || !util.Contains(environments, promote.Environment) {
+			continue
+		}
+		errs = append(errs, o.alert(activity, promote, state))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (o *SlackBotOptions) alert(activity *record.ActivityRecord, promote *jenkinsv1.PromoteActivityStep,
+	state v1alpha1.PipelineState) error {
+	ctx, cancel := o.withTimeout(context.Background())
+	defer cancel()
+	alerter := o.Alerter
+	if pagerDuty, ok := alerter.(*PagerDutyAlerter); ok && pagerDuty.HTTPClient == nil {
+		// the events go through the proxy configured for the bot
+		withClient := *pagerDuty
+		withClient.HTTPClient = o.httpClient()
+		alerter = &withClient
+	}
+	// one incident per environment the release failed to be promoted to
+	promotionActivity := *activity
+	promotionActivity.Name = promotionMessageKey(activity.Name, promote.Environment)
+	if ref := o.messageReference(alertsReferenceChannel, promotionActivity.Name); ref != nil && ref.Alerted {
+		return nil
+	}
+	err := alerter.Alert(ctx, &promotionActivity, state)
+	if err != nil {
+		return errors.Wrapf(err, "alerting on the failed promotion of %s to %s", activity.Name,
+			promote.Environment)
+	}
+	// the following events of the activity don't alert again
+	o.storeMessageReference(alertsReferenceChannel, promotionActivity.Name, &MessageReference{
+		Status:  state,
+		Alerted: true,
+	})
+	activityLogger(&promotionActivity).Infof("Alerted on the failed promotion of %s to %s\n", activity.Name,
+		promote.Environment)
+	return nil
+}
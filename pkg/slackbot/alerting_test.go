@@ -0,0 +1,167 @@
+package slackbot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type recordingAlerter struct {
+	alerts    []string
+	deadlines []time.Time
+}
+
+func (a *recordingAlerter) Alert(ctx context.Context, activity *record.ActivityRecord,
+	status v1alpha1.PipelineState) error {
+	a.alerts = append(a.alerts, activity.Name+" "+string(status))
+	deadline, _ := ctx.Deadline()
+	a.deadlines = append(a.deadlines, deadline)
+	return nil
+}
+
+func TestPagerDutyAlerter_Alert(t *testing.T) {
+	var event pagerDutyEvent
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewDecoder(r.Body).Decode(&event)
+		require.NoError(t, err)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	alerter := &PagerDutyAlerter{RoutingKey: "key", URL: server.URL}
+	act := &record.ActivityRecord{
+		Name:   "cheese-wine-master-3/promote-production",
+		Owner:  "cheese",
+		Repo:   "wine",
+		LogURL: "https://logs.example.com/3",
+	}
+
+	err := alerter.Alert(context.Background(), act, v1alpha1.FailureState)
+	require.NoError(t, err)
+	assert.Equal(t, "key", event.RoutingKey)
+	assert.Equal(t, "trigger", event.EventAction)
+	assert.Equal(t, act.Name, event.DedupKey)
+	assert.Equal(t, "cheese/wine", event.Payload.Source)
+	assert.Equal(t, "critical", event.Payload.Severity)
+	require.Len(t, event.Links, 1)
+	assert.Equal(t, act.LogURL, event.Links[0].Href)
+
+	// events rejected by PagerDuty are reported
+	status = http.StatusBadRequest
+	err = alerter.Alert(context.Background(), act, v1alpha1.FailureState)
+	assert.Error(t, err)
+}
+
+func TestSlackBotOptions_alertOnFailedPromotion(t *testing.T) {
+	promote := func(env string, status jenkinsv1.ActivityStatusType) jenkinsv1.PipelineActivityStep {
+		return jenkinsv1.PipelineActivityStep{
+			Kind: jenkinsv1.ActivityStepKindTypePromote,
+			Promote: &jenkinsv1.PromoteActivityStep{
+				CoreActivityStep: jenkinsv1.CoreActivityStep{Status: status},
+				Environment:      env,
+			},
+		}
+	}
+	pa := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cheese-wine-master-3",
+			Namespace: "jx",
+		},
+		Spec: jenkinsv1.PipelineActivitySpec{
+			Steps: []jenkinsv1.PipelineActivityStep{
+				promote("staging", jenkinsv1.ActivityStatusTypeFailed),
+				promote("production", jenkinsv1.ActivityStatusTypeFailed),
+			},
+		},
+	}
+	o := &SlackBotOptions{
+		Timestamps: make(map[string]map[string]*MessageReference),
+	}
+	act := &record.ActivityRecord{
+		Name:   "cheese-wine-master-3",
+		Owner:  "cheese",
+		Repo:   "wine",
+		Status: v1alpha1.FailureState,
+		Stages: []*record.ActivityStageOrStep{
+			{
+				Name:   "from build pack",
+				Status: v1alpha1.FailureState,
+				Steps: []*record.ActivityStageOrStep{
+					{Name: "promote jx promote", Status: v1alpha1.FailureState},
+				},
+			},
+		},
+	}
+
+	// no alerter is a no-op
+	err := o.alertOnFailedPromotion(act, pa)
+	require.NoError(t, err)
+
+	alerter := &recordingAlerter{}
+	o.Alerter = alerter
+	err = o.alertOnFailedPromotion(act, pa)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cheese-wine-master-3/promote-production failure"}, alerter.alerts)
+
+	// each failed promotion only alerts once
+	o.AlertEnvironments = []string{"staging", "production"}
+	err = o.alertOnFailedPromotion(act, pa)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"cheese-wine-master-3/promote-production failure",
+		"cheese-wine-master-3/promote-staging failure",
+	}, alerter.alerts)
+	assert.True(t, o.messageReference(alertsReferenceChannel, "cheese-wine-master-3/promote-staging").Alerted)
+	err = o.alertOnFailedPromotion(act, pa)
+	require.NoError(t, err)
+	assert.Len(t, alerter.alerts, 2)
+
+	// pipelines which didn't fail don't alert
+	act.Stages[0].Status = v1alpha1.SuccessState
+	act.Status = v1alpha1.SuccessState
+	delete(o.Timestamps, alertsReferenceChannel)
+	err = o.alertOnFailedPromotion(act, pa)
+	require.NoError(t, err)
+	assert.Len(t, alerter.alerts, 2)
+}
+
+func TestSlackBotOptions_alert(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	transport := &countingTransport{}
+	o := &SlackBotOptions{
+		GlobalClients:       &GlobalClients{HTTPClient: &http.Client{Transport: transport}},
+		Timestamps:          make(map[string]map[string]*MessageReference),
+		ExternalCallTimeout: time.Minute,
+	}
+	act := &record.ActivityRecord{Name: "cheese-wine-master-3", Owner: "cheese", Repo: "wine"}
+	promote := &jenkinsv1.PromoteActivityStep{Environment: "production"}
+
+	// the alerts time out after the timeout of the bot
+	alerter := &recordingAlerter{}
+	o.Alerter = alerter
+	err := o.alert(act, promote, v1alpha1.FailureState)
+	require.NoError(t, err)
+	require.Len(t, alerter.deadlines, 1)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), alerter.deadlines[0], time.Second)
+
+	// the PagerDuty events are sent with the HTTP client of the bot
+	o.Alerter = &PagerDutyAlerter{RoutingKey: "key", URL: server.URL}
+	promote.Environment = "staging"
+	err = o.alert(act, promote, v1alpha1.FailureState)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&transport.requests))
+}
@@ -0,0 +1,61 @@
+package slackbot
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+)
+
+const (
+	// ApprovalsLabelPrefix prefixes the label holding the number of approvals of a pull request, such as approvals/2,
+	// unless the SlackBot configures another approvalsLabel
+	ApprovalsLabelPrefix = "approvals/"
+	// RequiredApprovalsLabelPrefix prefixes the label holding the number of approvals a pull request needs to be
+	// merged, such as required-approvals/3, unless the SlackBot configures another requiredApprovalsLabel
+	RequiredApprovalsLabelPrefix = "required-approvals/"
+)
+
+// approvalProgress returns the number of approvals of the pull request and the number it requires from the labels
+// set by the review automation, ok is false unless both are known
+func (o *SlackBotOptions) approvalProgress(pr *gits.GitPullRequest) (approvals int, required int, ok bool) {
+	approvalsLabel, requiredLabel := o.ApprovalsLabel, o.RequiredApprovalsLabel
+	if approvalsLabel == "" {
+		approvalsLabel = ApprovalsLabelPrefix
+	}
+	if requiredLabel == "" {
+		requiredLabel = RequiredApprovalsLabelPrefix
+	}
+	approvals, ok = labelCount(pr, approvalsLabel)
+	if !ok {
+		return 0, 0, false
+	}
+	required, ok = labelCount(pr, requiredLabel)
+	if !ok || required <= 0 {
+		return 0, 0, false
+	}
+	return approvals, required, true
+}
+
+// labelCount returns the number of the first label of the pull request with the prefix, such as 2 for approvals/2.
+// The labels and the prefix are compared regardless of case
+func labelCount(pr *gits.GitPullRequest, prefix string) (int, bool) {
+	if pr == nil {
+		return 0, false
+	}
+	prefix = strings.ToLower(prefix)
+	for _, label := range pr.Labels {
+		if label == nil || label.Name == nil {
+			continue
+		}
+		name := strings.ToLower(*label.Name)
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		count, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+		if err == nil && count >= 0 {
+			return count, true
+		}
+	}
+	return 0, false
+}
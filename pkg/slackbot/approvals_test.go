@@ -0,0 +1,90 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_approvalProgress(t *testing.T) {
+	label := func(name string) *gits.Label {
+		return &gits.Label{Name: &name}
+	}
+	tests := []struct {
+		name         string
+		options      *SlackBotOptions
+		labels       []*gits.Label
+		wantApproved int
+		wantRequired int
+		wantOK       bool
+	}{
+		{name: "no_labels"},
+		{name: "approvals_only", labels: []*gits.Label{label("approvals/2")}},
+		{name: "required_only", labels: []*gits.Label{label("required-approvals/3")}},
+		{
+			name:         "both",
+			labels:       []*gits.Label{label("approved"), label("approvals/2"), label("required-approvals/3")},
+			wantApproved: 2,
+			wantRequired: 3,
+			wantOK:       true,
+		},
+		{name: "not_a_number", labels: []*gits.Label{label("approvals/two"), label("required-approvals/3")}},
+		{name: "nothing_required", labels: []*gits.Label{label("approvals/0"), label("required-approvals/0")}},
+		{
+			name:         "configured_labels",
+			options:      &SlackBotOptions{ApprovalsLabel: "Reviews-", RequiredApprovalsLabel: "needs-reviews-"},
+			labels:       []*gits.Label{label("approvals/1"), label("reviews-2"), label("needs-reviews-3")},
+			wantApproved: 2,
+			wantRequired: 3,
+			wantOK:       true,
+		},
+		{
+			name:    "default_labels_not_configured",
+			options: &SlackBotOptions{ApprovalsLabel: "reviews-", RequiredApprovalsLabel: "needs-reviews-"},
+			labels:  []*gits.Label{label("approvals/2"), label("required-approvals/3")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.options
+			if o == nil {
+				o = &SlackBotOptions{}
+			}
+			approved, required, ok := o.approvalProgress(&gits.GitPullRequest{Labels: tt.labels})
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantApproved, approved)
+			assert.Equal(t, tt.wantRequired, required)
+		})
+	}
+	_, _, ok := (&SlackBotOptions{}).approvalProgress(nil)
+	assert.False(t, ok)
+}
+
+func TestSlackBotOptions_createReviewersMessage_approvalProgress(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	clients, resolver := newReviewClients(nil, nil)
+	o := &SlackBotOptions{GlobalClients: clients}
+	approvals, required := "approvals/2", "required-approvals/3"
+	pr := &gits.GitPullRequest{
+		URL:    "https://github.com/jenkins-x-labs/jxl/pull/83",
+		Title:  "Add cheddar",
+		Labels: []*gits.Label{{Name: &approvals}, {Name: &required}},
+	}
+
+	attachments, _, _, err := o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{mention: true})
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	require.Len(t, attachments[0].Fields, 3)
+	assert.Equal(t, ":ballot_box_with_check: 2/3 approvals", attachments[0].Fields[2].Value)
+
+	// without the required number of approvals only the review status is shown
+	pr.Labels = pr.Labels[:1]
+	attachments, _, _, err = o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{mention: true})
+	require.NoError(t, err)
+	assert.Len(t, attachments[0].Fields, 2)
+}
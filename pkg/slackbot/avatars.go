@@ -0,0 +1,86 @@
+package slackbot
+
+import (
+	"context"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/slack-go/slack"
+)
+
+// maxContextElements is the number of elements Slack allows in a context block
+const maxContextElements = 10
+
+// UserInfoGetter gets the profiles of the Slack users, implemented by *slack.Client
+type UserInfoGetter interface {
+	GetUserInfoContext(ctx context.Context, user string) (*slack.User, error)
+}
+
+// reviewerAvatarsBlock returns a context block showing the profile image of each reviewer of the workspace next to
+// their mention, or no block if none of them has an avatar, the reviewers are then only mentioned in the message
+func (o *SlackBotOptions) reviewerAvatarsBlock(workspace string, reviewers []*slack.User) []slack.Block {
+	var elements []slack.MixedElement
+	withAvatar := false
+	for _, reviewer := range reviewers {
+		if reviewer == nil || reviewer.ID == "" {
+			continue
+		}
+		if len(elements)+2 > maxContextElements {
+			break
+		}
+		if avatar := o.slackAvatar(workspace, reviewer.ID); avatar != "" {
+			elements = append(elements, slack.NewImageBlockElement(avatar, reviewer.ID))
+			withAvatar = true
+		}
+		elements = append(elements, slack.NewTextBlockObject(slack.MarkdownType, "<@"+reviewer.ID+">", false,
+			false))
+	}
+	if !withAvatar {
+		return nil
+	}
+	return []slack.Block{slack.NewContextBlock("", elements...)}
+}
+
+// slackAvatar returns the URL of the profile image of the Slack user of the workspace, or an empty string if it can't
+// be found. The images are cached, including the users without one, so that each user is only looked up once.
+func (o *SlackBotOptions) slackAvatar(workspace string, id string) string {
+	if o.webAPIDisabled() {
+		return ""
+	}
+	o.avatarsLock.Lock()
+	avatar, ok := o.avatars[workspace][id]
+	o.avatarsLock.Unlock()
+	if ok {
+		return avatar
+	}
+	getter, ok := o.slackClientFor(workspace).(UserInfoGetter)
+	if !ok {
+		return ""
+	}
+	var user *slack.User
+	err := o.postWithRetry(context.Background(), "getting user info", func(ctx context.Context) error {
+		defer observeSlackAPICall("users.info", time.Now())
+		var err error
+		user, err = getter.GetUserInfoContext(ctx, id)
+		return err
+	})
+	if err != nil {
+		// not cached, the user is looked up again for the next message
+		log.Logger().Warnf("failed to get the profile of Slack user %s: %v", id, err)
+		return ""
+	}
+	avatar = user.Profile.Image48
+	if avatar == "" {
+		avatar = user.Profile.Image72
+	}
+	o.avatarsLock.Lock()
+	if o.avatars == nil {
+		o.avatars = make(map[string]map[string]string)
+	}
+	if o.avatars[workspace] == nil {
+		o.avatars[workspace] = make(map[string]string)
+	}
+	o.avatars[workspace][id] = avatar
+	o.avatarsLock.Unlock()
+	return avatar
+}
@@ -0,0 +1,47 @@
+package slackbot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_reviewerAvatarsBlock(t *testing.T) {
+	client := &fakeSlackClient{avatars: map[string]string{"U1": "https://avatars.example.com/U1_48.png"}}
+	o := &SlackBotOptions{SlackClient: client}
+	reviewers := []*slack.User{{ID: "U1"}, {ID: "U2"}}
+
+	blocks := o.reviewerAvatarsBlock("", reviewers)
+	require.Len(t, blocks, 1)
+	context, ok := blocks[0].(*slack.ContextBlock)
+	require.True(t, ok, "expected a context block")
+	elements := context.ContextElements.Elements
+	require.Len(t, elements, 3)
+	image, ok := elements[0].(*slack.ImageBlockElement)
+	require.True(t, ok, "expected the avatar of U1")
+	assert.Equal(t, "https://avatars.example.com/U1_48.png", image.ImageURL)
+	assert.Equal(t, "<@U1>", elements[1].(*slack.TextBlockObject).Text)
+	// the reviewers without an avatar are only mentioned
+	assert.Equal(t, "<@U2>", elements[2].(*slack.TextBlockObject).Text)
+
+	// the profiles are cached
+	o.reviewerAvatarsBlock("", reviewers)
+	assert.Len(t, client.callsTo("users.info"), 2)
+
+	// the reviewers are only mentioned in the message when none of them has an avatar
+	assert.Empty(t, o.reviewerAvatarsBlock("", []*slack.User{{ID: "U2"}}))
+	assert.Empty(t, o.reviewerAvatarsBlock("", nil))
+}
+
+func TestSlackBotOptions_slackAvatar_error(t *testing.T) {
+	client := &fakeSlackClient{err: errors.New("user_not_found")}
+	o := &SlackBotOptions{SlackClient: client}
+
+	assert.Empty(t, o.slackAvatar("", "U1"))
+	client.err = nil
+	client.avatars = map[string]string{"U1": "https://avatars.example.com/U1_48.png"}
+	assert.Equal(t, "https://avatars.example.com/U1_48.png", o.slackAvatar("", "U1"), "the errors shouldn't be cached")
+}
@@ -0,0 +1,105 @@
+package slackbot
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/jenkins-x/slack/pkg/slackbot/interaction"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/slack-go/slack"
+)
+
+// createPipelineBlocks renders the pipeline message using Block Kit rather than legacy attachments
+func (o *SlackBotOptions) createPipelineBlocks(activity *record.ActivityRecord, pa *jenkinsv1.PipelineActivity,
+	pr *gits.GitPullRequest, statuses slackapp.Statuses, private bool) ([]slack.Block, bool, error) {
+	attachments, createIfMissing, err := o.createPipelineMessage(activity, pa, pr, statuses, private)
+	if err != nil {
+		return nil, false, err
+	}
+	return attachmentsToBlocks(attachments), createIfMissing, nil
+}
+
+// attachmentsToBlocks converts attachments into the equivalent Block Kit layout. Attachments with a title, fields or
+// actions become section and action blocks, simple text attachments (such as pipeline steps) become context blocks.
+func attachmentsToBlocks(attachments []slack.Attachment) []slack.Block {
+	blocks := []slack.Block{}
+	blockIDs := map[string]bool{}
+	for i, a := range attachments {
+		text := a.Title
+		if text == "" {
+			text = a.Text
+		} else if a.Text != "" {
+			text = fmt.Sprintf("%s\n%s", text, a.Text)
+		}
+		if a.Title == "" && len(a.Fields) == 0 && len(a.Actions) == 0 {
+			if text != "" {
+				blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, text,
+					false, false)))
+			}
+			continue
+		}
+		var fields []*slack.TextBlockObject
+		for _, f := range a.Fields {
+			value := f.Value
+			if f.Title != "" {
+				value = fmt.Sprintf("*%s*\n%s", f.Title, f.Value)
+			}
+			fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, value, false, false))
+		}
+		var textObj *slack.TextBlockObject
+		if text != "" {
+			textObj = slack.NewTextBlockObject(slack.MarkdownType, text, false, false)
+		}
+		blocks = append(blocks, slack.NewSectionBlock(textObj, fields, nil))
+
+		var elements []slack.BlockElement
+		for j, action := range a.Actions {
+			button := slack.NewButtonBlockElement(fmt.Sprintf("%s-%d-%d", a.CallbackID, i, j), action.Value,
+				slack.NewTextBlockObject(slack.PlainTextType, action.Text, false, false))
+			button.URL = action.URL
+			button.Confirm = confirmationBlockObject(action.Confirm)
+			elements = append(elements, button)
+		}
+		if len(elements) > 0 {
+			// the ID of the block identifies the message in the interactive callbacks of its buttons, Slack rejects
+			// the messages with duplicate block IDs so the next blocks of the same message are suffixed
+			blockID := a.CallbackID
+			if blockID != "" && blockIDs[blockID] {
+				blockID = fmt.Sprintf("%s%s%d", blockID, interaction.BlockIDSeparator, i)
+			}
+			blockIDs[blockID] = true
+			blocks = append(blocks, slack.NewActionBlock(blockID, elements...))
+		}
+		if a.Footer != "" {
+			blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, a.Footer,
+				false, false)))
+		}
+	}
+	return blocks
+}
+
+// confirmationBlockObject converts the confirmation dialog of an attachment action into its Block Kit equivalent,
+// which needs a title and the texts of both buttons, defaulting to those of the attachment dialogs
+func confirmationBlockObject(confirm *slack.ConfirmationField) *slack.ConfirmationBlockObject {
+	if confirm == nil {
+		return nil
+	}
+	title, ok, dismiss := confirm.Title, confirm.OkText, confirm.DismissText
+	if title == "" {
+		title = "Are you sure?"
+	}
+	if ok == "" {
+		ok = "Okay"
+	}
+	if dismiss == "" {
+		dismiss = "Cancel"
+	}
+	return slack.NewConfirmationBlockObject(
+		slack.NewTextBlockObject(slack.PlainTextType, title, false, false),
+		slack.NewTextBlockObject(slack.MarkdownType, confirm.Text, false, false),
+		slack.NewTextBlockObject(slack.PlainTextType, ok, false, false),
+		slack.NewTextBlockObject(slack.PlainTextType, dismiss, false, false))
+}
@@ -0,0 +1,63 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_createPipelineBlocks(t *testing.T) {
+	o := &SlackBotOptions{}
+	act, err := getPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	pr := &gits.GitPullRequest{
+		URL: "https://github.com/jenkins-x-labs/jxl/pull/83",
+	}
+	blocks, _, err := o.createPipelineBlocks(act, nil, pr, o.Statuses, false)
+	require.NoError(t, err)
+
+	// the pipeline summary, its buttons and one context block per step
+	require.Len(t, blocks, 8)
+	assert.Equal(t, slack.MBTSection, blocks[0].BlockType())
+	assert.Equal(t, slack.MBTAction, blocks[1].BlockType())
+	actions := blocks[1].(*slack.ActionBlock)
+	assert.Len(t, actions.Elements.ElementSet, 2)
+	for _, b := range blocks[2:] {
+		assert.Equal(t, slack.MBTContext, b.BlockType())
+	}
+}
+
+func Test_attachmentsToBlocks_actions(t *testing.T) {
+	attachments := []slack.Attachment{
+		{
+			CallbackID: "pipelineactivity:cheese-wine-1",
+			Title:      "build",
+			Actions:    []slack.AttachmentAction{rerunAction()},
+		},
+		{
+			CallbackID: "pipelineactivity:cheese-wine-1",
+			Title:      "stage",
+			Actions:    []slack.AttachmentAction{{Name: "logs", Text: "Logs", Type: "button", URL: "https://logs"}},
+		},
+	}
+
+	blocks := attachmentsToBlocks(attachments)
+	require.Len(t, blocks, 4)
+	first := blocks[1].(*slack.ActionBlock)
+	second := blocks[3].(*slack.ActionBlock)
+	// the block IDs are unique within the message, but still identify the activity
+	assert.Equal(t, "pipelineactivity:cheese-wine-1", first.BlockID)
+	assert.Equal(t, "pipelineactivity:cheese-wine-1#1", second.BlockID)
+
+	rerun := first.Elements.ElementSet[0].(*slack.ButtonBlockElement)
+	require.NotNil(t, rerun.Confirm)
+	assert.Equal(t, "Rerun this pipeline?", rerun.Confirm.Text.Text)
+	assert.Equal(t, "Rerun", rerun.Confirm.Confirm.Text)
+	assert.Equal(t, "Cancel", rerun.Confirm.Deny.Text)
+	assert.NotEmpty(t, rerun.Confirm.Title.Text)
+	assert.Nil(t, second.Elements.ElementSet[0].(*slack.ButtonBlockElement).Confirm)
+}
@@ -2,8 +2,10 @@ package slackbot
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -15,6 +17,7 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/record"
 
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/jenkins-x/jx/v2/pkg/users"
 
@@ -39,6 +42,7 @@ const (
 	SlackAnnotationPrefix        = "bot.slack.apps.jenkins-x.io"
 	pullRequestReviewMessageType = "pr"
 	pipelineMessageType          = "pipeline"
+	mergeMethodLabelPrefix       = "tide/merge-method-"
 )
 
 var knownPipelineStageTypes = []string{"setup", "setVersion", "preBuild", "build", "postBuild", "promote", "pipeline"}
@@ -52,6 +56,18 @@ var defaultStatuses = slackapp.Statuses{
 		Emoji: ":closed_book:",
 		Text:  "closed and not merged",
 	},
+	Merging: &slackapp.Status{
+		Emoji: ":hourglass_flowing_sand:",
+		Text:  "merging",
+	},
+	Queued: &slackapp.Status{
+		Emoji: ":vertical_traffic_light:",
+		Text:  "queued for merge",
+	},
+	Rebase: &slackapp.Status{
+		Emoji: ":twisted_rightwards_arrows:",
+		Text:  "needs rebase",
+	},
 	Aborted: &slackapp.Status{
 		Emoji: ":red_circle:",
 		Text:  "build aborted",
@@ -96,6 +112,10 @@ var defaultStatuses = slackapp.Statuses{
 		Emoji: ":+1:",
 		Text:  "lgtm",
 	},
+	Skipped: &slackapp.Status{
+		Emoji: ":fast_forward:",
+		Text:  "build skipped",
+	},
 	Unknown: &slackapp.Status{
 		Emoji: ":grey_question:",
 		Text:  "",
@@ -103,39 +123,98 @@ var defaultStatuses = slackapp.Statuses{
 }
 
 type MessageReference struct {
-	ChannelID string
-	Timestamp string
+	// Workspace is the name of the workspace the message was posted to, empty for the default workspace
+	Workspace string `json:"workspace,omitempty"`
+	ChannelID string `json:"channelId"`
+	Timestamp string `json:"timestamp"`
+	// ThreadTimestamp is the timestamp of the parent message when this message is a threaded reply
+	ThreadTimestamp string `json:"threadTimestamp,omitempty"`
+	// Text is the text of the message, used to avoid re-posting a reply which hasn't changed
+	Text string `json:"text,omitempty"`
+	// Hash is the hash of the attachments or blocks last sent, used to skip updates which wouldn't change anything
+	Hash string `json:"hash,omitempty"`
+	// Reaction is the name of the reaction added for the final state of the pipeline
+	Reaction string `json:"reaction,omitempty"`
+	// LastUpdated is when the reference was last stored, references are evicted once they are older than the TTL
+	LastUpdated time.Time `json:"lastUpdated,omitempty"`
+	// Status is the status of the pipeline when the message was last sent
+	Status v1alpha1.PipelineState `json:"status,omitempty"`
+	// Reviewers are the logins of the reviewers requested when the review message was last rendered
+	Reviewers []string `json:"reviewers,omitempty"`
+	// NotifiedFailure is set once the message notified a failure of the pipeline, which is only notified once with
+	// NotifyOnFirstFailureOnly
+	NotifiedFailure bool `json:"notifiedFailure,omitempty"`
+	// WebhookState is the state of the activity a message posted with an incoming webhook was last posted for, a new
+	// message is only posted when it changes
+	WebhookState string `json:"webhookState,omitempty"`
+	// ScheduledMessageID is the ID of the message scheduled during a maintenance window, which Slack posts at
+	// ScheduledPostAt. The message has no Timestamp, as Slack doesn't tell it once it is posted
+	ScheduledMessageID string    `json:"scheduledMessageId,omitempty"`
+	ScheduledPostAt    time.Time `json:"scheduledPostAt,omitempty"`
+	// Alerted is set once the Alerter opened an incident for the failed promotion, so that it only alerts once
+	Alerted bool `json:"alerted,omitempty"`
 }
 
-func (o *SlackBotOptions) isEnabled(activity *record.ActivityRecord, orgs []slackapp.Org,
-	ignoreLabels []string) (bool, *gits.GitPullRequest, *users.GitUserResolver, error) {
-	if len(orgs) > 0 {
+func (o *SlackBotOptions) isEnabled(ctx context.Context, activity *record.ActivityRecord,
+	cfg slackapp.SlackBotMode) (bool, *gits.GitPullRequest, *users.GitUserResolver, error) {
+	if len(cfg.Orgs) > 0 {
 		found := false
-		for _, o := range orgs {
-			if o.Name == activity.Owner {
-				if len(o.Repos) == 0 {
+		for _, org := range cfg.Orgs {
+			if org.Name == activity.Owner {
+				if len(org.Repos) == 0 && cfg.RepoPattern == "" {
 					found = true
 					break
 				}
-				for _, r := range o.Repos {
-					if r == activity.Repo {
+				for _, r := range org.Repos {
+					if r.Name == activity.Repo {
 						found = true
 						break
 					}
 				}
+				if o.matchesRepoPattern(cfg, activity.Repo) {
+					found = true
+					break
+				}
 			}
 		}
 		if !found {
 			return false, nil, nil, nil
 		}
+	} else if cfg.RepoPattern != "" && !o.matchesRepoPattern(cfg, activity.Repo) {
+		return false, nil, nil, nil
 	}
 	var pr *gits.GitPullRequest
 	var err error
 	var resolver *users.GitUserResolver
-	pr, resolver, err = o.getPullRequest(activity)
+	pr, resolver, err = o.getPullRequest(ctx, activity)
 	if err != nil {
 		return false, nil, nil, errors.WithStack(err)
 	}
+	if !matchesLabels(activity, pr, cfg.IgnoreLabels, cfg.IncludeLabels) {
+		return false, nil, nil, nil
+	}
+	if ignoredAuthor(activity, pr, cfg.IgnoreAuthors) {
+		return false, nil, nil, nil
+	}
+	return true, pr, resolver, nil
+}
+
+// matchesContext returns false if the pipeline context is one of the ignored contexts, or if contexts are configured
+// and it isn't one of them
+func matchesContext(pipelineContext string, contexts []string, ignoreContexts []string) bool {
+	if containsIgnoreCase(ignoreContexts, pipelineContext) {
+		return false
+	}
+	return len(contexts) == 0 || containsIgnoreCase(contexts, pipelineContext)
+}
+
+// matchesLabels returns false if the pull request has one of the ignore labels, or if include labels are configured
+// and the pull request has none of them. Activities which are not for a pull request have no labels so always match.
+func matchesLabels(activity *record.ActivityRecord, pr *gits.GitPullRequest, ignoreLabels []string,
+	includeLabels []string) bool {
+	if pr == nil {
+		return true
+	}
 	if len(ignoreLabels) > 0 {
 
 		found := make([]string, 0)
@@ -148,10 +227,39 @@ func (o *SlackBotOptions) isEnabled(activity *record.ActivityRecord, orgs []slac
 		}
 		if len(found) > 0 {
 			log.Logger().Infof("Ignoring %s because it has labels %s\n", activity.Name, found)
-			return false, nil, nil, nil
+			return false
 		}
 	}
-	return true, pr, resolver, nil
+	if len(includeLabels) > 0 && !containsOneOf(pr.Labels, includeLabels...) {
+		log.Logger().Infof("Ignoring %s because it has none of the labels %s\n", activity.Name, includeLabels)
+		return false
+	}
+	return true
+}
+
+// ignoredAuthor returns true if the pull request was opened by one of the ignored authors, which may be globs such as
+// *-bot. Logins are compared ignoring case, and activities which are not for a pull request are never ignored.
+func ignoredAuthor(activity *record.ActivityRecord, pr *gits.GitPullRequest, ignoreAuthors []string) bool {
+	if pr == nil || pr.Author == nil || pr.Author.Login == "" {
+		return false
+	}
+	login := strings.ToLower(pr.Author.Login)
+	for _, pattern := range ignoreAuthors {
+		pattern = strings.ToLower(pattern)
+		// logins such as dependabot[bot] are compared as is, as they would be a character class in a glob. Invalid
+		// patterns are reported by the validation, they never match.
+		matched, _ := path.Match(pattern, login)
+		if matched || pattern == login {
+			log.Logger().Infof("Ignoring %s because it was opened by %s\n", activity.Name, pr.Author.Login)
+			return true
+		}
+	}
+	return false
+}
+
+func isValidAuthorPattern(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return err == nil
 }
 
 func (o *SlackBotOptions) PipelineMessage(activity *record.ActivityRecord) error {
@@ -160,62 +268,275 @@ func (o *SlackBotOptions) PipelineMessage(activity *record.ActivityRecord) error
 		return fmt.Errorf("PipelineActivity name cannot be empty")
 	}
 
+	ctx := context.Background()
+	pa := o.pipelineActivityFor(activity)
+	var errs []error
 	for _, cfg := range o.Pipelines {
-		if enabled, pullRequest, resolver, err := o.isEnabled(activity, cfg.Orgs, cfg.IgnoreLabels); err != nil {
+		if !matchesContext(createPipelineDetails(activity).Context, cfg.Contexts, cfg.IgnoreContexts) {
+			continue
+		}
+		if enabled, pullRequest, resolver, err := o.isEnabled(ctx, activity, cfg); err != nil {
 			return errors.WithStack(err)
 		} else if enabled {
-			attachments, createIfMissing, err := o.createPipelineMessage(activity, pullRequest)
+			statuses := o.statusesFor(cfg, activity)
+			var attachments []slack.Attachment
+			var blocks []slack.Block
+			var createIfMissing bool
+			if o.UseBlockKit {
+				blocks, createIfMissing, err = o.createPipelineBlocks(activity, pa, pullRequest, statuses,
+					cfg.Private)
+			} else {
+				attachments, createIfMissing, err = o.createPipelineMessage(activity, pa, pullRequest, statuses,
+					cfg.Private)
+			}
 			if err != nil {
 				return err
 			}
-			if cfg.Channel != "" {
-				channel := channelName(cfg.Channel)
-				err := o.postMessage(channel, false, pipelineMessageType, activity, nil, attachments, createIfMissing)
+			quiet, err := inQuietHours(o.QuietHours, pipelineStatus(activity), time.Now())
+			if err != nil {
+				return err
+			}
+			if quiet && createIfMissing {
+				// only update the messages which were already posted
+				activityLogger(activity).WithField("messageType", pipelineMessageType).Infof(
+					"Not posting new messages for %s during quiet hours\n", activity.Name)
+				createIfMissing = false
+			}
+			if createIfMissing && !createsOnStatus(cfg, pipelineStatus(activity)) {
+				// only update the messages which were already posted
+				activityLogger(activity).WithField("messageType", pipelineMessageType).Infof(
+					"Not posting new messages for %s with status %s\n", activity.Name, pipelineStatus(activity))
+				createIfMissing = false
+			}
+			for _, channel := range o.pipelineChannels(activity, cfg) {
+				channelAttachments, channelBlocks := attachments, blocks
+				// the status of the message already posted, to detect when the pipeline has just finished
+				previousStatus := o.previousStatus(channel, activity)
+				silent := cfg.NotifyOnFirstFailureOnly && o.reuseFailureMessage(channel, activity)
+				mention := ""
+				if mentionsAuthorOnFailure(cfg) && pullRequest != nil && !silent {
+					mention = o.failureMention(cfg.Workspace, previousStatus, activity, pullRequest, resolver)
+				}
+				if mention != "" {
+					channelAttachments, channelBlocks = withMention(mention, attachments, blocks)
+				}
+				err := o.postMessage(channel, false, pipelineMessageType, activity, nil, channelAttachments,
+					channelBlocks, createIfMissing)
+				if err != nil {
+					// carry on posting to the other channels
+					errs = append(errs, errors.Wrap(err, fmt.Sprintf("error posting cfg for %s to channel %s",
+						activity.Name, channel)))
+					continue
+				}
+				messageLogger(activity, channel, pipelineMessageType).Infof("Channel message sent to %s\n", channel)
+				if cfg.NotifyOnFirstFailureOnly {
+					o.trackFailureNotification(channel, activity)
+				}
+				if o.ReactOnComplete {
+					err = o.reactOnComplete(channel, activity, statuses)
+					if err != nil {
+						errs = append(errs, errors.Wrapf(err, "error reacting to the message for %s in channel %s",
+							activity.Name, channel))
+					}
+				}
+				err = o.pinOnSustainedFailure(channel, activity)
 				if err != nil {
-					return errors.Wrap(err, fmt.Sprintf("error posting cfg for %s to channel %s", activity.Name,
-						channel))
+					errs = append(errs, errors.Wrapf(err, "error pinning the message for %s in channel %s",
+						activity.Name, channel))
+				}
+				err = o.escalate(channel, cfg, activity)
+				if err != nil {
+					errs = append(errs, errors.Wrapf(err, "error escalating the failure of %s in channel %s",
+						activity.Name, channel))
+				}
+				err = o.pingSlowBuild(channel, activity, cfg.Private)
+				if err != nil {
+					errs = append(errs, errors.Wrapf(err, "error warning %s about the slow build of %s", channel,
+						activity.Name))
+				}
+				if o.ThreadStages {
+					err = o.postStageReplies(channel, false, activity, statuses)
+					if err != nil {
+						errs = append(errs, errors.Wrapf(err, "error posting stages for %s to channel %s",
+							activity.Name, channel))
+					}
+					if o.BroadcastTerminalToChannel && finishedSince(previousStatus, activity) {
+						err = o.broadcastTerminalStatus(channel, activity, statuses, cfg.Private)
+						if err != nil {
+							errs = append(errs, errors.Wrapf(err, "error broadcasting the status of %s to channel %s",
+								activity.Name, channel))
+						}
+					}
 				}
-				log.Logger().Infof("Channel message sent to %s\n", cfg.Channel)
 			}
 			if cfg.DirectMessage {
 				if pullRequest != nil {
-					id, err := o.resolveGitUserToSlackUser(pullRequest.Author, resolver)
+					id, err := o.resolveGitUserToSlackUser(cfg.Workspace, pullRequest.Author, resolver)
 					if err != nil {
-						return errors.Wrapf(err, "Cannot resolve Slack ID for Git user %s", pullRequest.Author)
-					}
-					if id != "" {
-						err = o.postMessage(id, true, pipelineMessageType, activity, nil, attachments, createIfMissing)
+						// the direct message needs a Slack ID, the channel messages were still sent
+						activityLogger(activity).Warnf("Not sending direct message for %s, cannot resolve Slack ID "+
+							"for Git user %s: %v", activity.Name, gitLogin(pullRequest.Author), err)
+					} else if id != "" {
+						err = o.postPipelineDirectMessage(workspaceChannel(cfg.Workspace, id), activity, pullRequest,
+							attachments, blocks, statuses, createIfMissing)
 						if err != nil {
-							return errors.Wrap(err, fmt.Sprintf("error sending direct pipeline for %s to %s", activity.Name,
-								id))
+							// the channel messages and the other modes are still reported
+							errs = append(errs, err)
 						}
-						log.Logger().Infof("Direct message sent to %s\n", pullRequest.Author)
 					}
 				}
 			}
 
 		}
 	}
+	if o.Alerter != nil {
+		if err := o.alertOnFailedPromotion(activity, pa); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// postPipelineDirectMessage sends the pipeline message for the activity to the author of the pull request, with its
+// reaction and stage replies
+func (o *SlackBotOptions) postPipelineDirectMessage(id string, activity *record.ActivityRecord, pr *gits.GitPullRequest,
+	attachments []slack.Attachment, blocks []slack.Block, statuses slackapp.Statuses, createIfMissing bool) error {
+	err := o.postMessage(id, true, pipelineMessageType, activity, nil, attachments, blocks, createIfMissing)
+	if err != nil {
+		return errors.Wrapf(err, "error sending direct pipeline for %s to %s", activity.Name, id)
+	}
+	messageLogger(activity, id, pipelineMessageType).Infof("Direct message sent to %s\n", pr.Author)
+	if o.ReactOnComplete {
+		err = o.reactOnComplete(id, activity, statuses)
+		if err != nil {
+			return errors.Wrapf(err, "error reacting to the direct message for %s to %s", activity.Name, id)
+		}
+	}
+	if o.ThreadStages {
+		err = o.postStageReplies(id, true, activity, statuses)
+		if err != nil {
+			return errors.Wrapf(err, "error sending direct stages for %s to %s", activity.Name, id)
+		}
+	}
 	return nil
 }
 
+// previousStatus returns the status of the pipeline when its message was last posted to the channel, or an empty status
+// if none was posted yet
+func (o *SlackBotOptions) previousStatus(channel string, activity *record.ActivityRecord) v1alpha1.PipelineState {
+	if ref := o.messageReference(channel, activity.Name); ref != nil {
+		return ref.Status
+	}
+	return ""
+}
+
+// createsOnStatus returns true if a new message can be posted for a pipeline with the status, rather than only updating
+// the message already posted
+func createsOnStatus(cfg slackapp.SlackBotMode, status v1alpha1.PipelineState) bool {
+	return len(cfg.CreateOnStatuses) == 0 || containsIgnoreCase(cfg.CreateOnStatuses, string(status))
+}
+
+// skipPendingState returns the modes with the statuses other than pending as the default of their CreateOnStatuses,
+// so that the pipelines are only posted once they are no longer pending
+func skipPendingState(modes []slackapp.SlackBotMode) []slackapp.SlackBotMode {
+	answer := make([]slackapp.SlackBotMode, 0, len(modes))
+	for _, mode := range modes {
+		if len(mode.CreateOnStatuses) == 0 {
+			for _, state := range knownPipelineStates {
+				if state != string(v1alpha1.PendingState) {
+					mode.CreateOnStatuses = append(mode.CreateOnStatuses, state)
+				}
+			}
+		}
+		answer = append(answer, mode)
+	}
+	return answer
+}
+
+// finishedSince returns true if the pipeline has finished since its message was posted with the previous status
+func finishedSince(previousStatus v1alpha1.PipelineState, activity *record.ActivityRecord) bool {
+	return isTerminalState(pipelineStatus(activity)) && !isTerminalState(previousStatus)
+}
+
+// failureMention returns the mention of the author of the pull request when the pipeline has just failed or been
+// aborted, or an empty string if the message posted to the channel with the previous status already reported the
+// failure. The Git login of the author is used if they can't be resolved to a Slack user.
+func (o *SlackBotOptions) failureMention(workspace string, previousStatus v1alpha1.PipelineState,
+	activity *record.ActivityRecord, pr *gits.GitPullRequest, resolver *users.GitUserResolver) string {
+	if !isFailedState(pipelineStatus(activity)) {
+		return ""
+	}
+	if isFailedState(previousStatus) {
+		// the author was already mentioned when the pipeline failed, don't ping them again
+		return ""
+	}
+	id, err := o.resolveGitUserToSlackUser(workspace, pr.Author, resolver)
+	if err != nil {
+		log.Logger().Warnf("failed to resolve the author of %s to mention: %v", activity.Name, err)
+		return gitLogin(pr.Author)
+	}
+	if id == "" {
+		return ""
+	}
+	return mentionUser(id)
+}
+
+// authorMention returns the mention of the author, or an empty string if they don't have a Slack account in the
+// workspace
+func (o *SlackBotOptions) authorMention(workspace string, author *jenkinsv1.User) (string, error) {
+	if author == nil {
+		return "", nil
+	}
+	id, err := o.slackUserID(workspace, author)
+	if err != nil || id == "" {
+		return "", err
+	}
+	return mentionUser(id), nil
+}
+
+func isFailedState(state v1alpha1.PipelineState) bool {
+	return state == v1alpha1.FailureState || state == v1alpha1.AbortedState
+}
+
+// withMention returns copies of the attachments and blocks with the mention prepended to the message
+func withMention(mention string, attachments []slack.Attachment, blocks []slack.Block) ([]slack.Attachment,
+	[]slack.Block) {
+	if len(blocks) > 0 {
+		section := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, mention, false, false), nil, nil)
+		return attachments, append([]slack.Block{section}, blocks...)
+	}
+	if len(attachments) > 0 {
+		attachments = append([]slack.Attachment{}, attachments...)
+		attachments[0].Pretext = mention
+	}
+	return attachments, blocks
+}
+
 func (o *SlackBotOptions) ReviewRequestMessage(activity *record.ActivityRecord) error {
 
 	if activity.Name == "" {
 		return fmt.Errorf("PipelineActivity name cannot be empty")
 	}
 
-	prn, err := getPullRequestNumber(activity)
+	prn, err := o.getPullRequestNumber(activity)
 	if err != nil {
 		return errors.Wrapf(err, "getting pull request number %s", activity.Name)
 	}
+	ctx := context.Background()
+	var errs []error
 	if prn > 0 {
+		pa := o.pipelineActivityFor(activity)
 		for _, cfg := range o.PullRequests {
-			if enabled, pullRequest, resolver, err := o.isEnabled(activity, cfg.Orgs, cfg.IgnoreLabels); err != nil {
+			if enabled, pullRequest, resolver, err := o.isEnabled(ctx, activity, cfg); err != nil {
 				return errors.WithStack(err)
+			} else if enabled && cfg.IgnoreDrafts && isDraft(pullRequest) {
+				// the message is posted by the first event once the pull request is ready for review
+				activityLogger(activity).WithField("messageType", pullRequestReviewMessageType).Debugf(
+					"Ignoring %s because its pull request is a draft\n", activity.Name)
 			} else if enabled {
-				log.Logger().Infof("Preparing review request message for %s\n", activity.Name)
-				oldestActivity, latestActivity, all, err := o.findPipelineActivities(activity)
+				activityLogger(activity).WithField("messageType", pullRequestReviewMessageType).Infof(
+					"Preparing review request message for %s\n", activity.Name)
+				oldestActivity, latestActivity, all, err := o.findPipelineActivities(ctx, activity)
 				if err != nil {
 					return err
 				}
@@ -235,88 +556,186 @@ func (o *SlackBotOptions) ReviewRequestMessage(activity *record.ActivityRecord)
 					oldestActivity = activity
 				}
 				if buildNumber >= latestBuildNumber {
-					attachments, reviewers, buildStatus, err := o.createReviewersMessage(activity, cfg.NotifyReviewers,
-						pullRequest, resolver)
+					statuses := o.statusesFor(cfg, activity)
+					attachments, reviewers, buildStatus, err := o.createReviewersMessage(activity, pa,
+						pullRequest, resolver, statuses, reviewMessageOptions{
+							listReviewers: listsReviewers(cfg),
+							mention:       mentionsReviewers(cfg, activity),
+							showPRSize:    cfg.ShowPRSize,
+							private:       cfg.Private,
+							workspace:     cfg.Workspace,
+						})
 					if err != nil {
 						return err
 					}
+					if cfg.ShowBuildHistory && len(attachments) > 0 {
+						attachments[0].Fields = append(attachments[0].Fields, buildHistoryFields(all, statuses)...)
+					}
 					createIfMissing := true
-					if buildStatus == defaultStatuses.Merged || buildStatus == defaultStatuses.Closed {
+					prClosed := buildStatus == getStatus(statuses.Merged, defaultStatuses.Merged) ||
+						buildStatus == getStatus(statuses.Closed, defaultStatuses.Closed)
+					if prClosed {
 						createIfMissing = false
 					}
-					if attachments != nil {
-						if cfg.Channel != "" {
-							channel := channelName(cfg.Channel)
-							err := o.postMessage(channel, false, pullRequestReviewMessageType, oldestActivity,
-								all, attachments, createIfMissing)
+					if prClosed && cfg.DeleteOnClose {
+						for _, channel := range activityChannels(activity, cfg) {
+							err := o.DeleteMessage(channel, oldestActivity.Name)
 							if err != nil {
-								return errors.Wrap(err, fmt.Sprintf("error posting PR review request for %s to channel %s",
-									activity.Name,
-									channel))
+								errs = append(errs, errors.Wrapf(err,
+									"error deleting PR review request for %s in channel %s", activity.Name, channel))
 							}
 						}
 						if cfg.DirectMessage && cfg.NotifyReviewers {
 							for _, user := range reviewers {
 								if user != nil {
-									err = o.postMessage(user.ID, true, pullRequestReviewMessageType, oldestActivity,
-										all, attachments, createIfMissing)
+									err := o.DeleteMessage(workspaceChannel(cfg.Workspace, user.ID),
+										oldestActivity.Name)
 									if err != nil {
-										return errors.Wrap(err, fmt.Sprintf("error sending direct PR review request for %s to %s",
-											activity.Name,
+										errs = append(errs, errors.Wrapf(err,
+											"error deleting direct PR review request for %s to %s", activity.Name,
 											user.ID))
 									}
 								}
 							}
+						}
+						continue
+					}
+					var blocks []slack.Block
+					if o.UseBlockKit && attachments != nil {
+						blocks = attachmentsToBlocks(attachments)
+						attachments = nil
+						if o.ShowReviewerAvatars {
+							blocks = append(blocks, o.reviewerAvatarsBlock(cfg.Workspace, reviewers)...)
+						}
+					}
+					if attachments != nil || blocks != nil {
+						requested := requestedReviewerLogins(pullRequest)
+						// the mentions added to a message already posted don't notify the reviewers
+						updated := make(map[string]bool)
+						for _, channel := range activityChannels(activity, cfg) {
+							o.invalidateOnReviewersChange(channel, oldestActivity.Name, requested)
+							updated[channel] = o.messageReference(channel, oldestActivity.Name) != nil
+							err := o.postMessage(channel, false, pullRequestReviewMessageType, oldestActivity,
+								all, attachments, blocks, createIfMissing)
+							if err != nil {
+								// carry on posting to the other channels
+								errs = append(errs, errors.Wrap(err, fmt.Sprintf(
+									"error posting PR review request for %s to channel %s", activity.Name, channel)))
+								continue
+							}
+							o.storeRenderedReviewers(channel, oldestActivity.Name, requested)
+						}
+						if (cfg.ReviewerThreadReplies || cfg.NotifyReviewersAfterGreen) && cfg.NotifyReviewers {
+							for _, channel := range activityChannels(activity, cfg) {
+								if !cfg.ReviewerThreadReplies && !updated[channel] {
+									// the message was posted once the build succeeded, with the mentions
+									continue
+								}
+								err := o.postReviewerThreadReply(channel, oldestActivity, reviewers)
+								if err != nil {
+									errs = append(errs, errors.Wrap(err, fmt.Sprintf(
+										"error notifying reviewers of %s in a thread in channel %s", activity.Name,
+										channel)))
+								}
+							}
+						}
+						if cfg.DirectMessage && cfg.NotifyReviewers {
+							for _, user := range reviewers {
+								if user != nil {
+									err = o.postMessage(workspaceChannel(cfg.Workspace, user.ID), true,
+										pullRequestReviewMessageType, oldestActivity, all, attachments, blocks,
+										createIfMissing)
+									if err != nil {
+										// carry on sending to the other reviewers
+										errs = append(errs, errors.Wrap(err, fmt.Sprintf(
+											"error sending direct PR review request for %s to %s", activity.Name,
+											user.ID)))
+										continue
+									}
+								}
+							}
 
 						}
 					}
 				} else {
-					log.Logger().Infof("Skipping %v as it is older than latest build number %d\n", activity.Name,
-						latestBuildNumber)
+					activityLogger(activity).WithField("messageType", pullRequestReviewMessageType).Infof(
+						"Skipping %v as it is older than latest build number %d\n", activity.Name, latestBuildNumber)
 				}
 			}
 		}
 	}
-	return nil
+	return utilerrors.NewAggregate(errs)
 }
 
-func (o *SlackBotOptions) isLgtmRepo(activity *record.ActivityRecord) (bool, error) {
+// keeperState checks the Keeper queries configured for the repository of the activity, returning whether the
+// repository uses the lgtm label and whether the pull request satisfies one of the queries (and so is in the merge pool)
+func (o *SlackBotOptions) keeperState(activity *record.ActivityRecord, pr *gits.GitPullRequest) (lgtmRepo bool,
+	inPool bool, err error) {
 	options := prow.Options{
 		KubeClient: o.KubeClient,
 		NS:         o.Namespace,
 	}
 	cfg, _, err := options.GetProwConfig()
 	if err != nil {
-		return false, errors.Wrapf(err, "getting prow config")
+		return false, false, errors.Wrapf(err, "getting prow config")
 	}
 	pipeDetails := createPipelineDetails(activity)
 	for _, query := range cfg.Keeper.Queries {
 		if query.ForRepo(pipeDetails.GitOwner, pipeDetails.GitRepository) {
 			if util.Contains(query.Labels, "lgtm") {
-				return true, nil
+				lgtmRepo = true
 			}
+			if matchesKeeperQuery(pr, query.Labels, query.MissingLabels) {
+				inPool = true
+			}
+		}
+	}
+	return lgtmRepo, inPool, nil
+}
+
+// matchesKeeperQuery returns true if the pull request is open and carries all the labels and none of the missing
+// labels of a Keeper query
+func matchesKeeperQuery(pr *gits.GitPullRequest, labels []string, missingLabels []string) bool {
+	if pr == nil || (pr.Merged != nil && *pr.Merged) || pr.ClosedAt != nil {
+		return false
+	}
+	for _, l := range labels {
+		if !containsOneOf(pr.Labels, l) {
+			return false
 		}
 	}
-	return false, nil
+	return !containsOneOf(pr.Labels, missingLabels...)
 }
 
-func (o *SlackBotOptions) findPipelineActivities(activity *record.ActivityRecord) (oldest *record.ActivityRecord, latest *record.ActivityRecord, all []*record.ActivityRecord, err error) {
+// hasMergeMethodLabel returns true if Keeper has been told how to merge the pull request
+func hasMergeMethodLabel(pr *gits.GitPullRequest) bool {
+	for _, l := range pr.Labels {
+		if l.Name != nil && strings.HasPrefix(*l.Name, mergeMethodLabelPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *SlackBotOptions) findPipelineActivities(ctx context.Context, activity *record.ActivityRecord) (oldest *record.ActivityRecord, latest *record.ActivityRecord, all []*record.ActivityRecord, err error) {
 	// This is the trigger activity. Working out the correct slack message is a bit tricky,
 	// as we have a 1:n mapping between PRs and PipelineActivities (which store the message info).
 	// The algorithm in use just picks the earliest pipeline activity as determined by build number
-	prn, err := getPullRequestNumber(activity)
+	prn, err := o.getPullRequestNumber(activity)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
 	pipelineDetails := createPipelineDetails(activity)
 
-	acts, err := o.getPipelineActivities(pipelineDetails.GitOwner, pipelineDetails.GitRepository, prn)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+	acts, err := o.getPipelineActivities(ctx, pipelineDetails.GitOwner, pipelineDetails.GitRepository, prn)
 
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	sort.Sort(byBuildNumber(acts.Items))
+	sortPipelineActivities(acts.Items)
 	var records []*record.ActivityRecord
 	for _, a := range acts.Items {
 		rec, err := jx.ConvertPipelineActivity(&a)
@@ -341,11 +760,29 @@ func getStatus(overrideStatus *slackapp.Status, defaultStatus *slackapp.Status)
 	return overrideStatus
 }
 
+// reviewMessageOptions are the options of the mode a review message is rendered for
+type reviewMessageOptions struct {
+	// listReviewers lists the requested reviewers in the message
+	listReviewers bool
+	// mention notifies the listed reviewers and the author, they are only named otherwise
+	mention bool
+	// showPRSize shows the size of the pull request
+	showPRSize bool
+	// private hides the title of the pull request and the name of its repository
+	private bool
+	// workspace is the Slack workspace the message is posted to, whose users are mentioned
+	workspace string
+}
+
 // createReviewersMessage will return a slackapp message notifying reviewers of a PR, or nil if the activity is not a PR
-func (o *SlackBotOptions) createReviewersMessage(activity *record.ActivityRecord, notifyReviewers bool, pr *gits.GitPullRequest, resolver *users.GitUserResolver) ([]slack.Attachment, []*slack.User, *slackapp.Status, error) {
+func (o *SlackBotOptions) createReviewersMessage(activity *record.ActivityRecord, pa *jenkinsv1.PipelineActivity,
+	pr *gits.GitPullRequest, resolver *users.GitUserResolver, statuses slackapp.Statuses,
+	opts reviewMessageOptions) ([]slack.Attachment, []*slack.User, *slackapp.Status, error) {
 	author, err := resolver.Resolve(pr.Author)
 	if err != nil {
-		return nil, nil, nil, errors.WithStack(err)
+		// a Git API hiccup shouldn't drop the message, the author is shown by their Git login instead
+		log.Logger().Warnf("failed to resolve the author of %s: %v", activity.Name, err)
+		author = nil
 	}
 	if pr != nil {
 		attachments := []slack.Attachment{}
@@ -353,94 +790,148 @@ func (o *SlackBotOptions) createReviewersMessage(activity *record.ActivityRecord
 		fallback := []string{}
 		status := pipelineStatus(activity)
 
-		authorName, err := o.mentionOrLinkUser(author)
-		if err != nil {
-			return nil, nil, nil, err
+		authorName := ""
+		if pr.Author != nil {
+			if id := o.mappedSlackUser(pr.Author.Login); id != "" {
+				authorName = mentionUser(id)
+			}
+		}
+		if authorName == "" {
+			authorName = o.mentionOrLinkUser(opts.workspace, author)
+		}
+		if authorName == "" {
+			authorName = gitLogin(pr.Author)
+		}
+		if !opts.mention {
+			// the author is named without being notified
+			authorName = linkUser(author, pr.Author)
 		}
 
 		mentions := make([]string, 0)
 		reviewers := make([]*slack.User, 0)
-		if notifyReviewers {
+		if opts.listReviewers {
 
 			// Match requested requested reviewers to slack users (if possible)
 			for _, r := range pr.RequestedReviewers {
+				if !opts.mention {
+					// the reviewers are named, but not returned as they aren't notified
+					mentions = append(mentions, o.linkReviewer(r, resolver))
+					continue
+				}
+				if team, ok := o.teamReviewer(r); ok {
+					if mention := o.userGroupMention(team); mention != "" {
+						mentions = append(mentions, mention)
+					} else {
+						log.Logger().Infof("No Slack user group configured for team %s\n", team)
+					}
+					continue
+				}
+				if id := o.mappedSlackUser(r.Login); id != "" {
+					mentions = append(mentions, mentionUser(id))
+					reviewers = append(reviewers, &slack.User{ID: id})
+					continue
+				}
 				u, err := resolver.Resolve(r)
 				if err != nil {
-					return nil, nil, nil, errors.Wrapf(err, "resolving %s user %s as Jenkins X user",
-						resolver.GitProviderKey(), r.Login)
+					log.Logger().Warnf("failed to resolve %s user %s as Jenkins X user, showing their login: %v",
+						resolver.GitProviderKey(), r.Login, err)
+					mentions = append(mentions, r.Login)
+					continue
 				}
 				if u != nil {
-					mention, err := o.mentionOrLinkUser(u)
+					mention := o.mentionOrLinkUser(opts.workspace, u)
+					// the reviewers are only sent direct messages if they have a Slack ID
+					id, err := o.slackUserID(opts.workspace, u)
 					if err != nil {
-						return nil, nil, nil, errors.Wrapf(err,
-							"generating mention or link for user record %s with email %s", u.Name, u.Spec.Email)
+						log.Logger().Warnf("failed to resolve the Slack user of user record %s: %v", u.Name, err)
+					} else if id != "" {
+						reviewers = append(reviewers, &slack.User{ID: id})
 					}
 					mentions = append(mentions, mention)
 				}
 			}
 		}
 
-		// The default state is not approved
-		reviewStatus := getStatus(o.Statuses.NotApproved, defaultStatuses.NotApproved)
-
-		// A bit of a hacky way to do this,
-		// but until we get a better CRD based interface to the prow this will work
-		lgtmRepo, err := o.isLgtmRepo(activity)
+		reviewStatus, needsRebase, err := o.reviewStatus(activity, pr, statuses)
 		if err != nil {
-			return nil, nil, nil, errors.Wrapf(err, "checking if repo for %s is configured for lgtm", activity.Name)
-		}
-		if lgtmRepo {
-			if containsOneOf(pr.Labels, "lgtm") {
-				reviewStatus = getStatus(o.Statuses.LGTM, defaultStatuses.LGTM)
-			}
-		} else {
-			if containsOneOf(pr.Labels, "approved") {
-				reviewStatus = getStatus(o.Statuses.Approved, defaultStatuses.Approved)
-			}
-		}
-		if containsOneOf(pr.Labels, "do-not-merge/hold") {
-			reviewStatus = getStatus(o.Statuses.Hold, defaultStatuses.Hold)
-		}
-		if containsOneOf(pr.Labels, "needs-ok-to-test") {
-			reviewStatus = getStatus(o.Statuses.NeedsOkToTest, defaultStatuses.NeedsOkToTest)
+			return nil, nil, nil, err
 		}
 
 		// The default build state is unknown
-		buildStatus := getStatus(o.Statuses.Unknown, defaultStatuses.Unknown)
+		buildStatus := getStatus(statuses.Unknown, defaultStatuses.Unknown)
+		state := ""
 		if pr.Merged != nil && *pr.Merged {
-			buildStatus = getStatus(o.Statuses.Merged, defaultStatuses.Merged)
+			buildStatus = getStatus(statuses.Merged, defaultStatuses.Merged)
+			state = "merged"
 		} else if pr.IsClosed() {
-			buildStatus = getStatus(o.Statuses.Closed, defaultStatuses.Closed)
+			buildStatus = getStatus(statuses.Closed, defaultStatuses.Closed)
+			state = "closed"
 		} else {
 			switch activity.Status {
 			case v1alpha1.PendingState:
-				buildStatus = getStatus(o.Statuses.Pending, defaultStatuses.Pending)
+				buildStatus = getStatus(statuses.Pending, defaultStatuses.Pending)
 			case v1alpha1.RunningState:
-				buildStatus = getStatus(o.Statuses.Running, defaultStatuses.Running)
+				buildStatus = getStatus(statuses.Running, defaultStatuses.Running)
 			case v1alpha1.SuccessState:
-				buildStatus = getStatus(o.Statuses.Succeeded, defaultStatuses.Succeeded)
+				buildStatus = getStatus(statuses.Succeeded, defaultStatuses.Succeeded)
 			case v1alpha1.FailureState:
-				buildStatus = getStatus(o.Statuses.Failed, defaultStatuses.Failed)
+				buildStatus = getStatus(statuses.Failed, defaultStatuses.Failed)
 			case v1alpha1.AbortedState:
-				buildStatus = getStatus(o.Statuses.Aborted, defaultStatuses.Aborted)
+				buildStatus = getStatus(statuses.Aborted, defaultStatuses.Aborted)
+			}
+			// a skipped pipeline never starts, it would otherwise be shown as pending forever
+			if pipelineSkipped(activity, pa) {
+				buildStatus = getStatus(statuses.Skipped, defaultStatuses.Skipped)
 			}
 		}
 
-		mentionsString := strings.Join(mentions, " ")
-		pleaseText := "please"
-		if len(mentions) == 0 {
-			pleaseText = "Please"
+		var gitKind string
+		if resolver != nil && resolver.GitProvider != nil {
+			gitKind = resolver.GitProvider.Kind()
+		} else {
+			gitKind = o.gitKind(pr.URL)
+		}
+		prName := fmt.Sprintf("Pull Request %s", pullRequestNameForKind(gitKind, pr.URL))
+		// the full title is kept in the fallback
+		prLink := link(fmt.Sprintf("%s (%s)", prName, o.truncateTitle(pr.Title)), pr.URL)
+		repo := repositoryName(activity)
+		if opts.private {
+			prLink = link(prName, pr.URL)
+			repo = privateRepositoryName(activity)
+		}
+		fallback = append(fallback, reviewFallback(prName, pr, opts.private, reviewStatus, state))
+		messageText, err := o.reviewMessageText(reviewMessageData{
+			Mentions: strings.Join(mentions, " "),
+			PRLink:   prLink,
+			Repo:     repo,
+			Author:   authorName,
+			Status:   fmt.Sprintf("%s %s", reviewStatus.Emoji, reviewStatus.Text),
+			State:    state,
+		})
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "rendering review message for %s", activity.Name)
+		}
+		if needsRebase && state == "" && o.MentionAuthorOnRebase && opts.mention {
+			mention, err := o.authorMention(opts.workspace, author)
+			if err != nil {
+				log.Logger().Warnf("failed to resolve the author of %s to mention: %v", activity.Name, err)
+			}
+			if mention != "" {
+				messageText = fmt.Sprintf("%s %s", mention, messageText)
+			}
+		}
+		if previewURL := previewApplicationURL(pa); previewURL != "" {
+			fallback = append(fallback, "Preview: "+previewURL)
+			actions = append(actions, slack.AttachmentAction{
+				Type: "button",
+				Text: "Preview",
+				URL:  previewURL,
+			})
 		}
-		messageText := fmt.Sprintf("%s %s review %s created on %s by %s",
-			mentionsString,
-			pleaseText,
-			link(fmt.Sprintf("Pull Request %s (%s)", pullRequestName(pr.URL), pr.Title), pr.URL),
-			repositoryName(activity),
-			authorName)
 		attachment := slack.Attachment{
 			CallbackID: "preview:" + activity.Name,
-			Color:      attachmentColor(status),
-			Text:       messageText,
+			Color:      statusColor(statuses, status),
+			Text:       o.decorateTitle(messageText),
 
 			Fallback: strings.Join(fallback, ", "),
 			Actions:  actions,
@@ -455,6 +946,25 @@ func (o *SlackBotOptions) createReviewersMessage(activity *record.ActivityRecord
 				},
 			},
 		}
+		// the progress is only known when both counts are labelled, the review status tells if it is approved otherwise
+		if approvals, required, ok := o.approvalProgress(pr); ok && state == "" {
+			attachment.Fields = append(attachment.Fields, slack.AttachmentField{
+				Value: ":ballot_box_with_check: " + fmt.Sprintf(o.messageCatalog().approvalProgress, approvals, required),
+				Short: true,
+			})
+		}
+		if !opts.private {
+			// the issue keys are taken from the title, which private repositories don't show
+			attachment.Fields = append(attachment.Fields, o.issueFields(pr)...)
+		}
+		if opts.showPRSize {
+			if size := pullRequestSize(pr); size != "" {
+				attachment.Fields = append(attachment.Fields, slack.AttachmentField{
+					Value: fmt.Sprintf("%s size/%s", pullRequestSizeEmojis[size], size),
+					Short: true,
+				})
+			}
+		}
 		updatedEpochTime := getLastUpdatedTime(pr, activity)
 		if updatedEpochTime > 0 {
 			attachment.Ts = json.Number(strconv.FormatInt(updatedEpochTime, 10))
@@ -467,6 +977,100 @@ func (o *SlackBotOptions) createReviewersMessage(activity *record.ActivityRecord
 	return nil, nil, nil, nil
 }
 
+// DefaultMaxTitleLength is the number of characters the titles of the pull requests are truncated to by default
+const DefaultMaxTitleLength = 120
+
+// truncateTitle truncates the title to MaxTitleLength characters, ending it with an ellipsis
+func (o *SlackBotOptions) truncateTitle(title string) string {
+	max := o.MaxTitleLength
+	if max == 0 {
+		max = DefaultMaxTitleLength
+	}
+	return truncateText(title, max)
+}
+
+// truncateText truncates the text to max characters, ending with an ellipsis, the text is unchanged if max is negative
+func truncateText(text string, max int) string {
+	runes := []rune(text)
+	if max < 0 || len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// reviewFallback returns the plain text summary of the review message, shown by the notifications and the screen
+// readers, e.g. "Pull Request #123 (Add cheese) by alice: not approved"
+func reviewFallback(prName string, pr *gits.GitPullRequest, private bool, reviewStatus *slackapp.Status,
+	state string) string {
+	summary := prName
+	if !private && pr.Title != "" {
+		summary = fmt.Sprintf("%s (%s)", summary, pr.Title)
+	}
+	if author := gitLogin(pr.Author); author != "" {
+		summary = fmt.Sprintf("%s by %s", summary, author)
+	}
+	if state != "" {
+		return fmt.Sprintf("%s: %s", summary, state)
+	}
+	return fmt.Sprintf("%s: %s", summary, reviewStatus.Text)
+}
+
+// reviewStatus returns the review status of the pull request from its labels and the Keeper merge pool, and whether it
+// needs a rebase
+func (o *SlackBotOptions) reviewStatus(activity *record.ActivityRecord, pr *gits.GitPullRequest,
+	statuses slackapp.Statuses) (*slackapp.Status, bool, error) {
+	// The default state is not approved
+	reviewStatus := getStatus(statuses.NotApproved, defaultStatuses.NotApproved)
+
+	// A bit of a hacky way to do this,
+	// but until we get a better CRD based interface to the prow this will work
+	lgtmRepo, inKeeperPool, err := o.keeperState(activity, pr)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "checking if repo for %s is configured for lgtm", activity.Name)
+	}
+	if lgtmRepo {
+		if containsOneOf(pr.Labels, "lgtm") {
+			reviewStatus = getStatus(statuses.LGTM, defaultStatuses.LGTM)
+		}
+	} else {
+		if containsOneOf(pr.Labels, "approved") {
+			reviewStatus = getStatus(statuses.Approved, defaultStatuses.Approved)
+		}
+	}
+	if containsOneOf(pr.Labels, "do-not-merge/hold") {
+		reviewStatus = getStatus(statuses.Hold, defaultStatuses.Hold)
+	}
+	if containsOneOf(pr.Labels, "needs-ok-to-test") {
+		reviewStatus = getStatus(statuses.NeedsOkToTest, defaultStatuses.NeedsOkToTest)
+	}
+	needsRebase := containsOneOf(pr.Labels, "needs-rebase")
+	if needsRebase {
+		reviewStatus = getStatus(statuses.Rebase, defaultStatuses.Rebase)
+	}
+	// once merged the build status tells it, the review status isn't left queued or merging
+	if !pr.IsClosed() && !(pr.Merged != nil && *pr.Merged) {
+		if hasMergeMethodLabel(pr) {
+			reviewStatus = getStatus(statuses.Merging, defaultStatuses.Merging)
+		}
+		if inKeeperPool {
+			reviewStatus = getStatus(statuses.Queued, defaultStatuses.Queued)
+		}
+	}
+	return reviewStatus, needsRebase, nil
+}
+
+// decorateTitle adds the MessagePrefix and MessageSuffix around the title of a message, such as an environment marker
+// telling apart the messages of several bots posting to the same channel
+func (o *SlackBotOptions) decorateTitle(title string) string {
+	if o.MessagePrefix != "" {
+		title = o.MessagePrefix + " " + title
+	}
+	if o.MessageSuffix != "" {
+		title = title + " " + o.MessageSuffix
+	}
+	return title
+}
+
 func getLastUpdatedTime(pr *gits.GitPullRequest, activity *record.ActivityRecord) int64 {
 	updatedEpochTime := int64(-1)
 	if pr != nil && pr.UpdatedAt != nil {
@@ -486,6 +1090,38 @@ func getLastUpdatedTime(pr *gits.GitPullRequest, activity *record.ActivityRecord
 	return updatedEpochTime
 }
 
+// pullRequestSizeEmojis are the emojis of the sizes of the pull requests, from the smallest to the largest
+var pullRequestSizeEmojis = map[string]string{
+	"XS":  ":mouse:",
+	"S":   ":rabbit:",
+	"M":   ":dog:",
+	"L":   ":horse:",
+	"XL":  ":elephant:",
+	"XXL": ":whale:",
+}
+
+// pullRequestSize returns the size of the pull request from its size/* label, such as the size/M label added by the
+// size plugin, or an empty string if the size is unknown
+func pullRequestSize(pr *gits.GitPullRequest) string {
+	if pr == nil {
+		return ""
+	}
+	for _, label := range pr.Labels {
+		if label == nil || label.Name == nil {
+			continue
+		}
+		name := strings.ToLower(*label.Name)
+		if !strings.HasPrefix(name, "size/") {
+			continue
+		}
+		size := strings.ToUpper(strings.TrimPrefix(name, "size/"))
+		if _, ok := pullRequestSizeEmojis[size]; ok {
+			return size
+		}
+	}
+	return ""
+}
+
 func containsOneOf(a []*gits.Label, x ...string) bool {
 	for _, n := range a {
 		for _, y := range x {
@@ -497,72 +1133,78 @@ func containsOneOf(a []*gits.Label, x ...string) bool {
 	return false
 }
 
-func (o *SlackBotOptions) createPipelineMessage(activity *record.ActivityRecord, pr *gits.GitPullRequest) ([]slack.Attachment, bool, error) {
+func (o *SlackBotOptions) createPipelineMessage(activity *record.ActivityRecord, pa *jenkinsv1.PipelineActivity,
+	pr *gits.GitPullRequest, statuses slackapp.Statuses, private bool) ([]slack.Attachment, bool, error) {
 	status := pipelineStatus(activity)
 	icon := pipelineIcon(status)
-	pipelineName, err := pipelineName(activity)
+	repo := repositoryName(activity)
+	if private {
+		repo = privateRepositoryName(activity)
+	}
+	pipelineTitle, err := o.pipelineTitle(activity, repo)
 	if err != nil {
 		return nil, false, errors.Wrapf(err, "getting pipeline name for %s", activity.Name)
 	}
-	messageText := icon + pipelineName + " " + repositoryName(activity)
-	if prn, err := getPullRequestNumber(activity); err != nil {
+	messageText := icon + pipelineTitle
+	if prn, err := o.getPullRequestNumber(activity); err != nil {
 		return nil, false, err
-	} else if prn > 0 {
-		messageText = fmt.Sprintf("%s%s", messageText, link(pullRequestName(pr.URL), pr.URL))
+	} else if prn > 0 && pr != nil {
+		messageText = fmt.Sprintf("%s%s", messageText, link(pullRequestNameForKind(o.gitKind(activity.GitURL), pr.URL),
+			pr.URL))
 	}
 	messageText = fmt.Sprintf("%s (Build %s)", messageText, buildNumber(activity))
 
 	attachments := []slack.Attachment{}
-	actions := []slack.AttachmentAction{}
-	fallback := []string{}
-	if activity.GitURL != "" {
-		fallback = append(fallback, "Repo: "+activity.GitURL)
-		actions = append(actions, slack.AttachmentAction{
-			Type: "button",
-			Text: "Repository",
-			URL:  activity.GitURL,
-		})
-	}
-	if activity.LinkURL != "" {
-		fallback = append(fallback, "Build: "+activity.LinkURL)
-		actions = append(actions, slack.AttachmentAction{
-			Type: "button",
-			Text: "Pipeline",
-			URL:  activity.LinkURL,
-		})
-	}
-	if activity.LogURL != "" {
-		fallback = append(fallback, "Logs: "+activity.LogURL)
-		actions = append(actions, slack.AttachmentAction{
-			Type: "button",
-			Text: "Build Logs",
-			URL:  strings.Replace(activity.LogURL, "gs://", "https://storage.cloud.google.com/", -1),
-		})
+	actions, fallback := o.pipelineButtons(activity, pa, private)
+	if o.RerunButton && status == v1alpha1.FailureState {
+		actions = append(actions, rerunAction())
 	}
 	attachment := slack.Attachment{
-		CallbackID: "pipelineactivity:" + activity.Name,
-		Color:      attachmentColor(status),
-		Title:      messageText,
+		CallbackID: PipelineActivityCallbackPrefix + ":" + activity.Name,
+		Color:      statusColor(statuses, status),
+		Title:      o.decorateTitle(messageText),
 		Fallback:   strings.Join(fallback, ", "),
 		Actions:    actions,
 	}
+	if o.ShowCommitInfo && !private {
+		attachment.Footer = commitFooter(activity, pr)
+	}
+	if o.ShowTestResults {
+		attachment.Fields = append(attachment.Fields, testResultFields(pa)...)
+	}
+	if o.ShowBuildDuration {
+		attachment.Fields = append(attachment.Fields, o.buildDurationField(activity, time.Now())...)
+	}
+	if o.ShowCommitMessage && !private {
+		attachment.Fields = append(attachment.Fields, o.commitMessageField(pa)...)
+	}
+	attachment.Fields = append(attachment.Fields, o.slowBuildField(activity)...)
 
 	lastUpdatedTime := getLastUpdatedTime(nil, activity)
 	if lastUpdatedTime > 0 {
 		attachment.Ts = json.Number(strconv.FormatInt(lastUpdatedTime, 10))
 	}
-	dayAgo := time.Now().Add(time.Duration((-24) * time.Hour)).Unix()
 	createIfMissing := true
-	if lastUpdatedTime < dayAgo {
-		createIfMissing = false
+	if o.CreateIfMissingWindow > 0 {
+		cutoff := time.Now().Add(-o.CreateIfMissingWindow).Unix()
+		if lastUpdatedTime < cutoff {
+			createIfMissing = false
+		}
 	}
 
 	attachments = append(attachments, attachment)
 
-	for _, step := range activity.Stages {
-		stepAttachments := o.createAttachments(activity, step)
-		if len(stepAttachments) > 0 {
-			attachments = append(attachments, stepAttachments...)
+	// when stages are threaded they are posted as replies to this message instead
+	if !o.ThreadStages {
+		if o.CollapseSucceededStages {
+			attachments = append(attachments, o.createCollapsedStageAttachments(activity, statuses)...)
+		} else {
+			for _, step := range activity.Stages {
+				stepAttachments := o.createAttachments(activity, step, statuses)
+				if len(stepAttachments) > 0 {
+					attachments = append(attachments, stepAttachments...)
+				}
+			}
 		}
 	}
 
@@ -582,86 +1224,294 @@ func (o *SlackBotOptions) getSlackUserID(gitUser *gits.GitUser, resolver *users.
 }
 
 // getPullRequestNumber extracts the pull request number from the activity or returns 0 if it's not a pull request
-func getPullRequestNumber(activity *record.ActivityRecord) (int, error) {
+func (o *SlackBotOptions) getPullRequestNumber(activity *record.ActivityRecord) (int, error) {
 	pipelineDetails := createPipelineDetails(activity)
-	if strings.HasPrefix(strings.ToLower(pipelineDetails.BranchName), "pr-") {
-		return strconv.Atoi(strings.TrimPrefix(strings.ToLower(pipelineDetails.BranchName), "pr-"))
+	branch := strings.ToLower(pipelineDetails.BranchName)
+	if strings.HasPrefix(branch, "pr-") {
+		return strconv.Atoi(strings.TrimPrefix(branch, "pr-"))
+	}
+	// GitLab merge requests may be built from MR-N branches, on other providers these are regular branches
+	if strings.HasPrefix(branch, "mr-") {
+		prn, err := strconv.Atoi(strings.TrimPrefix(branch, "mr-"))
+		if err == nil && o.gitKind(activity.GitURL) == gits.KindGitlab {
+			return prn, nil
+		}
 	}
 	return 0, nil
 }
 
 func (o *SlackBotOptions) postMessage(channel string, directMessage bool, messageType string,
 	activity *record.ActivityRecord, all []*record.ActivityRecord, attachments []slack.Attachment,
-	createIfMissing bool) error {
+	blocks []slack.Block, createIfMissing bool) error {
+	// a concurrent event for the same activity waits for the message to be posted, and then updates it rather than
+	// posting another one
+	unlock := o.lockMessage(channel, activity.Name)
+	defer unlock()
+
 	timestamp := ""
-	channelId := channel
+	workspace, channelId := splitWorkspaceChannel(channel)
 
-	messageRef := o.Timestamps[channel][activity.Name]
+	messageRef := o.messageReference(channel, activity.Name)
+	logger := messageLogger(activity, channel, messageType)
 
 	if messageRef != nil {
 		timestamp = messageRef.Timestamp
 		channelId = messageRef.ChannelID
 	}
 
-	if _, ok := o.Timestamps[channel]; !ok {
-		o.Timestamps[channel] = make(map[string]*MessageReference, 0)
+	//channelID, timestamp, err := o.slackClient().PostMessage(o.Channels, messageText, params, slackbot.MsgOptionUpdate(timestamp))
+	options := []slack.MsgOption{}
+	if len(blocks) > 0 {
+		options = append(options, slack.MsgOptionBlocks(blocks...))
+	} else {
+		options = append(options, slack.MsgOptionAttachments(attachments...))
 	}
-	//channelID, timestamp, err := o.SlackClient.PostMessage(o.Channels, messageText, params, slackbot.MsgOptionUpdate(timestamp))
-	options := []slack.MsgOption{
-		slack.MsgOptionAttachments(attachments...),
+	if o.DryRun {
+		return logDryRun(channel, activity, attachments, blocks)
 	}
-	if directMessage {
-		channel, _, _, err := o.SlackClient.OpenConversation(&slack.OpenConversationParameters{
-			Users: []string{
-				channel,
-			},
+	hash, err := messageHash(attachments, blocks)
+	if err != nil {
+		return errors.Wrapf(err, "hashing message for %s", activity.Name)
+	}
+	if messageRef != nil && messageRef.Hash == hash {
+		logger.Infof("Message for %s is unchanged, not updating it\n", activity.Name)
+		return nil
+	}
+	ctx := context.Background()
+	pendingSchedule := messageRef != nil && messageRef.ScheduledMessageID != "" && timestamp == ""
+	if pendingSchedule && !time.Now().Before(messageRef.ScheduledPostAt) {
+		// Slack posted the scheduled message, which is updated once its timestamp is found, a new message is posted
+		// otherwise so that the latest status is shown
+		posted, err := o.findScheduledMessage(ctx, activity.Name, messageRef)
+		if err != nil {
+			logger.Warnf("failed to find the scheduled message for %s: %v", activity.Name, err)
+		}
+		if posted != "" {
+			logger.Infof("Found the scheduled message for %s with timestamp %s\n", activity.Name, posted)
+			found := *messageRef
+			found.Timestamp, found.ScheduledMessageID, found.ScheduledPostAt = posted, "", time.Time{}
+			o.storeMessageReference(channel, activity.Name, &found)
+			messageRef, timestamp = &found, posted
+		} else {
+			logger.Infof("Scheduled message for %s not found, posting a new one\n", activity.Name)
+		}
+		pendingSchedule = false
+		createIfMissing = true
+	} else if pendingSchedule {
+		// the scheduled message is replaced with this one
+		createIfMissing = true
+	}
+	if o.DeliveryMode == DeliveryModeWebhook {
+		return o.postIncomingWebhook(ctx, channel, directMessage, messageType, activity, messageRef, attachments, hash,
+			createIfMissing)
+	}
+	if directMessage && (messageRef == nil || messageRef.ChannelID == "") {
+		// the conversation is only opened for a new message, the updates use the ID of the conversation it was
+		// posted to, which isn't a user ID
+		_, userID := splitWorkspaceChannel(channel)
+		var channel *slack.Channel
+		err := o.postWithRetry(ctx, "opening conversation", func(ctx context.Context) error {
+			defer observeSlackAPICall("conversations.open", time.Now())
+			var err error
+			channel, _, _, err = o.slackClientFor(workspace).OpenConversationContext(ctx, &slack.OpenConversationParameters{
+				Users: []string{
+					userID,
+				},
+			})
+			return err
 		})
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("(open converation channelId: %s)", channelId))
+			messagesFailed.WithLabelValues(messageType).Inc()
+			return errors.Wrap(err, fmt.Sprintf("(open converation userId: %s)", userID))
 		}
 		channelId = channel.ID
+	} else if !directMessage && messageRef == nil {
+		channelId = o.resolveChannelID(ctx, workspace, channelId)
+	}
+	if timestamp == "" && createIfMissing {
+		// the new messages are delayed until the end of the maintenance window, the updates are sent as usual as
+		// they don't notify anyone
+		if postAt, ok := maintenanceWindowEnd(o.MaintenanceWindows, time.Now()); ok {
+			scheduled, err := o.scheduleMessage(ctx, channel, workspace, channelId, messageType, activity, messageRef,
+				options, hash, postAt)
+			if scheduled {
+				return err
+			}
+		} else if pendingSchedule {
+			// the maintenance window was removed, the message is posted straight away instead
+			err := o.cancelScheduledMessage(ctx, messageRef)
+			if err != nil {
+				return errors.Wrapf(err, "cancelling the scheduled message for %s in %s", activity.Name, channel)
+			}
+		}
 	}
 	post := true
 	if timestamp != "" {
 		options = append(options, slack.MsgOptionUpdate(timestamp))
-		log.Logger().Infof("Updating message for %s with timestamp %s\n", activity.Name, timestamp)
+		logger.WithField("timestamp", timestamp).Infof("Updating message for %s with timestamp %s\n", activity.Name,
+			timestamp)
 	} else {
 		if createIfMissing {
-			log.Logger().Infof("Creating new message for %s\n", activity.Name)
+			logger.Infof("Creating new message for %s\n", activity.Name)
 		} else {
-			log.Logger().Infof("No existing message to update, ignoring, for %s\n", activity.Name)
+			logger.Infof("No existing message to update, ignoring, for %s\n", activity.Name)
 			post = false
 		}
 
 	}
 	if post {
-		channelId, timestamp, _, err := o.SlackClient.SendMessageContext(context.Background(), channelId, options...)
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("(post channelId: %s, timestamp: %s)", channelId, timestamp))
+		send := func() error {
+			if timestamp != "" {
+				// only the updates are delayed, so that new messages appear straight away
+				err := o.waitUpdateJitter(ctx)
+				if err != nil {
+					return err
+				}
+			}
+			err := o.RateLimiter.Wait(ctx, channel, directMessage)
+			if err != nil {
+				return errors.Wrapf(err, "waiting to post to %s", channel)
+			}
+			method := "chat.postMessage"
+			if timestamp != "" {
+				method = "chat.update"
+			}
+			var postedChannelID, postedTimestamp string
+			err = o.postWithRetry(ctx, "posting message", func(ctx context.Context) error {
+				defer observeSlackAPICall(method, time.Now())
+				var err error
+				client := o.slackClientFor(workspace)
+				postedChannelID, postedTimestamp, _, err = client.SendMessageContext(ctx, channelId, options...)
+				return err
+			})
+			if err != nil {
+				messagesFailed.WithLabelValues(messageType).Inc()
+				return errors.Wrap(err, fmt.Sprintf("(post channelId: %s, timestamp: %s)", channelId, timestamp))
+			}
+			logger.WithField("timestamp", postedTimestamp).Infof("Sent message for %s\n", activity.Name)
+			if timestamp != "" {
+				messagesUpdated.WithLabelValues(messageType).Inc()
+			} else {
+				messagesCreated.WithLabelValues(messageType).Inc()
+			}
+			reaction := ""
+			var reviewers []string
+			notifiedFailure := false
+			if messageRef != nil {
+				reaction = messageRef.Reaction
+				reviewers = messageRef.Reviewers
+				notifiedFailure = messageRef.NotifiedFailure
+			}
+			o.storeMessageReference(channel, activity.Name, &MessageReference{
+				Workspace:       workspace,
+				ChannelID:       postedChannelID,
+				Timestamp:       postedTimestamp,
+				Hash:            hash,
+				Reaction:        reaction,
+				Status:          pipelineStatus(activity),
+				Reviewers:       reviewers,
+				NotifiedFailure: notifiedFailure,
+			})
+			o.emitWebhooks(channel, directMessage, messageType, activity, attachments, blocks)
+			return nil
 		}
-		o.Timestamps[channel][activity.Name] = &MessageReference{
-			ChannelID: channelId,
-			Timestamp: timestamp,
+		key := fmt.Sprintf("%s/%s", channel, activity.Name)
+		if timestamp != "" && messageType == pipelineMessageType && !isTerminalState(pipelineStatus(activity)) {
+			// the message isn't locked while the update waits, the update locks it again once it is sent, and is
+			// dropped if the message was updated in the meantime, such as with the final state of the pipeline
+			unlock()
+			return o.Debouncer.Debounce(key, func() error {
+				unlock := o.lockMessage(channel, activity.Name)
+				defer unlock()
+				current := o.messageReference(channel, activity.Name)
+				if current == nil || current.Timestamp != timestamp || current.Hash != messageRef.Hash ||
+					current.Status != messageRef.Status {
+					logger.Infof("Message for %s was updated since, not sending the debounced update\n",
+						activity.Name)
+					return nil
+				}
+				return send()
+			})
 		}
+		// this update supersedes any update waiting to be sent
+		o.Debouncer.Cancel(key)
+		return send()
 	}
 	return nil
 }
 
+// requestedReviewerLogins returns the sorted logins of the reviewers requested on the pull request
+func requestedReviewerLogins(pr *gits.GitPullRequest) []string {
+	if pr == nil {
+		return nil
+	}
+	var answer []string
+	for _, reviewer := range pr.RequestedReviewers {
+		if reviewer != nil && reviewer.Login != "" {
+			answer = append(answer, strings.ToLower(reviewer.Login))
+		}
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// invalidateOnReviewersChange forgets the hash of the message posted for the name in the channel if the requested
+// reviewers differ from the ones it was rendered with, so that the next post updates it
+func (o *SlackBotOptions) invalidateOnReviewersChange(channel string, name string, reviewers []string) {
+	ref := o.messageReference(channel, name)
+	if ref == nil || equalStrings(ref.Reviewers, reviewers) {
+		return
+	}
+	log.Logger().Infof("Requested reviewers of %s changed from %v to %v, updating the message in %s\n", name,
+		ref.Reviewers, reviewers, channel)
+	updated := *ref
+	updated.Hash = ""
+	o.storeMessageReference(channel, name, &updated)
+}
+
+// storeRenderedReviewers records the requested reviewers the message posted for the name in the channel was
+// rendered with
+func (o *SlackBotOptions) storeRenderedReviewers(channel string, name string, reviewers []string) {
+	ref := o.messageReference(channel, name)
+	if ref == nil || equalStrings(ref.Reviewers, reviewers) {
+		return
+	}
+	updated := *ref
+	updated.Reviewers = reviewers
+	o.storeMessageReference(channel, name, &updated)
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 //getPullRequest will return the PullRequestInfo for the activity, or nil if it's not a pull request
-func (o *SlackBotOptions) getPullRequest(activity *record.ActivityRecord) (pr *gits.GitPullRequest,
-	resolver *users.GitUserResolver, err error) {
-	if prn, err := getPullRequestNumber(activity); prn > 0 {
+func (o *SlackBotOptions) getPullRequest(ctx context.Context, activity *record.ActivityRecord) (
+	pr *gits.GitPullRequest, resolver *users.GitUserResolver, err error) {
+	if prn, err := o.getPullRequestNumber(activity); prn > 0 {
 		if err != nil {
 			return nil, nil, err
 		}
 		if activity.GitURL == "" {
 			return nil, nil, fmt.Errorf("no GitURL on PipelineActivity %s", activity.Name)
 		}
-		gitProvider, gitInfo, err := o.CommonOptions.CreateGitProviderForURLWithoutKind(activity.GitURL)
+		key := pullRequestCacheKey(activity, prn)
+		if pr, resolver := o.cachedPullRequest(key, activity); pr != nil {
+			return pr, resolver, nil
+		}
+		gitProvider, gitInfo, err := o.createGitProviderForURL(activity.GitURL)
 		if err != nil {
 			return nil, nil, err
 		}
-		prn, err := getPullRequestNumber(activity)
+		prn, err := o.getPullRequestNumber(activity)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -670,46 +1520,244 @@ func (o *SlackBotOptions) getPullRequest(activity *record.ActivityRecord) (pr *g
 			GitProvider: gitProvider,
 			JXClient:    o.JXClient,
 		}
-		pr, err := gitProvider.GetPullRequest(gitInfo.Organisation, gitInfo, prn)
-		return pr, resolver, err
+		ctx, cancel := o.withTimeout(ctx)
+		defer cancel()
+		var pullRequest *gits.GitPullRequest
+		err = callWithContext(ctx, fmt.Sprintf("getting pull request %s", activity.GitURL), func() error {
+			var err error
+			pullRequest, err = gitProvider.GetPullRequest(gitInfo.Organisation, gitInfo, prn)
+			return err
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(o.UserGroups) > 0 {
+			teams, err := requestedTeams(ctx, gitProvider, gitInfo, prn)
+			if err != nil {
+				// the users requested to review are still mentioned
+				log.Logger().Warnf("failed to list the teams requested to review %s: %v", activity.GitURL, err)
+			}
+			for _, team := range teams {
+				pullRequest.RequestedReviewers = append(pullRequest.RequestedReviewers, &gits.GitUser{Login: team})
+			}
+		}
+		o.cachePullRequest(key, activity, pullRequest, resolver)
+		return pullRequest, resolver, nil
 	}
 	return nil, nil, nil
 }
 
+// requestedTeams returns the teams requested to review the pull request, in the org/team form. The Git providers only
+// return the users requested to review, so the teams are listed with the GitHub API, other providers have no teams.
+func requestedTeams(ctx context.Context, gitProvider gits.GitProvider, gitInfo *gits.GitRepository,
+	prn int) ([]string, error) {
+	github, ok := gitProvider.(*gits.GitHubProvider)
+	if !ok || github.Client == nil {
+		return nil, nil
+	}
+	var teams []string
+	err := callWithContext(ctx, fmt.Sprintf("listing the reviewers of %s", gitInfo.URL), func() error {
+		reviewers, _, err := github.Client.PullRequests.ListReviewers(ctx, gitInfo.Organisation, gitInfo.Name, prn,
+			nil)
+		if err != nil {
+			return err
+		}
+		for _, team := range reviewers.Teams {
+			if team.GetSlug() != "" {
+				teams = append(teams, fmt.Sprintf("%s/%s", gitInfo.Organisation, team.GetSlug()))
+			}
+		}
+		return nil
+	})
+	return teams, err
+}
+
+// DeleteMessage deletes the message posted for the activity in the channel, along with its threaded replies
+func (o *SlackBotOptions) DeleteMessage(channel string, activityName string) error {
+	messageRef := o.messageReference(channel, activityName)
+	if messageRef == nil {
+		return nil
+	}
+	if o.DryRun {
+		log.Logger().Infof("Dry run, not deleting message for %s in %s\n", activityName, channel)
+		return nil
+	}
+	for name := range o.messageReferencesWithPrefix(channel, activityName+"/") {
+		err := o.deleteMessage(channel, name)
+		if err != nil {
+			return err
+		}
+	}
+	return o.deleteMessage(channel, activityName)
+}
+
+// deleteMessage deletes the message stored with the name in the channel, a concurrent event for the same activity
+// waits for the deletion rather than updating the message being deleted
+func (o *SlackBotOptions) deleteMessage(channel string, name string) error {
+	unlock := o.lockMessage(channel, name)
+	defer unlock()
+	messageRef := o.messageReference(channel, name)
+	if messageRef == nil {
+		// the message was deleted in the meantime
+		return nil
+	}
+	ctx := context.Background()
+	if messageRef.Timestamp == "" {
+		// the messages posted with incoming webhooks, or scheduled, can't be deleted, but the scheduled ones can be
+		// cancelled until they are posted
+		err := o.cancelScheduledMessage(ctx, messageRef)
+		if err != nil {
+			return errors.Wrapf(err, "cancelling the scheduled message for %s in %s", name, channel)
+		}
+		o.removeMessageReference(channel, name)
+		return nil
+	}
+	err := o.postWithRetry(ctx, "deleting message", func(ctx context.Context) error {
+		defer observeSlackAPICall("chat.delete", time.Now())
+		client := o.slackClientFor(messageRef.Workspace)
+		_, _, err := client.DeleteMessageContext(ctx, messageRef.ChannelID, messageRef.Timestamp)
+		if err != nil && err.Error() == "message_not_found" {
+			// the message was already deleted
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		messagesFailed.WithLabelValues(pullRequestReviewMessageType).Inc()
+		return errors.Wrapf(err, "(delete channelId: %s, timestamp: %s)", messageRef.ChannelID, messageRef.Timestamp)
+	}
+	messagesDeleted.WithLabelValues(pullRequestReviewMessageType).Inc()
+	log.Logger().Infof("Deleted message for %s in %s\n", name, channel)
+	o.removeMessageReference(channel, name)
+	return nil
+}
+
+// messageHash returns a hash of the message content, covering the text, color, fields and actions of the attachments
+// or the blocks
+func messageHash(attachments []slack.Attachment, blocks []slack.Block) (string, error) {
+	var message interface{} = attachments
+	if len(blocks) > 0 {
+		message = blocks
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
 func annotationKey(channel string, messageType string) string {
 	return fmt.Sprintf("%s-%s/%s", SlackAnnotationPrefix, messageType, strings.TrimPrefix(channel, "#"))
 }
 
 func (o *SlackBotOptions) createAttachments(activity *record.ActivityRecord,
-	step *record.ActivityStageOrStep) []slack.Attachment {
+	step *record.ActivityStageOrStep, statuses slackapp.Statuses) []slack.Attachment {
 	if step != nil {
-		return o.createStageAttachments(activity, step)
+		return o.createStageAttachments(activity, step, statuses)
 	}
 	return []slack.Attachment{}
 
 }
 
 func (o *SlackBotOptions) createStageAttachments(activity *record.ActivityRecord,
-	stage *record.ActivityStageOrStep) []slack.Attachment {
+	stage *record.ActivityStageOrStep, statuses slackapp.Statuses) []slack.Attachment {
 	name := stage.Name
 	if name == "" {
 		name = "Stage"
 	}
 	attachments := []slack.Attachment{
-		o.createStepAttachment(stage, name, "", ""),
+		o.createStepAttachment(stage, name, "", "", statuses),
 	}
 	if stage.Name != "meta pipeline" {
+		var succeeded []*record.ActivityStageOrStep
 		for _, step := range stage.Steps {
 			// filter out tekton generated steps
-			if isUserPipelineStep(step.Name) {
-				attachments = append(attachments, o.createStepAttachment(step, "", "", ""))
+			if !isUserPipelineStep(step.Name) {
+				continue
 			}
+			if o.GroupSucceededSteps && step.Status == v1alpha1.SuccessState {
+				succeeded = append(succeeded, step)
+				continue
+			}
+			attachments = append(attachments, o.createSucceededStepsAttachments(succeeded, statuses)...)
+			succeeded = nil
+			attachments = append(attachments, o.createStepAttachment(step, "", "", "", statuses))
 		}
+		attachments = append(attachments, o.createSucceededStepsAttachments(succeeded, statuses)...)
 	}
 
 	return attachments
 }
 
+// createSucceededStepsAttachments renders consecutive steps which succeeded as a single summary line, a lone step is
+// rendered as usual
+func (o *SlackBotOptions) createSucceededStepsAttachments(steps []*record.ActivityStageOrStep,
+	statuses slackapp.Statuses) []slack.Attachment {
+	switch len(steps) {
+	case 0:
+		return nil
+	case 1:
+		return []slack.Attachment{o.createStepAttachment(steps[0], "", "", "", statuses)}
+	}
+	return []slack.Attachment{{
+		Text: strings.TrimSpace(fmt.Sprintf("%s %d steps succeeded", statusString(statuses, v1alpha1.SuccessState),
+			len(steps))),
+		MarkdownIn: []string{"fields"},
+		Color:      statusColor(statuses, v1alpha1.SuccessState),
+	}}
+}
+
+// createCollapsedStageAttachments renders the stages which succeeded as a single summary line, in place of the first
+// of them, while the other stages are rendered as usual so that running or failed stages stand out
+func (o *SlackBotOptions) createCollapsedStageAttachments(activity *record.ActivityRecord,
+	statuses slackapp.Statuses) []slack.Attachment {
+	attachments := []slack.Attachment{}
+	summaryIndex := -1
+	succeeded := 0
+	for _, stage := range activity.Stages {
+		if stage == nil {
+			continue
+		}
+		if stageSucceeded(stage) {
+			if summaryIndex < 0 {
+				summaryIndex = len(attachments)
+			}
+			succeeded++
+			continue
+		}
+		attachments = append(attachments, o.createAttachments(activity, stage, statuses)...)
+	}
+	if succeeded == 0 {
+		return attachments
+	}
+	text := fmt.Sprintf("%d stages succeeded", succeeded)
+	if succeeded == 1 {
+		text = "1 stage succeeded"
+	}
+	summary := slack.Attachment{
+		Text:       strings.TrimSpace(statusString(statuses, v1alpha1.SuccessState) + " " + text),
+		MarkdownIn: []string{"fields"},
+		Color:      statusColor(statuses, v1alpha1.SuccessState),
+	}
+	attachments = append(attachments, slack.Attachment{})
+	copy(attachments[summaryIndex+1:], attachments[summaryIndex:])
+	attachments[summaryIndex] = summary
+	return attachments
+}
+
+// stageSucceeded returns true if the stage and all its steps succeeded
+func stageSucceeded(stage *record.ActivityStageOrStep) bool {
+	if stage.Status != v1alpha1.SuccessState {
+		return false
+	}
+	for _, step := range append(stage.Stages, stage.Steps...) {
+		if step != nil && !stageSucceeded(step) {
+			return false
+		}
+	}
+	return true
+}
+
 func isUserPipelineStep(name string) bool {
 	if strings.TrimSpace(name) == "" {
 		return false
@@ -724,7 +1772,7 @@ func isUserPipelineStep(name string) bool {
 }
 
 func (o *SlackBotOptions) createStepAttachment(step *record.ActivityStageOrStep, name string, description string,
-	iconUrl string) slack.Attachment {
+	iconUrl string, statuses slackapp.Statuses) slack.Attachment {
 	text := description
 
 	textName := strings.Title(name)
@@ -733,9 +1781,12 @@ func (o *SlackBotOptions) createStepAttachment(step *record.ActivityStageOrStep,
 	}
 
 	textName = getUserFriendlyMapping(textName)
+	if emoji := o.stageEmoji(step.Name); emoji != "" {
+		textName = emoji + " " + textName
+	}
 
 	stepStatus := step.Status
-	textMessage := o.statusString(stepStatus) + " " + textName
+	textMessage := statusString(statuses, stepStatus) + " " + textName
 	if text != "" {
 		textMessage += " " + text
 	}
@@ -744,7 +1795,7 @@ func (o *SlackBotOptions) createStepAttachment(step *record.ActivityStageOrStep,
 		Text:       textMessage,
 		FooterIcon: iconUrl,
 		MarkdownIn: []string{"fields"},
-		Color:      attachmentColor(stepStatus),
+		Color:      statusColor(statuses, stepStatus),
 	}
 }
 
@@ -767,7 +1818,14 @@ func (o *SlackBotOptions) annotatePipelineActivity(activity *jenkinsv1.PipelineA
 	return err
 }
 
-func pullRequestName(url string) string {
+// pullRequestNameForKind returns the short name of a pull request, e.g. #123, or !123 for a GitLab merge request
+func pullRequestNameForKind(kind string, url string) string {
+	if n := pullRequestNumberFromURL(kind, url); n > 0 {
+		if kind == gits.KindGitlab {
+			return fmt.Sprintf("!%d", n)
+		}
+		return fmt.Sprintf("#%d", n)
+	}
 	idx := strings.LastIndex(url, "/")
 	if idx > 0 {
 		return "#" + url[idx+1:]
@@ -775,12 +1833,75 @@ func pullRequestName(url string) string {
 	return url
 }
 
-func pipelineName(activity *record.ActivityRecord) (string, error) {
+// pullRequestNumberFromURL extracts the pull request number from the URL of a pull request for the git provider kind,
+// or returns 0 if the URL isn't a pull request URL
+func pullRequestNumberFromURL(kind string, url string) int {
+	segment := "pull"
+	switch kind {
+	case gits.KindGitlab:
+		segment = "merge_requests"
+	case gits.KindBitBucketServer, gits.KindBitBucketCloud:
+		segment = "pull-requests"
+	case gits.KindGitea:
+		segment = "pulls"
+	}
+	parts := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	for i := 0; i < len(parts)-1; i++ {
+		if parts[i] == segment {
+			if n, err := strconv.Atoi(parts[i+1]); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// gitKindFromURL guesses the kind of git provider from the URL of a pull request
+func gitKindFromURL(url string) string {
+	switch {
+	case strings.Contains(url, "/merge_requests/") || strings.Contains(url, "gitlab"):
+		return gits.KindGitlab
+	case strings.Contains(url, "/projects/") && strings.Contains(url, "/pull-requests/"):
+		return gits.KindBitBucketServer
+	case strings.Contains(url, "/pull-requests/"):
+		return gits.KindBitBucketCloud
+	case strings.Contains(url, "/pulls/"):
+		return gits.KindGitea
+	}
+	return gits.KindGitHub
+}
+
+// DefaultReleaseBranches are the branches whose pipelines are named "Release Pipeline" when the SlackBot doesn't
+// configure its ReleaseBranches
+var DefaultReleaseBranches = []string{"master", "main"}
+
+// pipelineTitle returns the friendly name of the pipeline of the activity followed by the repository, or the
+// repository followed by the branch if RawPipelineNames is set. The repository is the one shown by the message, which
+// doesn't name it for the private repositories.
+func (o *SlackBotOptions) pipelineTitle(activity *record.ActivityRecord, repo string) (string, error) {
+	if o.RawPipelineNames {
+		return repo + "/" + activity.Branch, nil
+	}
+	name, err := o.pipelineName(activity)
+	if err != nil {
+		return "", err
+	}
+	return name + " " + repo, nil
+}
+
+// pipelineName returns the friendly name of the pipeline of the activity
+func (o *SlackBotOptions) pipelineName(activity *record.ActivityRecord) (string, error) {
 	name := fmt.Sprintf("%s/%s/%s", activity.Owner, activity.Repo, activity.Branch)
-	if strings.HasSuffix(name, "/master") {
-		return "Release Pipeline", nil
+	releaseBranches := o.ReleaseBranches
+	if len(releaseBranches) == 0 {
+		releaseBranches = DefaultReleaseBranches
+	}
+	for _, branch := range releaseBranches {
+		if strings.HasSuffix(name, "/"+branch) {
+			return "Release Pipeline", nil
+		}
 	}
-	prn, err := getPullRequestNumber(activity)
+	prn, err := o.getPullRequestNumber(activity)
 	if err != nil {
 		return "", errors.Wrapf(err, "getting pull request number from %s", activity.Name)
 	}
@@ -792,7 +1913,7 @@ func pipelineName(activity *record.ActivityRecord) (string, error) {
 
 func repositoryName(act *record.ActivityRecord) string {
 	details := createPipelineDetails(act)
-	gitURL := act.GitURL
+	gitURL := httpsGitURL(act.GitURL)
 	ownerURL := strings.TrimSuffix(gitURL, "/")
 	idx := strings.LastIndex(ownerURL, "/")
 	if idx > 0 {
@@ -801,29 +1922,115 @@ func repositoryName(act *record.ActivityRecord) string {
 	return link(details.GitOwner, ownerURL) + "/" + link(details.GitRepository, gitURL)
 }
 
-func (o *SlackBotOptions) mentionOrLinkUser(user *jenkinsv1.User) (string, error) {
-	id, err := o.SlackUserResolver.SlackUserLogin(user)
+// privateRepositoryName links to the repository of the activity without naming it
+func privateRepositoryName(act *record.ActivityRecord) string {
+	return link("private repository", httpsGitURL(act.GitURL))
+}
+
+func (o *SlackBotOptions) mentionOrLinkUser(workspace string, user *jenkinsv1.User) string {
+	if user == nil {
+		return ""
+	}
+	id, err := o.slackUserID(workspace, user)
 	if err != nil {
-		return "", err
+		// fall back to a link so that the message is still sent
+		log.Logger().Warnf("failed to resolve the Slack user of %s: %v", user.Spec.Login, err)
+	} else if id != "" {
+		return mentionUser(id)
+	}
+	return linkUser(user, nil)
+}
+
+// slackUserID returns the Slack ID of the user in the workspace, the UserMappings of the logins of the user take
+// precedence over the SlackUserResolver
+func (o *SlackBotOptions) slackUserID(workspace string, user *jenkinsv1.User) (string, error) {
+	logins := []string{user.Spec.Login}
+	for _, a := range user.Spec.Accounts {
+		logins = append(logins, a.ID)
 	}
-	if id != "" {
-		return mentionUser(id), nil
+	if id := o.mappedSlackUser(logins...); id != "" {
+		return id, nil
 	}
-	if user.Spec.Name != "" && user.Spec.URL != "" {
-		return link(user.Spec.Name, user.Spec.URL), nil
+	var id string
+	var err error
+	if workspace == "" {
+		id, err = o.SlackUserResolver.SlackUserLogin(user)
+	} else {
+		id, err = o.workspaceSlackUserID(workspace, user)
 	}
-	if user.Spec.Name != "" {
-		return user.Spec.Name, nil
+	if err == nil && id == "" {
+		provider := gitProviderKind(user)
+		log.Logger().Debugf("no Slack user for the %s user %s", provider, user.Spec.Login)
+		unresolvedSlackUsers.WithLabelValues(provider).Inc()
+	}
+	return id, err
+}
+
+// gitLogin returns the login of the Git user, or an empty string if there is no user
+func gitLogin(user *gits.GitUser) string {
+	if user == nil {
+		return ""
+	}
+	return user.Login
+}
+
+// mappedSlackUser returns the Slack ID the first of the Git logins is mapped to in the UserMappings, or an empty
+// string if none of them is mapped
+func (o *SlackBotOptions) mappedSlackUser(logins ...string) string {
+	for _, login := range logins {
+		if login == "" {
+			continue
+		}
+		for gitLogin, id := range o.UserMappings {
+			if strings.EqualFold(gitLogin, login) {
+				return id
+			}
+		}
 	}
-	return "", nil
+	return ""
 }
 
 func buildNumber(activity *record.ActivityRecord) string {
 	return link("#"+activity.BuildIdentifier, activity.LinkURL)
 }
 
+// activityChannels returns the channels to post the messages about the activity to, the channel configured for the
+// repository of the activity takes precedence over the channels of the mode. The channels are qualified by the
+// workspace of the mode
+func activityChannels(activity *record.ActivityRecord, cfg slackapp.SlackBotMode) []string {
+	for _, org := range cfg.Orgs {
+		if org.Name != activity.Owner {
+			continue
+		}
+		for _, r := range org.Repos {
+			if r.Name == activity.Repo && r.Channel != "" {
+				return []string{workspaceChannel(cfg.Workspace, channelName(r.Channel))}
+			}
+		}
+	}
+	channels := configChannels(cfg)
+	for i, channel := range channels {
+		channels[i] = workspaceChannel(cfg.Workspace, channel)
+	}
+	return channels
+}
+
+func configChannels(cfg slackapp.SlackBotMode) []string {
+	channels := []string{}
+	for _, c := range append([]string{cfg.Channel}, cfg.Channels...) {
+		if c == "" {
+			continue
+		}
+		channel := channelName(c)
+		if !util.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
 func channelName(channel string) string {
-	if !strings.HasPrefix(channel, "#") {
+	if !strings.HasPrefix(channel, "#") && !isChannelID(channel) {
 		return fmt.Sprintf("#%s", channel)
 	}
 	return channel
@@ -840,9 +2047,26 @@ func link(text string, url string) string {
 	}
 }
 
+// commitFooter returns the short SHA of the commit built by the activity, linked to the commit, and the author of the
+// pull request if there is one
+func commitFooter(activity *record.ActivityRecord, pr *gits.GitPullRequest) string {
+	sha := activity.LastCommitSHA
+	if sha == "" {
+		sha = activity.BaseSHA
+	}
+	if len(sha) < 7 {
+		return ""
+	}
+	footer := mergeShaText(activity.GitURL, sha)
+	if pr != nil && pr.Author != nil && pr.Author.Login != "" {
+		footer = fmt.Sprintf("%s by %s", footer, pr.Author.Login)
+	}
+	return footer
+}
+
 func mergeShaText(gitURL, sha string) string {
 	short := sha[0:7]
-	cleanUrl := strings.TrimSuffix(gitURL, ".git")
+	cleanUrl := strings.TrimSuffix(httpsGitURL(gitURL), ".git")
 	if cleanUrl != "" {
 		cleanUrl = util.UrlJoin(cleanUrl, "commit", sha)
 	}
@@ -861,27 +2085,56 @@ func pullRequestStatusString(text string) string {
 	}
 }
 
-func (o *SlackBotOptions) resolveGitUserToSlackUser(user *gits.GitUser, resolver *users.GitUserResolver) (string,
-	error) {
+func (o *SlackBotOptions) resolveGitUserToSlackUser(workspace string, user *gits.GitUser,
+	resolver *users.GitUserResolver) (string, error) {
+	if user != nil {
+		if id := o.mappedSlackUser(user.Login); id != "" {
+			return id, nil
+		}
+	}
 	resolved, err := resolver.Resolve(user)
 	if err != nil {
 		return "", err
 	}
-	return o.SlackUserResolver.SlackUserLogin(resolved)
+	if resolved == nil {
+		return "", nil
+	}
+	return o.slackUserID(workspace, resolved)
 }
 
-func (o *SlackBotOptions) statusString(statusType v1alpha1.PipelineState) string {
+func statusString(statuses slackapp.Statuses, statusType v1alpha1.PipelineState) string {
 	switch statusType {
 	case v1alpha1.FailureState, v1alpha1.AbortedState:
-		return getStatus(o.Statuses.Failed, defaultStatuses.Failed).Emoji
+		return getStatus(statuses.Failed, defaultStatuses.Failed).Emoji
 	case v1alpha1.SuccessState:
-		return getStatus(o.Statuses.Succeeded, defaultStatuses.Succeeded).Emoji
+		return getStatus(statuses.Succeeded, defaultStatuses.Succeeded).Emoji
 	case v1alpha1.RunningState, v1alpha1.PendingState:
-		return getStatus(o.Statuses.Running, defaultStatuses.Running).Emoji
+		return getStatus(statuses.Running, defaultStatuses.Running).Emoji
 	}
 	return ""
 }
 
+// statusColor returns the color configured for the status, falling back to the default attachment color
+func statusColor(statuses slackapp.Statuses, statusType v1alpha1.PipelineState) string {
+	var status *slackapp.Status
+	switch statusType {
+	case v1alpha1.FailureState:
+		status = statuses.Failed
+	case v1alpha1.SuccessState:
+		status = statuses.Succeeded
+	case v1alpha1.RunningState:
+		status = statuses.Running
+	case v1alpha1.PendingState:
+		status = statuses.Pending
+	case v1alpha1.AbortedState:
+		status = statuses.Aborted
+	}
+	if status != nil && status.Color != "" {
+		return status.Color
+	}
+	return attachmentColor(statusType)
+}
+
 func attachmentColor(statusType v1alpha1.PipelineState) string {
 	switch statusType {
 	case v1alpha1.FailureState:
@@ -931,6 +2184,35 @@ func pipelineIcon(statusType v1alpha1.PipelineState) string {
 	return ""
 }
 
+// teamReviewer returns the slug of the team if the requested reviewer is a team rather than a user. The teams listed
+// by requestedTeams have the org/team form, which user logins can't have.
+func (o *SlackBotOptions) teamReviewer(reviewer *gits.GitUser) (string, bool) {
+	if reviewer == nil {
+		return "", false
+	}
+	team := strings.TrimPrefix(reviewer.Login, "@")
+	if strings.Contains(team, "/") {
+		return team, true
+	}
+	return "", false
+}
+
+// userGroupMention returns the mention of the Slack user group configured for the team, or an empty string if there
+// is none
+func (o *SlackBotOptions) userGroupMention(team string) string {
+	id := o.UserGroups[team]
+	if id == "" {
+		// the team may be configured without the org
+		if idx := strings.LastIndex(team, "/"); idx >= 0 {
+			id = o.UserGroups[team[idx+1:]]
+		}
+	}
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("<!subteam^%s>", id)
+}
+
 func mentionUser(id string) string {
 	return fmt.Sprintf("<@%s>", id)
 }
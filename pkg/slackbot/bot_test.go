@@ -1,22 +1,45 @@
 package slackbot
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"path"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/ghodss/yaml"
+	"github.com/google/go-github/github"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
 	"github.com/jenkins-x/lighthouse/pkg/jx"
 	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
 
 	"github.com/pkg/errors"
 
 	"github.com/jenkins-x/jx-logging/pkg/log"
 
 	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/prow"
+	"github.com/jenkins-x/jx/v2/pkg/users"
+	"github.com/jenkins-x/jx/v2/pkg/util"
 	"github.com/slack-go/slack"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestSlackBotOptions_createAttachments(t *testing.T) {
@@ -40,7 +63,7 @@ func TestSlackBotOptions_createAttachments(t *testing.T) {
 
 			attachments := []slack.Attachment{}
 			for _, step := range act.Stages {
-				stepAttachments := o.createAttachments(act, step)
+				stepAttachments := o.createAttachments(act, step, o.Statuses)
 				if len(stepAttachments) > 0 {
 					attachments = append(attachments, stepAttachments...)
 				}
@@ -59,6 +82,104 @@ func TestSlackBotOptions_createAttachments(t *testing.T) {
 	}
 }
 
+func TestSlackBotOptions_createStepAttachmentStageEmojis(t *testing.T) {
+	o := &SlackBotOptions{
+		StageEmojis: map[string]string{
+			"build":   ":hammer:",
+			"Promote": ":rocket:",
+		},
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"build make linux", ":white_check_mark: :hammer: build make linux"},
+		{"promote jx promote", ":white_check_mark: :rocket: promote jx promote"},
+		{"setup git merge", ":white_check_mark: setup git merge"},
+		{"git clone", ":white_check_mark: git clone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := &record.ActivityStageOrStep{Name: tt.name, Status: v1alpha1.SuccessState}
+			assert.Equal(t, tt.want, o.createStepAttachment(step, "", "", "", o.Statuses).Text)
+		})
+	}
+}
+
+func TestSlackBotOptions_createCollapsedStageAttachments(t *testing.T) {
+	o := &SlackBotOptions{CollapseSucceededStages: true}
+	stage := func(name string, status v1alpha1.PipelineState, steps ...*record.ActivityStageOrStep) *record.ActivityStageOrStep {
+		return &record.ActivityStageOrStep{Name: name, Status: status, Steps: steps}
+	}
+	act := &record.ActivityRecord{
+		Stages: []*record.ActivityStageOrStep{
+			stage("checkout", v1alpha1.SuccessState),
+			stage("lint", v1alpha1.SuccessState, stage("build lint", v1alpha1.SuccessState)),
+			stage("test", v1alpha1.FailureState, stage("build test", v1alpha1.FailureState)),
+			stage("package", v1alpha1.SuccessState),
+			stage("deploy", v1alpha1.RunningState),
+		},
+	}
+	attachments := o.createCollapsedStageAttachments(act, o.Statuses)
+	texts := []string{}
+	for _, a := range attachments {
+		texts = append(texts, a.Text)
+	}
+	assert.Equal(t, []string{
+		":white_check_mark: 3 stages succeeded",
+		":red_circle: Test",
+		":red_circle: build test",
+		":white_circle: Deploy",
+	}, texts)
+
+	// a stage which succeeded with a failed step is expanded
+	act.Stages = []*record.ActivityStageOrStep{
+		stage("checkout", v1alpha1.SuccessState),
+		stage("lint", v1alpha1.SuccessState, stage("build lint", v1alpha1.FailureState)),
+	}
+	attachments = o.createCollapsedStageAttachments(act, o.Statuses)
+	require.Len(t, attachments, 3)
+	assert.Equal(t, ":white_check_mark: 1 stage succeeded", attachments[0].Text)
+}
+
+func TestSlackBotOptions_createStageAttachments_groupSucceededSteps(t *testing.T) {
+	o := &SlackBotOptions{GroupSucceededSteps: true}
+	step := func(name string, status v1alpha1.PipelineState) *record.ActivityStageOrStep {
+		return &record.ActivityStageOrStep{Name: name, Status: status}
+	}
+	stage := &record.ActivityStageOrStep{
+		Name:   "ci",
+		Status: v1alpha1.FailureState,
+		Steps: []*record.ActivityStageOrStep{
+			step("build one", v1alpha1.SuccessState),
+			step("build two", v1alpha1.SuccessState),
+			step("git merge", v1alpha1.SuccessState),
+			step("build three", v1alpha1.SuccessState),
+			step("build lint", v1alpha1.FailureState),
+			step("build four", v1alpha1.SuccessState),
+			step("build five", v1alpha1.RunningState),
+		},
+	}
+	texts := func(attachments []slack.Attachment) []string {
+		texts := []string{}
+		for _, a := range attachments {
+			texts = append(texts, a.Text)
+		}
+		return texts
+	}
+	assert.Equal(t, []string{
+		":red_circle: Ci",
+		":white_check_mark: 3 steps succeeded",
+		":red_circle: build lint",
+		":white_check_mark: build four",
+		":white_circle: build five",
+	}, texts(o.createStageAttachments(&record.ActivityRecord{}, stage, o.Statuses)))
+
+	// the steps are all shown without the option
+	o.GroupSucceededSteps = false
+	assert.Len(t, o.createStageAttachments(&record.ActivityRecord{}, stage, o.Statuses), 7)
+}
+
 func getPipelineActivity(filename string) (*record.ActivityRecord, error) {
 	testData := path.Join("test_data", "bot")
 	testfile, err := ioutil.ReadFile(path.Join(testData, filename))
@@ -73,6 +194,43 @@ func getPipelineActivity(filename string) (*record.ActivityRecord, error) {
 	return jx.ConvertPipelineActivity(act)
 }
 
+// getRecentPipelineActivity returns the pipeline activity as if it had just completed
+func getRecentPipelineActivity(filename string) (*record.ActivityRecord, error) {
+	act, err := getPipelineActivity(filename)
+	if err != nil {
+		return nil, err
+	}
+	now := metav1.Now()
+	act.StartTime = &now
+	act.CompletionTime = &now
+	return act, nil
+}
+
+// newReviewClients returns the clients of a bot reading an empty Prow config, whose pull requests are returned by the
+// Git provider, along with the resolver of the Git users. A draftGitProvider without pull request is used if the
+// provider is nil, and a new fake client if jxClient is nil
+func newReviewClients(jxClient *jxfake.Clientset, provider gits.GitProvider) (*GlobalClients, *users.GitUserResolver) {
+	if jxClient == nil {
+		jxClient = jxfake.NewSimpleClientset()
+	}
+	if provider == nil {
+		provider = &draftGitProvider{}
+	}
+	clients := &GlobalClients{
+		Namespace: "jx",
+		JXClient:  jxClient,
+		KubeClient: kubefake.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: prow.ProwConfigMapName, Namespace: "jx"},
+			Data:       map[string]string{prow.ProwConfigFilename: "{}"},
+		}),
+		gitProviderForURL: func(gitURL string) (gits.GitProvider, *gits.GitRepository, error) {
+			gitInfo, err := gits.ParseGitURL(gitURL)
+			return provider, gitInfo, err
+		},
+	}
+	return clients, &users.GitUserResolver{GitProvider: provider, JXClient: jxClient, Namespace: "jx"}
+}
+
 func Test_isUserPipelineStep(t *testing.T) {
 	type args struct {
 		name string
@@ -104,3 +262,1244 @@ func Test_isUserPipelineStep(t *testing.T) {
 		})
 	}
 }
+
+func Test_matchesLabels(t *testing.T) {
+	label := func(name string) *gits.Label {
+		return &gits.Label{Name: &name}
+	}
+	pr := &gits.GitPullRequest{
+		Labels: []*gits.Label{label("area/frontend"), label("lgtm")},
+	}
+	act := &record.ActivityRecord{Name: "test"}
+	tests := []struct {
+		name          string
+		pr            *gits.GitPullRequest
+		ignoreLabels  []string
+		includeLabels []string
+		want          bool
+	}{
+		{name: "no_labels_configured", pr: pr, want: true},
+		{name: "ignored", pr: pr, ignoreLabels: []string{"lgtm"}, want: false},
+		{name: "included", pr: pr, includeLabels: []string{"area/backend", "area/frontend"}, want: true},
+		{name: "not_included", pr: pr, includeLabels: []string{"area/backend"}, want: false},
+		{name: "ignore_takes_precedence", pr: pr, ignoreLabels: []string{"lgtm"},
+			includeLabels: []string{"area/frontend"}, want: false},
+		{name: "not_a_pull_request", pr: nil, includeLabels: []string{"area/frontend"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesLabels(act, tt.pr, tt.ignoreLabels, tt.includeLabels); got != tt.want {
+				t.Errorf("matchesLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_matchesContext(t *testing.T) {
+	tests := []struct {
+		name           string
+		contexts       []string
+		ignoreContexts []string
+		want           bool
+	}{
+		{name: "unset", want: true},
+		{name: "allowed", contexts: []string{"integration-tests", "pr-build"}, want: true},
+		{name: "not_allowed", contexts: []string{"integration-tests"}, want: false},
+		{name: "denied", ignoreContexts: []string{"pr-build"}, want: false},
+		{name: "not_denied", ignoreContexts: []string{"integration-tests"}, want: true},
+		{name: "deny_takes_precedence", contexts: []string{"pr-build"}, ignoreContexts: []string{"PR-Build"},
+			want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesContext("pr-build", tt.contexts, tt.ignoreContexts); got != tt.want {
+				t.Errorf("matchesContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_matchesKeeperQuery(t *testing.T) {
+	label := func(name string) *gits.Label {
+		return &gits.Label{Name: &name}
+	}
+	merged := true
+	tests := []struct {
+		name          string
+		pr            *gits.GitPullRequest
+		labels        []string
+		missingLabels []string
+		want          bool
+	}{
+		{name: "in_pool", pr: &gits.GitPullRequest{Labels: []*gits.Label{label("lgtm"), label("approved")}},
+			labels: []string{"lgtm", "approved"}, missingLabels: []string{"do-not-merge/hold"}, want: true},
+		{name: "missing_label", pr: &gits.GitPullRequest{Labels: []*gits.Label{label("lgtm")}},
+			labels: []string{"lgtm", "approved"}, want: false},
+		{name: "on_hold", pr: &gits.GitPullRequest{Labels: []*gits.Label{label("approved"), label("do-not-merge/hold")}},
+			labels: []string{"approved"}, missingLabels: []string{"do-not-merge/hold"}, want: false},
+		{name: "merged", pr: &gits.GitPullRequest{Labels: []*gits.Label{label("approved")}, Merged: &merged},
+			labels: []string{"approved"}, want: false},
+		{name: "nil", pr: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesKeeperQuery(tt.pr, tt.labels, tt.missingLabels); got != tt.want {
+				t.Errorf("matchesKeeperQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hasMergeMethodLabel(t *testing.T) {
+	squash := "tide/merge-method-squash"
+	lgtm := "lgtm"
+	assert.True(t, hasMergeMethodLabel(&gits.GitPullRequest{Labels: []*gits.Label{{Name: &lgtm}, {Name: &squash}}}))
+	assert.False(t, hasMergeMethodLabel(&gits.GitPullRequest{Labels: []*gits.Label{{Name: &lgtm}}}))
+}
+
+func TestSlackBotOptions_createReviewersMessage_queued(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	jxClient := jxfake.NewSimpleClientset()
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: "jx",
+			JXClient:  jxClient,
+			KubeClient: kubefake.NewSimpleClientset(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: prow.ProwConfigMapName, Namespace: "jx"},
+				Data: map[string]string{prow.ProwConfigFilename: `tide:
+  queries:
+  - repos: [jenkins-x-labs/jxl]
+    labels: [approved]
+`},
+			}),
+		},
+		Namespace: "jx",
+	}
+	resolver := &users.GitUserResolver{GitProvider: &draftGitProvider{}, JXClient: jxClient, Namespace: "jx"}
+	approved := "approved"
+	pr := &gits.GitPullRequest{
+		URL:    "https://github.com/jenkins-x-labs/jxl/pull/83",
+		Title:  "Add cheddar",
+		Labels: []*gits.Label{{Name: &approved}},
+	}
+
+	attachments, _, _, err := o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{mention: true})
+	require.NoError(t, err)
+	assert.Equal(t, ":vertical_traffic_light: queued for merge", attachments[0].Fields[0].Value)
+	assert.Equal(t, "Pull Request #83 (Add cheddar): queued for merge", attachments[0].Fallback)
+
+	// once merged the pull request is no longer queued
+	merged := true
+	pr.Merged = &merged
+	attachments, _, buildStatus, err := o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{mention: true})
+	require.NoError(t, err)
+	assert.Equal(t, ":+1: approved", attachments[0].Fields[0].Value)
+	assert.Equal(t, defaultStatuses.Merged, buildStatus)
+	assert.Equal(t, "Pull Request #83 (Add cheddar): merged", attachments[0].Fallback)
+
+	// long titles are truncated in the message, but kept whole in the fallback
+	o.MaxTitleLength = 5
+	attachments, _, _, err = o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{mention: true})
+	require.NoError(t, err)
+	assert.Contains(t, attachments[0].Text, "|Pull Request #83 (Add …)>")
+	assert.Equal(t, "Pull Request #83 (Add cheddar): merged", attachments[0].Fallback)
+}
+
+func TestSlackBotOptions_pipelineName(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *SlackBotOptions
+		branch  string
+		want    string
+	}{
+		{name: "master", options: &SlackBotOptions{}, branch: "master", want: "Release Pipeline"},
+		{name: "main", options: &SlackBotOptions{}, branch: "main", want: "Release Pipeline"},
+		{name: "other branch", options: &SlackBotOptions{}, branch: "feature", want: "Pipeline"},
+		{name: "pull request", options: &SlackBotOptions{}, branch: "PR-12", want: "Pull Request Pipeline"},
+		{name: "custom release branch", options: &SlackBotOptions{ReleaseBranches: []string{"trunk"}},
+			branch: "trunk", want: "Release Pipeline"},
+		{name: "default release branch not configured", options: &SlackBotOptions{ReleaseBranches: []string{"trunk"}},
+			branch: "master", want: "Pipeline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := &record.ActivityRecord{Name: "cheese-wine-1", Owner: "cheese", Repo: "wine", Branch: tt.branch}
+			got, err := tt.options.pipelineName(act)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSlackBotOptions_pipelineTitle(t *testing.T) {
+	act := &record.ActivityRecord{
+		Name:   "cheese-wine-1",
+		Owner:  "cheese",
+		Repo:   "wine",
+		Branch: "master",
+		GitURL: "https://github.com/cheese/wine",
+	}
+	o := &SlackBotOptions{}
+	got, err := o.pipelineTitle(act, repositoryName(act))
+	require.NoError(t, err)
+	assert.Equal(t, "Release Pipeline <https://github.com/cheese/|cheese>/<https://github.com/cheese/wine|wine>", got)
+
+	// the raw name doesn't repeat the repository
+	o.RawPipelineNames = true
+	got, err = o.pipelineTitle(act, repositoryName(act))
+	require.NoError(t, err)
+	assert.Equal(t, "<https://github.com/cheese/|cheese>/<https://github.com/cheese/wine|wine>/master", got)
+
+	// nor names the private repositories
+	got, err = o.pipelineTitle(act, privateRepositoryName(act))
+	require.NoError(t, err)
+	assert.Equal(t, "<https://github.com/cheese/wine|private repository>/master", got)
+}
+
+func Test_sortPipelineActivities(t *testing.T) {
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	activity := func(name string, build string, started time.Duration) jenkinsv1.PipelineActivity {
+		startedTimestamp := metav1.NewTime(start.Add(started))
+		return jenkinsv1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       jenkinsv1.PipelineActivitySpec{Build: build, StartedTimestamp: &startedTimestamp},
+		}
+	}
+	names := func(activities []jenkinsv1.PipelineActivity) []string {
+		var names []string
+		for _, a := range activities {
+			names = append(names, a.Name)
+		}
+		return names
+	}
+	tests := []struct {
+		name       string
+		activities []jenkinsv1.PipelineActivity
+		want       []string
+	}{
+		{
+			name: "by start time",
+			activities: []jenkinsv1.PipelineActivity{
+				activity("pr-1-2", "2", time.Minute), activity("pr-1-1", "1", 0),
+			},
+			want: []string{"pr-1-1", "pr-1-2"},
+		},
+		{
+			name: "numeric build numbers",
+			activities: []jenkinsv1.PipelineActivity{
+				activity("pr-1-10", "10", 0), activity("pr-1-9", "9", 0), activity("pr-1-2", "2", 0),
+			},
+			want: []string{"pr-1-2", "pr-1-9", "pr-1-10"},
+		},
+		{
+			name: "missing build numbers come last",
+			activities: []jenkinsv1.PipelineActivity{
+				activity("pr-1-a", "", 0), activity("pr-1-x", "x", 0), activity("pr-1-3", "3", 0),
+			},
+			want: []string{"pr-1-3", "pr-1-a", "pr-1-x"},
+		},
+		{
+			name: "empty build numbers",
+			activities: []jenkinsv1.PipelineActivity{
+				activity("pr-1-b", "", 2*time.Minute), activity("pr-1-3", "3", time.Minute),
+				activity("pr-1-a", "", 0),
+			},
+			want: []string{"pr-1-a", "pr-1-3", "pr-1-b"},
+		},
+		{
+			name: "non-numeric build numbers",
+			activities: []jenkinsv1.PipelineActivity{
+				activity("pr-1-second", "abc", time.Minute), activity("pr-1-first", "xyz", 0),
+			},
+			want: []string{"pr-1-first", "pr-1-second"},
+		},
+		{
+			name: "same start time",
+			activities: []jenkinsv1.PipelineActivity{
+				activity("pr-1-b", "", 0), activity("pr-1-a", "", 0),
+			},
+			want: []string{"pr-1-a", "pr-1-b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortPipelineActivities(tt.activities)
+			assert.Equal(t, tt.want, names(tt.activities))
+		})
+	}
+}
+
+func TestSlackBotOptions_truncateTitle(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxTitleLength int
+		title          string
+		want           string
+	}{
+		{name: "short title", maxTitleLength: 10, title: "Add brie", want: "Add brie"},
+		{name: "exact length", maxTitleLength: 8, title: "Add brie", want: "Add brie"},
+		{name: "long title", maxTitleLength: 8, title: "Add brie and camembert", want: "Add bri…"},
+		{name: "multibyte title", maxTitleLength: 6, title: "Ajoute le fromage affiné", want: "Ajout…"},
+		{name: "multibyte characters kept whole", maxTitleLength: 4, title: "チーズを追加する", want: "チーズ…"},
+		{name: "emoji kept whole", maxTitleLength: 3, title: "🧀🧀🧀🧀", want: "🧀🧀…"},
+		{name: "default length", title: strings.Repeat("é", 130), want: strings.Repeat("é", 119) + "…"},
+		{name: "disabled", maxTitleLength: -1, title: strings.Repeat("é", 130), want: strings.Repeat("é", 130)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &SlackBotOptions{MaxTitleLength: tt.maxTitleLength}
+			got := o.truncateTitle(tt.title)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, utf8.ValidString(got))
+		})
+	}
+}
+
+func Test_pullRequestName(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		kind string
+		want string
+	}{
+		{name: "github", url: "https://github.com/cheese/wine/pull/12", kind: gits.KindGitHub, want: "#12"},
+		{name: "gitlab", url: "https://gitlab.com/cheese/wine/-/merge_requests/34", kind: gits.KindGitlab,
+			want: "!34"},
+		{name: "gitlab_self_hosted", url: "https://git.example.com/cheese/wine/merge_requests/56",
+			kind: gits.KindGitlab, want: "!56"},
+		{name: "bitbucket_server",
+			url:  "https://bitbucket.example.com/projects/CHEESE/repos/wine/pull-requests/78/overview",
+			kind: gits.KindBitBucketServer, want: "#78"},
+		{name: "bitbucket_cloud", url: "https://bitbucket.org/cheese/wine/pull-requests/90",
+			kind: gits.KindBitBucketCloud, want: "#90"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.kind, gitKindFromURL(tt.url))
+			assert.Equal(t, tt.want, pullRequestNameForKind(tt.kind, tt.url))
+		})
+	}
+}
+
+// kindGitProvider is a Git provider of the kind, the other methods of the provider aren't implemented
+type kindGitProvider struct {
+	gits.GitProvider
+	kind string
+}
+
+func (p *kindGitProvider) Kind() string {
+	return p.kind
+}
+
+func TestSlackBotOptions_getPullRequestNumber(t *testing.T) {
+	tests := []struct {
+		branch string
+		kind   string
+		want   int
+	}{
+		{branch: "PR-12", kind: gits.KindGitHub, want: 12},
+		{branch: "PR-12", kind: gits.KindGitlab, want: 12},
+		{branch: "MR-34", kind: gits.KindGitlab, want: 34},
+		{branch: "master", kind: gits.KindGitlab, want: 0},
+		// MR- branches only build merge requests on GitLab, and only when followed by their number
+		{branch: "MR-34", kind: gits.KindGitHub, want: 0},
+		{branch: "mr-cleanup", kind: gits.KindGitlab, want: 0},
+		{branch: "MR-docs", kind: gits.KindGitHub, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch+"_"+tt.kind, func(t *testing.T) {
+			o := &SlackBotOptions{GlobalClients: &GlobalClients{
+				gitProviderForURL: func(gitURL string) (gits.GitProvider, *gits.GitRepository, error) {
+					return &kindGitProvider{kind: tt.kind}, nil, nil
+				},
+			}}
+			got, err := o.getPullRequestNumber(&record.ActivityRecord{Owner: "cheese", Repo: "wine",
+				Branch: tt.branch, BuildIdentifier: "1", GitURL: "https://git.example.com/cheese/wine.git"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// without a Git provider the kind is guessed from the URL
+	o := &SlackBotOptions{}
+	got, err := o.getPullRequestNumber(&record.ActivityRecord{Owner: "cheese", Repo: "wine", Branch: "MR-34",
+		BuildIdentifier: "1", GitURL: "https://gitlab.com/cheese/wine.git"})
+	require.NoError(t, err)
+	assert.Equal(t, 34, got)
+}
+
+func TestGlobalClients_gitKind(t *testing.T) {
+	created := 0
+	c := &GlobalClients{
+		gitProviderForURL: func(gitURL string) (gits.GitProvider, *gits.GitRepository, error) {
+			created++
+			return &kindGitProvider{kind: gits.KindGitlab}, nil, nil
+		},
+	}
+	// the kind of the provider is used rather than guessed from the URL, and cached by host
+	assert.Equal(t, gits.KindGitlab, c.gitKind("https://git.example.com/cheese/wine/pull/12"))
+	assert.Equal(t, gits.KindGitlab, c.gitKind("https://git.example.com/cheese/brie/pull/13"))
+	assert.Equal(t, 1, created)
+
+	c.gitProviderForURL = func(gitURL string) (gits.GitProvider, *gits.GitRepository, error) {
+		return nil, nil, errors.New("no token for github.com")
+	}
+	assert.Equal(t, gits.KindGitHub, c.gitKind("https://github.com/cheese/wine/pull/12"))
+}
+
+func Test_commitFooter(t *testing.T) {
+	act := &record.ActivityRecord{
+		GitURL:        "https://github.com/cheese/wine.git",
+		LastCommitSHA: "0123456789abcdef",
+	}
+	assert.Equal(t, "<https://github.com/cheese/wine/commit/0123456789abcdef|0123456>", commitFooter(act, nil))
+	pr := &gits.GitPullRequest{Author: &gits.GitUser{Login: "cheddar"}}
+	assert.Equal(t, "<https://github.com/cheese/wine/commit/0123456789abcdef|0123456> by cheddar",
+		commitFooter(act, pr))
+	assert.Equal(t, "", commitFooter(&record.ActivityRecord{}, pr))
+}
+
+func Test_httpsGitURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		gitURL string
+		want   string
+	}{
+		{name: "https", gitURL: "https://github.com/cheese/wine.git", want: "https://github.com/cheese/wine.git"},
+		{name: "scp_like", gitURL: "git@github.com:cheese/wine.git", want: "https://github.com/cheese/wine.git"},
+		{name: "scp_like_no_user", gitURL: "github.com:cheese/wine", want: "https://github.com/cheese/wine"},
+		{name: "ssh", gitURL: "ssh://git@github.com/cheese/wine.git", want: "https://github.com/cheese/wine.git"},
+		{name: "ssh_port", gitURL: "ssh://git@git.example.com:7999/cheese/wine.git",
+			want: "https://git.example.com/cheese/wine.git"},
+		{name: "git_ssh", gitURL: "git+ssh://git@gitlab.com/cheese/wine", want: "https://gitlab.com/cheese/wine"},
+		{name: "empty", gitURL: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, httpsGitURL(tt.gitURL))
+		})
+	}
+}
+
+func Test_repositoryName(t *testing.T) {
+	for _, gitURL := range []string{
+		"https://github.com/cheese/wine.git",
+		"git@github.com:cheese/wine.git",
+		"ssh://git@github.com/cheese/wine.git",
+	} {
+		t.Run(gitURL, func(t *testing.T) {
+			act := &record.ActivityRecord{Owner: "cheese", Repo: "wine", Branch: "master", GitURL: gitURL}
+			assert.Equal(t, "<https://github.com/cheese/|cheese>/<https://github.com/cheese/wine.git|wine>",
+				repositoryName(act))
+			assert.Equal(t, "<https://github.com/cheese/wine/commit/0123456789abcdef|0123456>",
+				mergeShaText(gitURL, "0123456789abcdef"))
+		})
+	}
+}
+
+func Test_statusColor(t *testing.T) {
+	statuses := slackapp.Statuses{
+		Failed:  &slackapp.Status{Color: "#E01E5A"},
+		Running: &slackapp.Status{Emoji: ":runner:"},
+	}
+	assert.Equal(t, "#E01E5A", statusColor(statuses, v1alpha1.FailureState))
+	assert.Equal(t, "#3AA3E3", statusColor(statuses, v1alpha1.RunningState))
+	assert.Equal(t, "good", statusColor(statuses, v1alpha1.SuccessState))
+}
+
+func TestSlackBotOptions_userGroupMention(t *testing.T) {
+	o := &SlackBotOptions{
+		UserGroups: map[string]string{
+			"cheese/cheddar": "S0614TZR7",
+			"brie":           "S0615G0KT",
+		},
+	}
+	tests := []struct {
+		login   string
+		team    bool
+		mention string
+	}{
+		{"cheese/cheddar", true, "<!subteam^S0614TZR7>"},
+		{"@cheese/cheddar", true, "<!subteam^S0614TZR7>"},
+		{"cheese/brie", true, "<!subteam^S0615G0KT>"},
+		{"cheese/wine", true, ""},
+		// a user named like a team isn't mistaken for the team
+		{"brie", false, ""},
+		{"jstrachan", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.login, func(t *testing.T) {
+			team, ok := o.teamReviewer(&gits.GitUser{Login: tt.login})
+			assert.Equal(t, tt.team, ok)
+			if ok {
+				assert.Equal(t, tt.mention, o.userGroupMention(team))
+			}
+		})
+	}
+}
+
+func TestSlackBotOptions_createReviewersMessage_requestedTeams(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/jenkins-x-labs/jxl/pulls/83", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"number": 83,
+			"title": "Add cheddar",
+			"html_url": "https://github.com/jenkins-x-labs/jxl/pull/83",
+			"user": {"login": "brie", "avatar_url": "", "html_url": "https://github.com/brie"},
+			"head": {"sha": "abc"}
+		}`)
+	})
+	mux.HandleFunc("/repos/jenkins-x-labs/jxl/pulls/83/requested_reviewers",
+		func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"users": [], "teams": [{"slug": "cheddar"}]}`)
+		})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+	provider := &gits.GitHubProvider{Client: client, Context: context.Background()}
+
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	clients, _ := newReviewClients(nil, provider)
+	o := &SlackBotOptions{
+		GlobalClients: clients,
+		UserGroups:    map[string]string{"jenkins-x-labs/cheddar": "S0614TZR7"},
+	}
+
+	pr, resolver, err := o.getPullRequest(context.Background(), act)
+	require.NoError(t, err)
+	require.Len(t, pr.RequestedReviewers, 1)
+	assert.Equal(t, "jenkins-x-labs/cheddar", pr.RequestedReviewers[0].Login)
+	attachments, _, _, err := o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{listReviewers: true, mention: true})
+	require.NoError(t, err)
+	assert.Contains(t, attachments[0].Text, "<!subteam^S0614TZR7> please review")
+}
+
+func Test_configChannels(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  slackapp.SlackBotMode
+		want []string
+	}{
+		{name: "none", cfg: slackapp.SlackBotMode{}, want: []string{}},
+		{name: "channel", cfg: slackapp.SlackBotMode{Channel: "cheese"}, want: []string{"#cheese"}},
+		{name: "channels", cfg: slackapp.SlackBotMode{Channels: []string{"cheese", "#wine"}},
+			want: []string{"#cheese", "#wine"}},
+		{name: "channel_and_channels", cfg: slackapp.SlackBotMode{Channel: "cheese", Channels: []string{"#cheese", "wine"}},
+			want: []string{"#cheese", "#wine"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, configChannels(tt.cfg))
+		})
+	}
+}
+
+func Test_activityChannels(t *testing.T) {
+	cfg := slackapp.SlackBotMode{
+		Channel: "builds",
+		Orgs: []slackapp.Org{
+			{
+				Name: "cheese",
+				Repos: []slackapp.Repo{
+					{Name: "wine"},
+					{Name: "cheddar", Channel: "team-cheddar"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, []string{"#team-cheddar"},
+		activityChannels(&record.ActivityRecord{Owner: "cheese", Repo: "cheddar"}, cfg))
+	assert.Equal(t, []string{"#builds"}, activityChannels(&record.ActivityRecord{Owner: "cheese", Repo: "wine"}, cfg))
+	assert.Equal(t, []string{"#builds"},
+		activityChannels(&record.ActivityRecord{Owner: "wine", Repo: "cheddar"}, cfg))
+}
+
+func TestSlackBotOptions_PipelineMessageMultipleChannels(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+		if call.Values.Get("channel") == "#broken" {
+			fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+			return true
+		}
+		return false
+	}
+	o := &SlackBotOptions{
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines: []slackapp.SlackBotMode{{
+			Channel:  "broken",
+			Channels: []string{"cheese"},
+		}},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+
+	err = o.PipelineMessage(act)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "#broken")
+	assert.Len(t, recorder.callsTo("chat.postMessage"), 2)
+	assert.NotNil(t, o.Timestamps["#cheese"][act.Name])
+}
+
+func TestSlackBotOptions_PipelineMessageChannelAndDirectMessageErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		failChannels []string
+		wantErrs     []string
+		wantStored   []string
+	}{
+		{
+			name:         "failing_direct_message",
+			failChannels: []string{"D0001"},
+			wantErrs:     []string{"to U1"},
+			wantStored:   []string{"#cheese", "#wine"},
+		},
+		{
+			name:         "failing_channel",
+			failChannels: []string{"#cheese"},
+			wantErrs:     []string{"to channel #cheese"},
+			wantStored:   []string{"U1", "#wine"},
+		},
+		{
+			name:         "failing_channel_and_direct_message",
+			failChannels: []string{"#cheese", "D0001"},
+			wantErrs:     []string{"to channel #cheese", "to U1"},
+			wantStored:   []string{"#wine"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newSlackRecorder(t)
+			defer recorder.Close()
+			recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+				if call.Method == "chat.postMessage" && util.Contains(tt.failChannels, call.Values.Get("channel")) {
+					fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+					return true
+				}
+				return false
+			}
+			provider := &draftGitProvider{pr: &gits.GitPullRequest{
+				URL:    "https://github.com/jenkins-x-labs/jxl/pull/83",
+				Author: &gits.GitUser{Login: "brie"},
+			}}
+			o := &SlackBotOptions{
+				GlobalClients: &GlobalClients{
+					gitProviderForURL: func(gitURL string) (gits.GitProvider, *gits.GitRepository, error) {
+						gitInfo, err := gits.ParseGitURL(gitURL)
+						return provider, gitInfo, err
+					},
+				},
+				SlackClient:  recorder.client(),
+				Timestamps:   make(map[string]map[string]*MessageReference),
+				UserMappings: map[string]string{"brie": "U1"},
+				Pipelines: []slackapp.SlackBotMode{
+					{Channel: "#cheese", DirectMessage: true},
+					{Channel: "#wine"},
+				},
+			}
+			act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+			require.NoError(t, err, "failed to read files")
+
+			// every failure is reported, without preventing the message from being sent to the other sinks
+			err = o.PipelineMessage(act)
+			require.Error(t, err)
+			for _, want := range tt.wantErrs {
+				assert.Contains(t, err.Error(), want)
+			}
+			assert.Len(t, recorder.callsTo("chat.postMessage"), 3)
+			for _, channel := range tt.wantStored {
+				assert.NotNil(t, o.messageReference(channel, act.Name), "the message to %s should be stored", channel)
+			}
+		})
+	}
+}
+
+func TestSlackBotOptions_ReviewRequestMessageDirectMessageErrors(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+		if call.Method == "conversations.open" && call.Values.Get("users") == "U2" {
+			fmt.Fprint(w, `{"ok":false,"error":"user_not_found"}`)
+			return true
+		}
+		return false
+	}
+	provider := &draftGitProvider{GitProvider: &usersGitProvider{}, pr: &gits.GitPullRequest{
+		URL:    "https://github.com/jenkins-x-labs/jxl/pull/83",
+		Title:  "Add cheddar",
+		Author: &gits.GitUser{Login: "brie", URL: "https://github.com/brie"},
+		RequestedReviewers: []*gits.GitUser{
+			{Login: "cheddar", URL: "https://github.com/cheddar"},
+			{Login: "gouda", URL: "https://github.com/gouda"},
+		},
+	}}
+	clients, _ := newReviewClients(nil, provider)
+	o := &SlackBotOptions{
+		GlobalClients: clients,
+		SlackClient:   recorder.client(),
+		Timestamps:    make(map[string]map[string]*MessageReference),
+		UserMappings:  map[string]string{"brie": "U1", "cheddar": "U2", "gouda": "U3"},
+		PullRequests: []slackapp.SlackBotMode{
+			{Channel: "#cheese", DirectMessage: true, NotifyReviewers: true},
+		},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	// the failure is reported, without preventing the request from being sent to the other reviewers
+	err = o.ReviewRequestMessage(act)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "to U2")
+	assert.NotNil(t, o.messageReference("#cheese", act.Name))
+	assert.Nil(t, o.messageReference("U2", act.Name))
+	assert.NotNil(t, o.messageReference("U3", act.Name))
+}
+
+func TestSlackBotOptions_postMessageSkipsUnchangedUpdates(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+
+	attachments, _, err := o.createPipelineMessage(act, nil, nil, o.Statuses, false)
+	require.NoError(t, err)
+	channel := "#cheese"
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, attachments, nil, true)
+	require.NoError(t, err)
+
+	// the same message is only posted once
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, attachments, nil, true)
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("chat.postMessage"), 1)
+	assert.Len(t, recorder.callsTo("chat.update"), 0)
+
+	// a changed message is updated
+	attachments[0].Color = "danger"
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, attachments, nil, true)
+	require.NoError(t, err)
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, attachments, nil, true)
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("chat.postMessage"), 1)
+	assert.Len(t, recorder.callsTo("chat.update"), 1)
+}
+
+func TestSlackBotOptions_createPipelineMessageCreateIfMissingWindow(t *testing.T) {
+	window := 2 * time.Hour
+	tests := []struct {
+		name   string
+		window time.Duration
+		age    time.Duration
+		want   bool
+	}{
+		{name: "inside_window", window: window, age: window - time.Minute, want: true},
+		{name: "outside_window", window: window, age: window + time.Minute, want: false},
+		{name: "always_create", window: 0, age: 30 * 24 * time.Hour, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &SlackBotOptions{CreateIfMissingWindow: tt.window}
+			act, err := getPipelineActivity("stage_multiple_steps.yaml")
+			require.NoError(t, err, "failed to read files")
+			act.Branch = "master"
+			updated := metav1.NewTime(time.Now().Add(-tt.age))
+			act.StartTime = &updated
+			act.CompletionTime = &updated
+
+			_, createIfMissing, err := o.createPipelineMessage(act, nil, nil, o.Statuses, false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, createIfMissing)
+		})
+	}
+}
+
+func TestSlackBotOptions_DeleteMessage(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	store := NewMemoryTimestampStore()
+	o := &SlackBotOptions{
+		SlackClient:    recorder.client(),
+		Timestamps:     make(map[string]map[string]*MessageReference),
+		TimestampStore: store,
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	channel := "#cheese"
+	err = o.postMessage(channel, false, pullRequestReviewMessageType, act, nil, nil, nil, true)
+	require.NoError(t, err)
+	err = o.postReviewerThreadReply(channel, act, []*slack.User{{ID: "U1"}})
+	require.NoError(t, err)
+	parent := o.Timestamps[channel][act.Name]
+	require.NotNil(t, parent)
+
+	err = o.DeleteMessage(channel, act.Name)
+	require.NoError(t, err)
+	deletes := recorder.callsTo("chat.delete")
+	require.Len(t, deletes, 2)
+	assert.Equal(t, parent.Timestamp, deletes[1].Values.Get("ts"))
+	assert.Empty(t, o.Timestamps[channel])
+	stored, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, stored[channel])
+
+	// deleting a message which isn't known does nothing
+	err = o.DeleteMessage(channel, act.Name)
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("chat.delete"), 2)
+}
+
+func TestSlackBotOptions_DeleteMessageWaitsForLock(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	channel := "#cheese"
+	err = o.postMessage(channel, false, pullRequestReviewMessageType, act, nil, nil, nil, true)
+	require.NoError(t, err)
+
+	// the message is being updated: the deletion waits for the update to be done
+	unlock := o.lockMessage(channel, act.Name)
+	done := make(chan error)
+	go func() {
+		done <- o.DeleteMessage(channel, act.Name)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, recorder.callsTo("chat.delete"))
+	unlock()
+	require.NoError(t, <-done)
+	assert.Len(t, recorder.callsTo("chat.delete"), 1)
+	assert.Empty(t, o.Timestamps[channel])
+}
+
+func TestSlackBotOptions_failureMention(t *testing.T) {
+	o := &SlackBotOptions{
+		Timestamps: make(map[string]map[string]*MessageReference),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	channel := "#cheese"
+	pr := &gits.GitPullRequest{Author: &gits.GitUser{Login: "someone"}}
+
+	// no mention while the pipeline is running
+	act.Status = v1alpha1.RunningState
+	assert.Empty(t, o.failureMention("", o.previousStatus(channel, act), act, pr, nil))
+
+	// the author is shown by their login if they can't be resolved
+	act.Status = v1alpha1.FailureState
+	jxClient := jxfake.NewSimpleClientset()
+	jxClient.PrependReactor("list", "users", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("the server is currently unable to handle the request")
+	})
+	resolver := &users.GitUserResolver{GitProvider: &draftGitProvider{}, JXClient: jxClient, Namespace: "jx"}
+	assert.Equal(t, "someone", o.failureMention("", o.previousStatus(channel, act), act, pr, resolver))
+
+	// no mention if the message already reported the failure
+	o.storeMessageReference(channel, act.Name, &MessageReference{ChannelID: "C0001", Timestamp: "1.000100",
+		Status: v1alpha1.AbortedState})
+	assert.Empty(t, o.failureMention("", o.previousStatus(channel, act), act, pr, nil))
+}
+
+func TestSlackBotOptions_createReviewersMessage_unresolvedUsers(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	jxClient := jxfake.NewSimpleClientset()
+	jxClient.PrependReactor("list", "users", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("the server is currently unable to handle the request")
+	})
+	clients, resolver := newReviewClients(jxClient, nil)
+	o := &SlackBotOptions{GlobalClients: clients}
+	pr := &gits.GitPullRequest{
+		URL:                "https://github.com/jenkins-x-labs/jxl/pull/83",
+		Title:              "Add cheddar",
+		Author:             &gits.GitUser{Login: "someone"},
+		RequestedReviewers: []*gits.GitUser{{Login: "reviewer"}},
+	}
+
+	// the message is still rendered, with the Git logins of the users who couldn't be resolved
+	attachments, reviewers, _, err := o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{listReviewers: true, mention: true})
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Contains(t, attachments[0].Text, "reviewer please review")
+	assert.Contains(t, attachments[0].Text, "by someone")
+	// but nobody can be sent a direct message
+	assert.Empty(t, reviewers)
+}
+
+func Test_withMention(t *testing.T) {
+	attachments := []slack.Attachment{{Title: "build failed"}, {Text: "step"}}
+	withAttachments, blocks := withMention("<@U1>", attachments, nil)
+	assert.Nil(t, blocks)
+	require.Len(t, withAttachments, 2)
+	assert.Equal(t, "<@U1>", withAttachments[0].Pretext)
+	assert.Empty(t, attachments[0].Pretext, "the original attachments must not be modified")
+
+	original := attachmentsToBlocks(attachments)
+	_, withBlocks := withMention("<@U1>", nil, original)
+	require.Len(t, withBlocks, len(original)+1)
+	section, ok := withBlocks[0].(*slack.SectionBlock)
+	require.True(t, ok)
+	assert.Equal(t, "<@U1>", section.Text.Text)
+}
+
+func TestSlackBotOptions_PipelineMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		pipelines []slackapp.SlackBotMode
+		existing  map[string]*MessageReference
+		want      map[string][]string
+	}{
+		{
+			name:      "create",
+			pipelines: []slackapp.SlackBotMode{{Channel: "builds"}},
+			want:      map[string][]string{"chat.postMessage": {"#builds"}},
+		},
+		{
+			name:      "update",
+			pipelines: []slackapp.SlackBotMode{{Channel: "builds"}},
+			existing:  map[string]*MessageReference{"#builds": {ChannelID: "C0001", Timestamp: "1.000100"}},
+			want:      map[string][]string{"chat.update": {"C0001"}},
+		},
+		{
+			name:      "several_channels",
+			pipelines: []slackapp.SlackBotMode{{Channel: "builds", Channels: []string{"releases"}}},
+			existing:  map[string]*MessageReference{"#releases": {ChannelID: "C0002", Timestamp: "1.000100"}},
+			want:      map[string][]string{"chat.postMessage": {"#builds"}, "chat.update": {"C0002"}},
+		},
+		{
+			name:      "other_org",
+			pipelines: []slackapp.SlackBotMode{{Channel: "builds", Orgs: []slackapp.Org{{Name: "cheese"}}}},
+			want:      map[string][]string{},
+		},
+		{
+			name: "contexts",
+			pipelines: []slackapp.SlackBotMode{
+				{Channel: "builds", Contexts: []string{"PR-build"}},
+				{Channel: "integration", Contexts: []string{"integration-tests"}},
+			},
+			want: map[string][]string{"chat.postMessage": {"#builds"}},
+		},
+		{
+			name: "ignored_contexts",
+			pipelines: []slackapp.SlackBotMode{
+				{Channel: "builds", IgnoreContexts: []string{"pr-build"}},
+				{Channel: "integration", IgnoreContexts: []string{"integration-tests"}},
+			},
+			want: map[string][]string{"chat.postMessage": {"#integration"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeSlackClient{}
+			o := &SlackBotOptions{
+				SlackClient: client,
+				Timestamps:  make(map[string]map[string]*MessageReference),
+				Pipelines:   tt.pipelines,
+			}
+			act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+			require.NoError(t, err, "failed to read files")
+			act.Branch = "master"
+			for channel, ref := range tt.existing {
+				o.storeMessageReference(channel, act.Name, ref)
+			}
+
+			err = o.PipelineMessage(act)
+			require.NoError(t, err)
+			got := map[string][]string{}
+			for _, call := range client.calls {
+				if call.Method == "conversations.list" {
+					// resolving the ID of channels posted to for the first time
+					continue
+				}
+				got[call.Method] = append(got[call.Method], call.Values.Get("channel"))
+				var attachments []slack.Attachment
+				err = json.Unmarshal([]byte(call.Values.Get("attachments")), &attachments)
+				require.NoError(t, err)
+				require.NotEmpty(t, attachments)
+				assert.Contains(t, attachments[0].Title, "Release Pipeline")
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSlackBotOptions_postMessageDirect(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	attachments := []slack.Attachment{{Title: "build running"}}
+
+	err = o.postMessage("U0001", true, pipelineMessageType, act, nil, attachments, nil, true)
+	require.NoError(t, err)
+	opens := client.callsTo("conversations.open")
+	require.Len(t, opens, 1)
+	assert.Equal(t, "U0001", opens[0].Values.Get("users"))
+	posts := client.callsTo("chat.postMessage")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "D0001", posts[0].Values.Get("channel"))
+	assert.Equal(t, "D0001", o.Timestamps["U0001"][act.Name].ChannelID)
+
+	// the update is sent to the conversation of the message, without opening it again with its ID
+	attachments = []slack.Attachment{{Title: "build succeeded"}}
+	err = o.postMessage("U0001", true, pipelineMessageType, act, nil, attachments, nil, true)
+	require.NoError(t, err)
+	assert.Len(t, client.callsTo("conversations.open"), 1)
+	updates := client.callsTo("chat.update")
+	require.Len(t, updates, 1)
+	assert.Equal(t, "D0001", updates[0].Values.Get("channel"))
+
+	// failing to post is reported and doesn't store a reference
+	client.err = errors.New("channel_not_found")
+	err = o.postMessage("U0002", true, pipelineMessageType, act, nil, attachments, nil, true)
+	assert.Error(t, err)
+	assert.Nil(t, o.Timestamps["U0002"])
+}
+
+func TestSlackBotOptions_invalidateOnReviewersChange(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	attachments := []slack.Attachment{{Title: "review requested"}}
+	pr := &gits.GitPullRequest{RequestedReviewers: []*gits.GitUser{{Login: "Wine"}, {Login: "cheese"}}}
+	requested := requestedReviewerLogins(pr)
+	assert.Equal(t, []string{"cheese", "wine"}, requested)
+
+	post := func() {
+		o.invalidateOnReviewersChange("#pr", act.Name, requested)
+		err := o.postMessage("#pr", false, pullRequestReviewMessageType, act, nil, attachments, nil, true)
+		require.NoError(t, err)
+		o.storeRenderedReviewers("#pr", act.Name, requested)
+	}
+	post()
+	assert.Equal(t, requested, o.Timestamps["#pr"][act.Name].Reviewers)
+
+	// the same reviewers don't update the message
+	post()
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+	assert.Empty(t, client.callsTo("chat.update"))
+
+	// other reviewers update it even if the rest of the message didn't change
+	requested = requestedReviewerLogins(&gits.GitPullRequest{RequestedReviewers: []*gits.GitUser{{Login: "cheese"}}})
+	post()
+	assert.Len(t, client.callsTo("chat.update"), 1)
+	assert.Equal(t, []string{"cheese"}, o.Timestamps["#pr"][act.Name].Reviewers)
+	assert.NotEmpty(t, o.Timestamps["#pr"][act.Name].Hash)
+}
+
+func TestSlackBotOptions_authorMention(t *testing.T) {
+	resolver := NewSlackUserResolver(nil, nil, "jx")
+	resolver.LookupByEmail = false
+	o := &SlackBotOptions{SlackUserResolver: &resolver}
+	author := &jenkinsv1.User{
+		Spec: jenkinsv1.UserDetails{
+			Accounts: []jenkinsv1.AccountReference{{Provider: resolver.SlackProviderKey(), ID: "U1"}},
+		},
+	}
+	mention, err := o.authorMention("", author)
+	require.NoError(t, err)
+	assert.Equal(t, "<@U1>", mention)
+
+	// authors without a Slack account aren't mentioned
+	mention, err = o.authorMention("", &jenkinsv1.User{})
+	require.NoError(t, err)
+	assert.Empty(t, mention)
+	mention, err = o.authorMention("", nil)
+	require.NoError(t, err)
+	assert.Empty(t, mention)
+}
+
+func Test_ignoredAuthor(t *testing.T) {
+	act := &record.ActivityRecord{Name: "cheese-wine-pr-1-1"}
+	pr := func(login string) *gits.GitPullRequest {
+		return &gits.GitPullRequest{Author: &gits.GitUser{Login: login}}
+	}
+	ignoreAuthors := []string{"dependabot[bot]", "*-bot", "renovate*"}
+	tests := []struct {
+		name string
+		pr   *gits.GitPullRequest
+		want bool
+	}{
+		{name: "not_a_pull_request", pr: nil, want: false},
+		{name: "human", pr: pr("alice"), want: false},
+		{name: "exact", pr: pr("dependabot[bot]"), want: true},
+		{name: "glob_suffix", pr: pr("jenkins-x-bot"), want: true},
+		{name: "glob_prefix_ignoring_case", pr: pr("Renovate-Approve"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ignoredAuthor(act, tt.pr, ignoreAuthors))
+		})
+	}
+}
+
+func TestSlackBotOptions_decorateTitle(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		suffix string
+		want   string
+	}{
+		{name: "none", want: "Pipeline cheese/wine"},
+		{name: "prefix", prefix: "[staging]", want: "[staging] Pipeline cheese/wine"},
+		{name: "suffix", suffix: "(staging)", want: "Pipeline cheese/wine (staging)"},
+		{name: "both", prefix: "[staging]", suffix: ":construction:",
+			want: "[staging] Pipeline cheese/wine :construction:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &SlackBotOptions{MessagePrefix: tt.prefix, MessageSuffix: tt.suffix}
+			assert.Equal(t, tt.want, o.decorateTitle("Pipeline cheese/wine"))
+		})
+	}
+
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	o := &SlackBotOptions{MessagePrefix: "[staging]"}
+	attachments, _, err := o.createPipelineMessage(act, nil, nil, o.Statuses, false)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(attachments[0].Title, "[staging] "), attachments[0].Title)
+}
+
+func TestSlackBotOptions_UserMappings(t *testing.T) {
+	resolver := NewSlackUserResolver(nil, nil, "jx")
+	resolver.LookupByEmail = false
+	o := &SlackBotOptions{
+		SlackUserResolver: &resolver,
+		UserMappings:      map[string]string{"Cheese": "U1"},
+	}
+
+	// mapped logins don't need a user resolver
+	id, err := o.resolveGitUserToSlackUser("", &gits.GitUser{Login: "cheese"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "U1", id)
+	id, err = o.resolveGitUserToSlackUser("", &gits.GitUser{Login: "wine"}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, id)
+
+	// the mappings take precedence over the Slack accounts of the users
+	user := &jenkinsv1.User{
+		Spec: jenkinsv1.UserDetails{
+			Login:    "cheese",
+			Accounts: []jenkinsv1.AccountReference{{Provider: resolver.SlackProviderKey(), ID: "U2"}},
+		},
+	}
+	assert.Equal(t, "<@U1>", o.mentionOrLinkUser("", user))
+	mention, err := o.authorMention("", user)
+	require.NoError(t, err)
+	assert.Equal(t, "<@U1>", mention)
+
+	// users which aren't mapped are resolved
+	user.Spec.Login = "wine"
+	assert.Equal(t, "<@U2>", o.mentionOrLinkUser("", user))
+}
+
+func Test_pullRequestSize(t *testing.T) {
+	label := func(name string) *gits.Label {
+		return &gits.Label{Name: &name}
+	}
+	tests := []struct {
+		name   string
+		labels []*gits.Label
+		want   string
+	}{
+		{name: "no_labels", want: ""},
+		{name: "size_label", labels: []*gits.Label{label("lgtm"), label("size/M")}, want: "M"},
+		{name: "lower_case", labels: []*gits.Label{label("size/xxl")}, want: "XXL"},
+		{name: "unknown_size", labels: []*gits.Label{label("size/huge")}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pullRequestSize(&gits.GitPullRequest{Labels: tt.labels}))
+		})
+	}
+	assert.Empty(t, pullRequestSize(nil))
+}
+
+func TestSlackBotOptions_PipelineMessage_skipPendingState(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines:   skipPendingState([]slackapp.SlackBotMode{{Channel: "#cheese"}}),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+
+	act.Status = v1alpha1.PendingState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Empty(t, client.callsTo("chat.postMessage"))
+
+	// the message is created once the pipeline is running
+	act.Status = v1alpha1.RunningState
+	require.NoError(t, o.PipelineMessage(act))
+	require.Len(t, client.callsTo("chat.postMessage"), 1)
+	assert.Equal(t, v1alpha1.RunningState, o.messageReference("#cheese", act.Name).Status)
+
+	act.Status = v1alpha1.SuccessState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+	assert.Len(t, client.callsTo("chat.update"), 1)
+
+	// the modes configuring createOnStatuses keep them
+	modes := skipPendingState([]slackapp.SlackBotMode{{Channel: "#cheese", CreateOnStatuses: []string{"pending"}}})
+	assert.Equal(t, []string{"pending"}, modes[0].CreateOnStatuses)
+}
+
+func TestSlackBotOptions_PipelineMessage_createOnStatuses(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines:   []slackapp.SlackBotMode{{Channel: "#cheese", CreateOnStatuses: []string{"Running"}}},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+
+	// a late terminal event doesn't post a lone message
+	act.Status = v1alpha1.SuccessState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Empty(t, client.callsTo("chat.postMessage"))
+
+	act.Status = v1alpha1.RunningState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+
+	// the message is still updated once the pipeline has finished
+	act.Status = v1alpha1.SuccessState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+	assert.Len(t, client.callsTo("chat.update"), 1)
+}
@@ -0,0 +1,41 @@
+package slackbot
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/slack-go/slack"
+)
+
+// BuildHistoryLimit is the maximum number of builds listed in the build history of a review message, the older builds
+// are only counted
+const BuildHistoryLimit = 5
+
+// buildHistoryFields returns a field per build of the pull request, from the latest, with the status of the build.
+// There is no history for a single build, as the review message already shows its status
+func buildHistoryFields(all []*record.ActivityRecord, statuses slackapp.Statuses) []slack.AttachmentField {
+	if len(all) < 2 {
+		return nil
+	}
+	var fields []slack.AttachmentField
+	// the activities are sorted by build number, the latest build is last
+	for i := len(all) - 1; i >= 0 && len(fields) < BuildHistoryLimit; i-- {
+		activity := all[i]
+		status := statusForState(statuses, pipelineStatus(activity))
+		if status == nil {
+			status = getStatus(statuses.Unknown, defaultStatuses.Unknown)
+		}
+		fields = append(fields, slack.AttachmentField{
+			Value: fmt.Sprintf("%s %s %s", buildNumber(activity), status.Emoji, status.Text),
+			Short: true,
+		})
+	}
+	if older := len(all) - len(fields); older > 0 {
+		fields = append(fields, slack.AttachmentField{
+			Value: fmt.Sprintf("+%d older builds", older),
+			Short: true,
+		})
+	}
+	return fields
+}
@@ -0,0 +1,42 @@
+package slackbot
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildHistoryFields(t *testing.T) {
+	builds := func(n int) []*record.ActivityRecord {
+		var all []*record.ActivityRecord
+		for i := 1; i <= n; i++ {
+			status := v1alpha1.FailureState
+			if i == n {
+				status = v1alpha1.SuccessState
+			}
+			all = append(all, &record.ActivityRecord{BuildIdentifier: strconv.Itoa(i), Status: status,
+				LinkURL: "https://dashboard.example.com/builds/" + strconv.Itoa(i)})
+		}
+		return all
+	}
+
+	// a single build has no history
+	assert.Empty(t, buildHistoryFields(builds(1), defaultStatuses))
+
+	fields := buildHistoryFields(builds(2), defaultStatuses)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "<https://dashboard.example.com/builds/2|#2> :white_check_mark: build succeeded",
+		fields[0].Value)
+	assert.Equal(t, "<https://dashboard.example.com/builds/1|#1> :red_circle: build failed", fields[1].Value)
+
+	// the older builds are only counted
+	fields = buildHistoryFields(builds(8), defaultStatuses)
+	require.Len(t, fields, BuildHistoryLimit+1)
+	assert.Contains(t, fields[0].Value, "#8")
+	assert.Contains(t, fields[BuildHistoryLimit-1].Value, "#4")
+	assert.Equal(t, "+3 older builds", fields[BuildHistoryLimit].Value)
+}
@@ -0,0 +1,107 @@
+package slackbot
+
+import (
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// ButtonRepository links to the Git repository of the pipeline
+	ButtonRepository = "repository"
+	// ButtonPipeline links to the pipeline
+	ButtonPipeline = "pipeline"
+	// ButtonLogs links to the build logs
+	ButtonLogs = "logs"
+	// ButtonArtifacts links to the artifacts attached to the PipelineActivity
+	ButtonArtifacts = "artifacts"
+)
+
+// pipelineButtonNames are the supported names of the buttons of the pipeline messages
+var pipelineButtonNames = []string{ButtonRepository, ButtonPipeline, ButtonLogs, ButtonArtifacts}
+
+// DefaultPipelineButtons are the buttons of the pipeline messages when none are configured
+var DefaultPipelineButtons = []slackapp.PipelineButton{
+	{Name: ButtonRepository},
+	{Name: ButtonPipeline},
+	{Name: ButtonLogs},
+}
+
+// defaultButtonTexts are the labels of the buttons without a text
+var defaultButtonTexts = map[string]string{
+	ButtonRepository: "Repository",
+	ButtonPipeline:   "Pipeline",
+	ButtonLogs:       "Build Logs",
+	ButtonArtifacts:  "Download artifacts",
+}
+
+// pipelineButtons returns the buttons of the pipeline message for the activity, in the configured order, and the
+// fallback text listing their links. The buttons without a link for the activity are left out.
+func (o *SlackBotOptions) pipelineButtons(activity *record.ActivityRecord, pa *jenkinsv1.PipelineActivity,
+	private bool) ([]slack.AttachmentAction, []string) {
+	buttons := o.PipelineButtons
+	if len(buttons) == 0 {
+		buttons = DefaultPipelineButtons
+	}
+	actions := []slack.AttachmentAction{}
+	fallback := []string{}
+	for _, button := range buttons {
+		var url string
+		switch button.Name {
+		case ButtonRepository:
+			if activity.GitURL == "" {
+				continue
+			}
+			url = httpsGitURL(activity.GitURL)
+			// the fallback is shown as is in the notifications, unlike the URLs of the buttons
+			if !private {
+				fallback = append(fallback, "Repo: "+url)
+			}
+		case ButtonPipeline:
+			url = activity.LinkURL
+			if url != "" {
+				fallback = append(fallback, "Build: "+url)
+			}
+		case ButtonLogs:
+			if activity.LogURL == "" {
+				continue
+			}
+			fallback = append(fallback, "Logs: "+activity.LogURL)
+			url = o.logURL(activity.LogURL)
+		case ButtonArtifacts:
+			url = attachmentURL(pa)
+			if url != "" {
+				fallback = append(fallback, "Artifacts: "+url)
+			}
+		}
+		if url == "" {
+			continue
+		}
+		text := button.Text
+		if text == "" {
+			text = defaultButtonTexts[button.Name]
+		}
+		actions = append(actions, slack.AttachmentAction{
+			Type: "button",
+			Text: text,
+			URL:  url,
+		})
+	}
+	return actions, fallback
+}
+
+// attachmentURL returns the first URL of the attachments of the activity, or an empty string if there are none
+func attachmentURL(pa *jenkinsv1.PipelineActivity) string {
+	if pa == nil {
+		return ""
+	}
+	for _, attachment := range pa.Spec.Attachments {
+		for _, url := range attachment.URLs {
+			if url != "" {
+				return url
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package slackbot
+
+import (
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSlackBotOptions_pipelineButtons(t *testing.T) {
+	pa := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jenkins-x-labs-slack-pr-83-1",
+			Namespace: "jx",
+		},
+		Spec: jenkinsv1.PipelineActivitySpec{
+			Attachments: []jenkinsv1.Attachment{
+				{Name: "reports", URLs: []string{"https://artifacts.example.com/slack/pr-83/1"}},
+			},
+		},
+	}
+	activity := &record.ActivityRecord{
+		Name:    pa.Name,
+		GitURL:  "https://github.com/jenkins-x-labs/slack",
+		LinkURL: "https://dashboard.example.com/jenkins-x-labs/slack/PR-83/1",
+		LogURL:  "https://logs.example.com/jenkins-x-labs/slack/PR-83/1.log",
+	}
+	texts := func(o *SlackBotOptions, activity *record.ActivityRecord, pa *jenkinsv1.PipelineActivity) []string {
+		actions, _ := o.pipelineButtons(activity, pa, false)
+		var texts []string
+		for _, action := range actions {
+			texts = append(texts, action.Text+" "+action.URL)
+		}
+		return texts
+	}
+
+	o := &SlackBotOptions{}
+	assert.Equal(t, []string{
+		"Repository https://github.com/jenkins-x-labs/slack",
+		"Pipeline https://dashboard.example.com/jenkins-x-labs/slack/PR-83/1",
+		"Build Logs https://logs.example.com/jenkins-x-labs/slack/PR-83/1.log",
+	}, texts(o, activity, pa), "the default buttons")
+
+	o.PipelineButtons = []slackapp.PipelineButton{
+		{Name: ButtonLogs, Text: "Logs"},
+		{Name: ButtonArtifacts},
+		{Name: ButtonRepository},
+	}
+	assert.Equal(t, []string{
+		"Logs https://logs.example.com/jenkins-x-labs/slack/PR-83/1.log",
+		"Download artifacts https://artifacts.example.com/slack/pr-83/1",
+		"Repository https://github.com/jenkins-x-labs/slack",
+	}, texts(o, activity, pa), "the configured buttons, in order")
+
+	// the buttons without a link are left out
+	assert.Equal(t, []string{"Repository https://github.com/jenkins-x-labs/slack"},
+		texts(o, &record.ActivityRecord{Name: "missing", GitURL: activity.GitURL}, nil))
+
+	_, fallback := o.pipelineButtons(activity, pa, true)
+	assert.Equal(t, []string{
+		"Logs: https://logs.example.com/jenkins-x-labs/slack/PR-83/1.log",
+		"Artifacts: https://artifacts.example.com/slack/pr-83/1",
+	}, fallback, "the private messages hide the repository")
+}
@@ -0,0 +1,99 @@
+package slackbot
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/slack-go/slack"
+)
+
+// channelMissTTL is how long a channel name which couldn't be resolved is posted to as is, before listing the
+// channels again in case it was created since
+const channelMissTTL = 10 * time.Minute
+
+// slackChannelIDRegex matches the IDs of public (C) and private (G) Slack channels
+var slackChannelIDRegex = regexp.MustCompile(`^[CG][A-Z0-9]{8,}$`)
+
+// isChannelID returns true if the channel is a Slack channel ID rather than a channel name
+func isChannelID(channel string) bool {
+	return slackChannelIDRegex.MatchString(channel)
+}
+
+// resolveChannelID returns the ID of the channel, so that messages target the same channel even when several
+// workspaces of an Enterprise Grid have a channel with the same name. The IDs are listed once and cached, the name is
+// returned as is if it can't be resolved, and for channelMissTTL without listing the channels again.
+func (o *SlackBotOptions) resolveChannelID(ctx context.Context, workspace string, channel string) string {
+	if isChannelID(channel) || o.webAPIDisabled() {
+		return channel
+	}
+	name := strings.TrimPrefix(channel, "#")
+	o.channelIDsLock.Lock()
+	id, ok := o.channelIDs[workspace][name]
+	missed, known := o.channelMisses[workspace][name]
+	o.channelIDsLock.Unlock()
+	if ok {
+		return id
+	}
+	if known && time.Since(missed) < channelMissTTL {
+		return channel
+	}
+	// the channel may have been created since the channels were listed. The channels are listed without holding the
+	// lock, so that the posts to the channels already resolved aren't held back
+	ids, err := o.listChannelIDs(ctx, workspace)
+	if err != nil {
+		log.Logger().Warnf("failed to list the Slack channels to resolve the ID of %s: %v", channel, err)
+		return channel
+	}
+	o.channelIDsLock.Lock()
+	defer o.channelIDsLock.Unlock()
+	if o.channelIDs == nil {
+		o.channelIDs = make(map[string]map[string]string)
+	}
+	o.channelIDs[workspace] = ids
+	if id, ok := ids[name]; ok {
+		delete(o.channelMisses[workspace], name)
+		return id
+	}
+	if o.channelMisses == nil {
+		o.channelMisses = make(map[string]map[string]time.Time)
+	}
+	if o.channelMisses[workspace] == nil {
+		o.channelMisses[workspace] = make(map[string]time.Time)
+	}
+	o.channelMisses[workspace][name] = time.Now()
+	log.Logger().Warnf("No Slack channel found named %s, using the name", channel)
+	return channel
+}
+
+// listChannelIDs returns the IDs of the channels of the workspace keyed by name
+func (o *SlackBotOptions) listChannelIDs(ctx context.Context, workspace string) (map[string]string, error) {
+	ids := make(map[string]string)
+	params := &slack.GetConversationsParameters{
+		ExcludeArchived: "true",
+		Limit:           1000,
+		Types:           []string{"public_channel", "private_channel"},
+	}
+	for {
+		var channels []slack.Channel
+		var cursor string
+		err := o.postWithRetry(ctx, "listing channels", func(ctx context.Context) error {
+			defer observeSlackAPICall("conversations.list", time.Now())
+			var err error
+			channels, cursor, err = o.slackClientFor(workspace).GetConversationsContext(ctx, params)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range channels {
+			ids[c.Name] = c.ID
+		}
+		if cursor == "" {
+			return ids, nil
+		}
+		params.Cursor = cursor
+	}
+}
@@ -0,0 +1,87 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_channelName(t *testing.T) {
+	assert.Equal(t, "#cheese", channelName("cheese"))
+	assert.Equal(t, "#cheese", channelName("#cheese"))
+	assert.Equal(t, "C0123ABCD", channelName("C0123ABCD"))
+	assert.Equal(t, "G0123ABCD", channelName("G0123ABCD"))
+	assert.Equal(t, "#c0123abcd", channelName("c0123abcd"))
+}
+
+func TestSlackBotOptions_resolveChannelID(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+		if call.Method != "conversations.list" {
+			return false
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if call.Values.Get("cursor") == "" {
+			fmt.Fprint(w, `{"ok":true,"channels":[{"id":"C0000WINE","name":"wine"}],
+				"response_metadata":{"next_cursor":"page2"}}`)
+		} else {
+			fmt.Fprint(w, `{"ok":true,"channels":[{"id":"C000CHEESE","name":"cheese"}]}`)
+		}
+		return true
+	}
+	o := &SlackBotOptions{SlackClient: recorder.client()}
+	ctx := context.Background()
+
+	assert.Equal(t, "C000CHEESE", o.resolveChannelID(ctx, "", "#cheese"))
+	assert.Equal(t, "C0000WINE", o.resolveChannelID(ctx, "", "#wine"))
+	require.Len(t, recorder.callsTo("conversations.list"), 2, "the channels should be listed once")
+
+	// IDs are used as is
+	assert.Equal(t, "C0123ABCD", o.resolveChannelID(ctx, "", "C0123ABCD"))
+	require.Len(t, recorder.callsTo("conversations.list"), 2)
+
+	// unknown channels are listed again, in case they were created since, and fall back to the name
+	assert.Equal(t, "#cheddar", o.resolveChannelID(ctx, "", "#cheddar"))
+	assert.Len(t, recorder.callsTo("conversations.list"), 4)
+
+	// but not until channelMissTTL elapsed
+	assert.Equal(t, "#cheddar", o.resolveChannelID(ctx, "", "#cheddar"))
+	assert.Len(t, recorder.callsTo("conversations.list"), 4)
+	o.channelMisses[""]["cheddar"] = time.Now().Add(-channelMissTTL)
+	assert.Equal(t, "#cheddar", o.resolveChannelID(ctx, "", "#cheddar"))
+	assert.Len(t, recorder.callsTo("conversations.list"), 6)
+}
+
+func TestSlackBotOptions_postMessageResolvesChannelID(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+		if call.Method != "conversations.list" {
+			return false
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true,"channels":[{"id":"C000CHEESE","name":"cheese"}]}`)
+		return true
+	}
+	o := &SlackBotOptions{
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	err = o.postMessage("#cheese", false, pipelineMessageType, act, nil, nil, nil, true)
+	require.NoError(t, err)
+	err = o.postMessage("C0123ABCD", false, pipelineMessageType, act, nil, nil, nil, true)
+	require.NoError(t, err)
+	posts := recorder.callsTo("chat.postMessage")
+	require.Len(t, posts, 2)
+	assert.Equal(t, "C000CHEESE", posts[0].Values.Get("channel"))
+	assert.Equal(t, "C0123ABCD", posts[1].Values.Get("channel"))
+}
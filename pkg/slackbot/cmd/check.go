@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	jxcmd "github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/slack/pkg/slackbot"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type SlackAppCheckSlackOptions struct {
+	Cmd   *cobra.Command
+	Args  []string
+	Token string
+}
+
+func NewCmdCheckSlack() *cobra.Command {
+	var options = &SlackAppCheckSlackOptions{}
+
+	var rootCmd = &cobra.Command{
+		Use:   "check-slack",
+		Short: "Check the Slack API can be reached with the token and that it has the scopes the bot uses",
+		Long:  ``,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			jxcmd.CheckErr(err)
+		},
+	}
+	rootCmd.Flags().StringVarP(&options.Token, "token", "", os.Getenv("SLACK_TOKEN"),
+		"The Slack token to check, defaults to the SLACK_TOKEN environment variable")
+	return rootCmd
+}
+
+func (o *SlackAppCheckSlackOptions) Run() error {
+	if o.Token == "" {
+		return fmt.Errorf("no Slack token, use --token or the SLACK_TOKEN environment variable")
+	}
+	client := slackbot.NewSlackClient(o.Token)
+	ctx := context.Background()
+	auth, err := client.AuthTestContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "authenticating with the Slack token")
+	}
+	out := o.Cmd.OutOrStdout()
+	fmt.Fprintf(out, "Authenticated as %s (%s) in workspace %s (%s) %s\n\n", auth.User, auth.UserID, auth.Team,
+		auth.TeamID, auth.URL)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	var missing []string
+	for _, check := range slackbot.CheckSlackScopes(ctx, client) {
+		status := "ok"
+		if check.Err == slackbot.ErrMissingScope {
+			status = "missing"
+		} else if check.Err != nil {
+			status = fmt.Sprintf("unknown: %v", check.Err)
+		}
+		if check.Err != nil && check.Essential {
+			missing = append(missing, check.Scope)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", check.Scope, status)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the essential scopes %s are missing or couldn't be checked", strings.Join(missing, ", "))
+	}
+	return nil
+}
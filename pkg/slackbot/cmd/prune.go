@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	jxcmd "github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	slackappapi "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/jenkins-x/slack/pkg/slackbot"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+type SlackAppPruneOptions struct {
+	Cmd          *cobra.Command
+	Args         []string
+	SlackBotName string
+	DryRun       bool
+}
+
+func NewCmdPrune() *cobra.Command {
+	var options = &SlackAppPruneOptions{}
+
+	var rootCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Delete the messages posted for PipelineActivities which no longer exist",
+		Long:  ``,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			jxcmd.CheckErr(err)
+		},
+	}
+	rootCmd.Flags().StringVarP(&options.SlackBotName, "bot", "", "",
+		"The name of the SlackBot to prune the messages of, all the SlackBots if empty")
+	rootCmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false,
+		"List the orphaned messages instead of deleting them")
+	return rootCmd
+}
+
+func (o *SlackAppPruneOptions) Run() error {
+	clients, err := slackbot.CreateClients()
+	if err != nil {
+		return err
+	}
+	var slackBots []slackappapi.SlackBot
+	if o.SlackBotName != "" {
+		slackBot, err := clients.SlackAppClient.SlackV1alpha1().SlackBots(clients.Namespace).Get(o.SlackBotName,
+			metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "getting SlackBot %s", o.SlackBotName)
+		}
+		slackBots = append(slackBots, *slackBot)
+	} else {
+		list, err := clients.SlackAppClient.SlackV1alpha1().SlackBots(clients.Namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "listing SlackBots in namespace %s", clients.Namespace)
+		}
+		slackBots = list.Items
+	}
+
+	var errs []error
+	for i := range slackBots {
+		bot, err := slackbot.CreateSlackBot(clients, &slackBots[i])
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "creating SlackBot %s", slackBots[i].Name))
+			continue
+		}
+		if o.DryRun {
+			bot.DryRun = true
+		}
+		orphans, err := bot.PruneOrphanedMessages()
+		for _, orphan := range orphans {
+			log.Logger().Infof("SlackBot %s: message for %s in %s is orphaned, PipelineActivity %s no longer exists\n",
+				bot.Name, orphan.Name, orphan.Channel, orphan.Activity)
+		}
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "pruning the messages of SlackBot %s", bot.Name))
+			continue
+		}
+		log.Logger().Infof("SlackBot %s has %d orphaned messages\n", bot.Name, len(orphans))
+	}
+	return utilerrors.NewAggregate(errs)
+}
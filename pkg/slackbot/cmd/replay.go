@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	jxcmd "github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	slackappapi "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/jenkins-x/slack/pkg/slackbot"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+type SlackAppReplayOptions struct {
+	Cmd          *cobra.Command
+	Args         []string
+	Name         string
+	SlackBotName string
+	DryRun       bool
+}
+
+func NewCmdReplay() *cobra.Command {
+	var options = &SlackAppReplayOptions{}
+
+	var rootCmd = &cobra.Command{
+		Use:   "replay",
+		Short: "Send the messages for a PipelineActivity as if it had just been updated",
+		Long:  ``,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			jxcmd.CheckErr(err)
+		},
+	}
+	rootCmd.Flags().StringVarP(&options.Name, "name", "n", "", "The name of the PipelineActivity to replay")
+	rootCmd.Flags().StringVarP(&options.SlackBotName, "bot", "", "",
+		"The name of the SlackBot to replay the PipelineActivity with, all the SlackBots if empty")
+	rootCmd.Flags().BoolVarP(&options.DryRun, "dry-run", "", false,
+		"Log the messages which would be sent instead of posting them to Slack")
+	_ = rootCmd.MarkFlagRequired("name")
+	return rootCmd
+}
+
+func (o *SlackAppReplayOptions) Run() error {
+	clients, err := slackbot.CreateClients()
+	if err != nil {
+		return err
+	}
+	var slackBots []slackappapi.SlackBot
+	if o.SlackBotName != "" {
+		slackBot, err := clients.SlackAppClient.SlackV1alpha1().SlackBots(clients.Namespace).Get(o.SlackBotName,
+			metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "getting SlackBot %s", o.SlackBotName)
+		}
+		slackBots = append(slackBots, *slackBot)
+	} else {
+		list, err := clients.SlackAppClient.SlackV1alpha1().SlackBots(clients.Namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "listing SlackBots in namespace %s", clients.Namespace)
+		}
+		slackBots = list.Items
+	}
+
+	var errs []error
+	for i := range slackBots {
+		bot, err := slackbot.CreateSlackBot(clients, &slackBots[i])
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "creating SlackBot %s", slackBots[i].Name))
+			continue
+		}
+		if o.DryRun {
+			bot.DryRun = true
+		}
+		err = bot.ReplayPipelineActivity(o.Name)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "replaying %s with SlackBot %s", o.Name, bot.Name))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
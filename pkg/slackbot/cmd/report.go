@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	jxcmd "github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	slackappapi "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/jenkins-x/slack/pkg/slackbot"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const defaultUnmappedUsersSince = 7 * 24 * time.Hour
+
+type SlackAppReportUnmappedUsersOptions struct {
+	Cmd          *cobra.Command
+	Args         []string
+	SlackBotName string
+	Since        time.Duration
+}
+
+func NewCmdReport() *cobra.Command {
+	var rootCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Report on the configuration of the SlackBots",
+		Long:  ``,
+	}
+	rootCmd.AddCommand(NewCmdReportUnmappedUsers())
+	return rootCmd
+}
+
+func NewCmdReportUnmappedUsers() *cobra.Command {
+	var options = &SlackAppReportUnmappedUsersOptions{}
+
+	var rootCmd = &cobra.Command{
+		Use:   "unmapped-users",
+		Short: "List the authors of the recent PipelineActivities who can't be resolved to a Slack user",
+		Long:  ``,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			jxcmd.CheckErr(err)
+		},
+	}
+	rootCmd.Flags().StringVarP(&options.SlackBotName, "bot", "", "",
+		"The name of the SlackBot to resolve the users with, all the SlackBots if empty")
+	rootCmd.Flags().DurationVarP(&options.Since, "since", "", defaultUnmappedUsersSince,
+		"How far back to look for PipelineActivities")
+	return rootCmd
+}
+
+func (o *SlackAppReportUnmappedUsersOptions) Run() error {
+	clients, err := slackbot.CreateClients()
+	if err != nil {
+		return err
+	}
+	var slackBots []slackappapi.SlackBot
+	if o.SlackBotName != "" {
+		slackBot, err := clients.SlackAppClient.SlackV1alpha1().SlackBots(clients.Namespace).Get(o.SlackBotName,
+			metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "getting SlackBot %s", o.SlackBotName)
+		}
+		slackBots = append(slackBots, *slackBot)
+	} else {
+		list, err := clients.SlackAppClient.SlackV1alpha1().SlackBots(clients.Namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "listing SlackBots in namespace %s", clients.Namespace)
+		}
+		slackBots = list.Items
+	}
+
+	out := o.Cmd.OutOrStdout()
+	since := time.Now().Add(-o.Since)
+	var errs []error
+	for i := range slackBots {
+		bot, err := slackbot.CreateSlackBot(clients, &slackBots[i])
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "creating SlackBot %s", slackBots[i].Name))
+			continue
+		}
+		unmapped, err := bot.UnmappedUsers(since)
+		if err != nil {
+			// the users which could be resolved are still listed
+			errs = append(errs, errors.Wrapf(err, "resolving the users of SlackBot %s", bot.Name))
+		}
+		fmt.Fprintf(out, "SlackBot %s: %d unmapped user(s)\n", bot.Name, len(unmapped))
+		for _, user := range unmapped {
+			fmt.Fprintf(out, "  - %s (%s), %d PipelineActivities\n", user.Login, user.Provider, user.Activities)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
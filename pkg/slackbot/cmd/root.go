@@ -1,13 +1,20 @@
 package cmd
 
 import (
+	"net/http"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
 	jxcmd "github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/slack/pkg/slackbot"
 	"github.com/spf13/cobra"
 )
 
 type SlackAppOptions struct {
-	Cmd  *cobra.Command
-	Args []string
+	Cmd            *cobra.Command
+	Args           []string
+	MetricsAddress string
+	LogFormat      string
+	SlackAPIURL    string
 }
 
 func NewCmdRoot() *cobra.Command {
@@ -24,11 +31,50 @@ func NewCmdRoot() *cobra.Command {
 			jxcmd.CheckErr(err)
 		},
 	}
+	rootCmd.PersistentFlags().StringVarP(&options.MetricsAddress, "metrics-address", "", "",
+		"The address to serve Prometheus metrics and the /healthz and /readyz probes on, they are not served if empty")
+	rootCmd.PersistentFlags().StringVarP(&options.LogFormat, "log-format", "", "",
+		"The format of the logs, either text or json. Defaults to the JX_LOG_FORMAT environment variable or text")
+	rootCmd.PersistentFlags().StringVarP(&options.SlackAPIURL, "slack-api-url", "", "",
+		"The base URL of the Slack API, such as the URL of a Slack-compatible gateway, defaults to "+
+			"https://slack.com/api/. Slack is reached through the proxy of the HTTPS_PROXY environment variable")
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		err := slackbot.SetLogFormat(options.LogFormat)
+		jxcmd.CheckErr(err)
+		err = slackbot.SetSlackAPIURL(options.SlackAPIURL)
+		jxcmd.CheckErr(err)
+		options.serveMetricsAndProbes()
+	}
+	rootCmd.AddCommand(NewCmdCheckSlack())
 	rootCmd.AddCommand(NewCmdHook())
+	rootCmd.AddCommand(NewCmdPrune())
+	rootCmd.AddCommand(NewCmdReplay())
+	rootCmd.AddCommand(NewCmdReport())
 	rootCmd.AddCommand(NewCmdRun())
+	rootCmd.AddCommand(NewCmdServe())
+	rootCmd.AddCommand(NewCmdValidate())
 	return rootCmd
 }
 
+// serveMetricsAndProbes serves the Prometheus metrics and the liveness and readiness probes in the background if a
+// metrics address is configured
+func (o *SlackAppOptions) serveMetricsAndProbes() {
+	if o.MetricsAddress == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", slackbot.MetricsHandler())
+	mux.Handle("/healthz", slackbot.LivenessHandler())
+	mux.Handle("/readyz", slackbot.ReadinessHandler(slackbot.DefaultReadinessTimeout))
+	go func() {
+		log.Logger().Infof("Serving metrics on %s\n", o.MetricsAddress)
+		err := http.ListenAndServe(o.MetricsAddress, mux)
+		if err != nil {
+			log.Logger().Errorf("failed to serve metrics on %s: %v", o.MetricsAddress, err)
+		}
+	}()
+}
+
 func (o *SlackAppOptions) Run() error {
 	return o.Cmd.Help()
 }
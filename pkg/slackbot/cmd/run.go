@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
 
 	"github.com/pkg/errors"
@@ -29,6 +36,14 @@ type SlackAppRunOptions struct {
 	clients        *slackbot.GlobalClients
 	Items          []*slackbot.SlackBotOptions
 	botChannels    map[types.UID]chan struct{}
+	// bots are the Items keyed by the UID of their SlackBot, so that they are removed when it is updated or deleted
+	bots map[types.UID]*slackbot.SlackBotOptions
+	// lock guards Items, botChannels and bots, which the shutdown reads while the informer updates them
+	lock sync.Mutex
+	// ShutdownGracePeriod is how long the pending updates are given to be sent on SIGTERM
+	ShutdownGracePeriod time.Duration
+	// ConfigDir is the directory of the YAML files of the SlackBot to run, instead of watching the SlackBot resources
+	ConfigDir string
 }
 
 func NewCmdRun() *cobra.Command {
@@ -49,6 +64,11 @@ func NewCmdRun() *cobra.Command {
 		"The name of github webhook secret")
 	rootCmd.Flags().IntVarP(&options.Port, "port", "p", slackbot.DefaultPort,
 		"The port to run the prow external plugin server on")
+	rootCmd.Flags().DurationVarP(&options.ShutdownGracePeriod, "shutdown-grace-period", "",
+		slackbot.DefaultShutdownGracePeriod, "How long the pending Slack updates are given to be sent on SIGTERM")
+	rootCmd.Flags().StringVarP(&options.ConfigDir, "config-dir", "", "",
+		"The directory of the YAML files of the SlackBot to run, merged into a single SlackBot, instead of watching "+
+			"the SlackBot resources")
 	rootCmd.AddCommand(NewCmdHook())
 	return rootCmd
 }
@@ -61,25 +81,38 @@ func (o *SlackAppRunOptions) Run() error {
 	}
 
 	o.botChannels = make(map[types.UID]chan struct{})
+	o.bots = make(map[types.UID]*slackbot.SlackBotOptions)
+	slackbot.RegisterReadinessCheck("kubernetes", slackbot.KubeReadinessCheck(o.clients.KubeClient,
+		o.clients.Namespace))
 
-	log.Logger().Infof("Watching slackbots in namespace %s\n", o.clients.Namespace)
+	defer runtime.HandleCrash()
 
-	factory := informers.NewSharedInformerFactoryWithOptions(o.clients.SlackAppClient, 0, informers.WithNamespace(o.clients.Namespace))
+	if o.ConfigDir != "" {
+		slackBot, err := slackbot.LoadSlackBotDir(o.ConfigDir)
+		if err != nil {
+			return err
+		}
+		slackBot.Namespace = o.clients.Namespace
+		log.Logger().Infof("Running slackbot %s from %s\n", slackBot.Name, o.ConfigDir)
+		o.add(slackBot)
+	} else {
+		log.Logger().Infof("Watching slackbots in namespace %s\n", o.clients.Namespace)
 
-	informer := factory.Slack().V1alpha1().SlackBots().Informer()
+		factory := informers.NewSharedInformerFactoryWithOptions(o.clients.SlackAppClient, 0, informers.WithNamespace(o.clients.Namespace))
 
-	stopper := make(chan struct{})
-	defer close(stopper)
+		informer := factory.Slack().V1alpha1().SlackBots().Informer()
 
-	defer runtime.HandleCrash()
+		stopper := make(chan struct{})
+		defer close(stopper)
 
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    o.add,
-		UpdateFunc: o.onUpdate,
-		DeleteFunc: o.delete,
-	})
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    o.add,
+			UpdateFunc: o.onUpdate,
+			DeleteFunc: o.delete,
+		})
 
-	go informer.Run(stopper)
+		go informer.Run(stopper)
+	}
 
 	isLighthouse := false
 	_, err = o.clients.KubeClient.AppsV1().Deployments(o.clients.Namespace).Get("tide", metav1.GetOptions{})
@@ -97,12 +130,54 @@ func (o *SlackAppRunOptions) Run() error {
 		Port:           o.Port,
 		IsLighthouse:   isLighthouse,
 	}
-	handler := bots.ExternalPluginServer()
-	err = http.ListenAndServe("0.0.0.0:"+strconv.Itoa(o.Port), handler)
-	if err != nil {
+	server := &http.Server{
+		Addr:    "0.0.0.0:" + strconv.Itoa(o.Port),
+		Handler: bots.ExternalPluginServer(),
+	}
+	shutdown := make(chan error, 1)
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+		sig := <-signals
+		log.Logger().Infof("Received %s, shutting down\n", sig)
+		shutdown <- o.shutdown(server)
+	}()
+	err = server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
 		return errors.Wrap(err, "failed to start prow plugin server")
 	}
-	return nil
+	return <-shutdown
+}
+
+// shutdown stops receiving events, then gives the bots the grace period to send their pending updates
+func (o *SlackAppRunOptions) shutdown(server *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.ShutdownGracePeriod)
+	defer cancel()
+	var errs []error
+	err := server.Shutdown(ctx)
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "stopping the prow plugin server"))
+	}
+	o.lock.Lock()
+	bots := append([]*slackbot.SlackBotOptions{}, o.Items...)
+	o.lock.Unlock()
+	for _, bot := range bots {
+		err := bot.Shutdown(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// shutdownBot gives the bot the grace period to send its pending updates once its SlackBot is updated or deleted
+func (o *SlackAppRunOptions) shutdownBot(bot *slackbot.SlackBotOptions) {
+	ctx, cancel := context.WithTimeout(context.Background(), o.ShutdownGracePeriod)
+	defer cancel()
+	err := bot.Shutdown(ctx)
+	if err != nil {
+		log.Logger().Warnf("failed to shut down SlackBot %s: %v", bot.Name, err)
+	}
 }
 
 func (o *SlackAppRunOptions) add(obj interface{}) {
@@ -116,11 +191,25 @@ func (o *SlackAppRunOptions) add(obj interface{}) {
 	if err != nil {
 		log.Logger().Warnf("failed to create slack bot for %s", slackBot.Name)
 	}
-
+	if bot != nil && bot.SlackClient != nil {
+		slackbot.RegisterReadinessCheck(readinessCheckName(slackBot), bot.ReadinessCheck())
+	}
+	if bot == nil {
+		return
+	}
+	stop := make(chan struct{})
+	go bot.RunTimestampGC(stop)
+	go bot.WatchTokenSecret(stop)
+	go bot.RunReviewDigest(stop)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.botChannels[slackBot.UID] = stop
+	o.bots[slackBot.UID] = bot
 	o.Items = append(o.Items, bot)
 }
 
-func (o SlackAppRunOptions) onUpdate(oldObj interface{}, newObj interface{}) {
+func (o *SlackAppRunOptions) onUpdate(oldObj interface{}, newObj interface{}) {
 	o.delete(newObj)
 	o.add(newObj)
 }
@@ -131,6 +220,20 @@ func (o *SlackAppRunOptions) delete(obj interface{}) {
 		log.Logger().Infof("Object is not a PipelineActivity %#v\n", obj)
 		return
 	}
+	slackbot.UnregisterReadinessCheck(readinessCheckName(slackBot))
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if bot := o.bots[slackBot.UID]; bot != nil {
+		delete(o.bots, slackBot.UID)
+		for i, item := range o.Items {
+			if item == bot {
+				o.Items = append(o.Items[:i], o.Items[i+1:]...)
+				break
+			}
+		}
+		// the pending updates of the bot are still sent, the bot replacing it doesn't know about them
+		go o.shutdownBot(bot)
+	}
 	if o.botChannels[slackBot.UID] != nil {
 		close(o.botChannels[slackBot.UID])
 		log.Logger().Info("SlackBot channel closed successfully")
@@ -140,3 +243,8 @@ func (o *SlackAppRunOptions) delete(obj interface{}) {
 		log.Logger().Warnf("No SlackBot named %s found so not deleted", slackBot.Name)
 	}
 }
+
+// readinessCheckName returns the name of the readiness check of the Slack API for the SlackBot
+func readinessCheckName(slackBot *slackappapi.SlackBot) string {
+	return "slack-" + slackBot.Name
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	jxcmd "github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	"github.com/jenkins-x/slack/pkg/slackbot"
+	"github.com/jenkins-x/slack/pkg/slackbot/interaction"
+	"github.com/jenkins-x/slack/pkg/slackbot/slashcommand"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultSigningSecretName = "slack-signing-secret"
+	signingSecretKey         = "signing-secret"
+)
+
+type SlackAppServeOptions struct {
+	Cmd               *cobra.Command
+	Args              []string
+	Port              int
+	SigningSecretName string
+	SlackBotName      string
+}
+
+func NewCmdServe() *cobra.Command {
+	var options = &SlackAppServeOptions{}
+
+	var rootCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the Slack slash commands, e.g. /jx status owner/repo, and the interactive buttons of the messages",
+		Long:  ``,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			jxcmd.CheckErr(err)
+		},
+	}
+	rootCmd.Flags().IntVarP(&options.Port, "port", "p", slackbot.DefaultPort,
+		"The port to serve the slash commands on")
+	rootCmd.Flags().StringVarP(&options.SigningSecretName, "signing-secret-name", "", defaultSigningSecretName,
+		fmt.Sprintf("The name of the secret containing the Slack signing secret in the %s key", signingSecretKey))
+	rootCmd.Flags().StringVarP(&options.SlackBotName, "bot", "", "",
+		"The name of the SlackBot whose statuses are used to render the pipelines")
+	return rootCmd
+}
+
+func (o *SlackAppServeOptions) Run() error {
+	// the lookups in progress are abandoned once the command is terminated
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clients, err := slackbot.CreateClients()
+	if err != nil {
+		return err
+	}
+	secret, err := clients.KubeClient.CoreV1().Secrets(clients.Namespace).Get(o.SigningSecretName,
+		metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "getting signing secret %s", o.SigningSecretName)
+	}
+	signingSecret, ok := secret.Data[signingSecretKey]
+	if !ok {
+		return fmt.Errorf("expected key %s in secret %s", signingSecretKey, o.SigningSecretName)
+	}
+
+	bot := &slackbot.SlackBotOptions{
+		GlobalClients: clients,
+		Namespace:     clients.Namespace,
+	}
+	if o.SlackBotName != "" {
+		slackBot, err := clients.SlackAppClient.SlackV1alpha1().SlackBots(clients.Namespace).Get(o.SlackBotName,
+			metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "getting SlackBot %s", o.SlackBotName)
+		}
+		bot, err = slackbot.CreateSlackBot(clients, slackBot)
+		if err != nil {
+			return errors.Wrapf(err, "creating SlackBot %s", o.SlackBotName)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/commands", &slashcommand.Handler{
+		SigningSecret: string(signingSecret),
+		Lookup:        bot.LatestPipelineStatus,
+		SlackClient:   bot.SlackClient,
+		Context:       ctx,
+	})
+	interactions := &interaction.Handler{
+		SigningSecret: string(signingSecret),
+	}
+	bot.RegisterActions(interactions)
+	mux.Handle("/interactions", interactions)
+	server := &http.Server{
+		Addr:    "0.0.0.0:" + strconv.Itoa(o.Port),
+		Handler: mux,
+	}
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+		sig := <-signals
+		log.Logger().Infof("Received %s, shutting down\n", sig)
+		cancel()
+		err := server.Shutdown(context.Background())
+		if err != nil {
+			log.Logger().Warnf("failed to stop the slash command server: %v", err)
+		}
+	}()
+	log.Logger().Infof("Serving slash commands and interactions on port %d\n", o.Port)
+	err = server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(err, "failed to start slash command server")
+	}
+	return nil
+}
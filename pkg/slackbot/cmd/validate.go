@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	jxcmd "github.com/jenkins-x/jx/v2/pkg/cmd/helper"
+	slackappapi "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/jenkins-x/slack/pkg/slackbot"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type SlackAppValidateOptions struct {
+	Cmd  *cobra.Command
+	Args []string
+}
+
+func NewCmdValidate() *cobra.Command {
+	var options = &SlackAppValidateOptions{}
+
+	var rootCmd = &cobra.Command{
+		Use:   "validate <file or directory>...",
+		Short: "Validate SlackBot configuration files, or directories of files merged into a single SlackBot",
+		Long:  ``,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.Cmd = cmd
+			options.Args = args
+			err := options.Run()
+			jxcmd.CheckErr(err)
+		},
+	}
+	return rootCmd
+}
+
+func (o *SlackAppValidateOptions) Run() error {
+	out := o.Cmd.OutOrStdout()
+	invalid := 0
+	for _, file := range o.Args {
+		var problems []error
+		slackBot, err := loadSlackBot(file)
+		if err != nil {
+			problems = append(problems, err)
+		} else {
+			problems = slackbot.ValidateSlackBot(slackBot)
+		}
+		if len(problems) == 0 {
+			fmt.Fprintf(out, "%s: OK\n", file)
+			continue
+		}
+		invalid++
+		fmt.Fprintf(out, "%s: %d problem(s)\n", file, len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(out, "  - %v\n", p)
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d file(s) are invalid", invalid, len(o.Args))
+	}
+	return nil
+}
+
+// loadSlackBot loads the SlackBot of the file, or merges the SlackBots of the files of the directory
+func loadSlackBot(file string) (*slackappapi.SlackBot, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", file)
+	}
+	if info.IsDir() {
+		return slackbot.LoadSlackBotDir(file)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", file)
+	}
+	return slackbot.LoadSlackBot(data)
+}
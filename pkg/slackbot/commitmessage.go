@@ -0,0 +1,39 @@
+package slackbot
+
+import (
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/slack-go/slack"
+)
+
+// maxCommitMessageLength is the number of characters the commit messages are truncated to
+const maxCommitMessageLength = 100
+
+// slackEscaper escapes the characters Slack uses for its links and mentions
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// commitMessageField returns the field showing the first line of the message of the commit the pipeline built, or no
+// field if the message isn't known
+func (o *SlackBotOptions) commitMessageField(pa *jenkinsv1.PipelineActivity) []slack.AttachmentField {
+	if pa == nil {
+		return nil
+	}
+	message := commitSubject(pa.Spec.LastCommitMessage)
+	if message == "" {
+		return nil
+	}
+	return []slack.AttachmentField{{
+		Title: o.messageCatalog().commitFieldTitle,
+		Value: slackEscaper.Replace(truncateText(message, maxCommitMessageLength)),
+	}}
+}
+
+// commitSubject returns the first line of the commit message
+func commitSubject(message string) string {
+	message = strings.TrimSpace(message)
+	if i := strings.IndexAny(message, "\r\n"); i >= 0 {
+		message = message[:i]
+	}
+	return strings.TrimSpace(message)
+}
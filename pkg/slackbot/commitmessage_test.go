@@ -0,0 +1,38 @@
+package slackbot
+
+import (
+	"strings"
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_commitSubject(t *testing.T) {
+	assert.Equal(t, "Add cheddar", commitSubject("Add cheddar"))
+	assert.Equal(t, "Add cheddar", commitSubject("\n  Add cheddar\r\n\nIt's a hard cheese\n"))
+	assert.Equal(t, "", commitSubject(""))
+}
+
+func TestSlackBotOptions_commitMessageField(t *testing.T) {
+	activity := func(message string) *jenkinsv1.PipelineActivity {
+		return &jenkinsv1.PipelineActivity{
+			Spec: jenkinsv1.PipelineActivitySpec{LastCommitMessage: message},
+		}
+	}
+	o := &SlackBotOptions{}
+
+	fields := o.commitMessageField(activity("Add <cheddar> & brie\n\nFixes #82"))
+	require.Len(t, fields, 1)
+	assert.Equal(t, "Commit", fields[0].Title)
+	assert.Equal(t, "Add &lt;cheddar&gt; &amp; brie", fields[0].Value, "the subject should be escaped")
+
+	fields = o.commitMessageField(activity(strings.Repeat("cheese ", 20)))
+	require.Len(t, fields, 1)
+	assert.Len(t, []rune(fields[0].Value), maxCommitMessageLength)
+	assert.True(t, strings.HasSuffix(fields[0].Value, "…"))
+
+	assert.Empty(t, o.commitMessageField(activity("")))
+	assert.Empty(t, o.commitMessageField(nil))
+}
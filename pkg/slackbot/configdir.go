@@ -0,0 +1,95 @@
+package slackbot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// isConfigFile returns true if the file is a YAML file holding a SlackBot
+func isConfigFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// LoadSlackBotDir loads the SlackBots of all the YAML files of the directory and merges them into a single SlackBot,
+// so that each team can own the file routing its pipelines and pull requests
+func LoadSlackBotDir(dir string) (*slackapp.SlackBot, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading config directory %s", dir)
+	}
+	var files []string
+	for _, info := range infos {
+		if !info.IsDir() && isConfigFile(info.Name()) {
+			files = append(files, filepath.Join(dir, info.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no YAML file in config directory %s", dir)
+	}
+	sort.Strings(files)
+	var slackBots []*slackapp.SlackBot
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s", file)
+		}
+		slackBot, err := LoadSlackBot(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "loading %s", file)
+		}
+		slackBots = append(slackBots, slackBot)
+	}
+	return mergeSlackBots(files, slackBots)
+}
+
+// mergeSlackBots merges the SlackBots loaded from the files, in order. The pipelines and pull requests are
+// concatenated, the other settings (such as the statuses or the token) can be set by any of the files but must not
+// differ from one file to another
+func mergeSlackBots(files []string, slackBots []*slackapp.SlackBot) (*slackapp.SlackBot, error) {
+	merged := &slackapp.SlackBot{}
+	// the file which set each setting, to report the conflicts
+	setBy := make(map[string]string)
+	for i, slackBot := range slackBots {
+		file := files[i]
+		if slackBot.Name != "" {
+			if merged.Name != "" && merged.Name != slackBot.Name {
+				return nil, fmt.Errorf("conflicting metadata.name in %s and %s", setBy["metadata.name"], file)
+			}
+			merged.Name = slackBot.Name
+			setBy["metadata.name"] = file
+		}
+		merged.Spec.Pipelines = append(merged.Spec.Pipelines, slackBot.Spec.Pipelines...)
+		merged.Spec.PullRequests = append(merged.Spec.PullRequests, slackBot.Spec.PullRequests...)
+
+		from := reflect.ValueOf(slackBot.Spec)
+		to := reflect.ValueOf(&merged.Spec).Elem()
+		for i := 0; i < from.NumField(); i++ {
+			field := from.Type().Field(i)
+			if field.Name == "Pipelines" || field.Name == "PullRequests" {
+				continue
+			}
+			value := from.Field(i)
+			if reflect.DeepEqual(value.Interface(), reflect.Zero(field.Type).Interface()) {
+				continue
+			}
+			name := "spec." + strings.Split(field.Tag.Get("json"), ",")[0]
+			if previous, ok := setBy[name]; ok && !reflect.DeepEqual(to.Field(i).Interface(), value.Interface()) {
+				return nil, fmt.Errorf("conflicting %s in %s and %s", name, previous, file)
+			}
+			to.Field(i).Set(value)
+			setBy[name] = file
+		}
+	}
+	if merged.Name == "" {
+		return nil, fmt.Errorf("none of the files %s sets metadata.name", strings.Join(files, ", "))
+	}
+	return merged, nil
+}
@@ -0,0 +1,37 @@
+package slackbot
+
+import (
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSlackBotDir(t *testing.T) {
+	slackBot, err := LoadSlackBotDir(path.Join("test_data", "configdir", "teams"))
+	require.NoError(t, err)
+	assert.Equal(t, "cheese-bot", slackBot.Name)
+	assert.Equal(t, "slack-token", slackBot.Spec.TokenReference.Name)
+	require.NotNil(t, slackBot.Spec.Statuses.Failed)
+	assert.Equal(t, ":boom:", slackBot.Spec.Statuses.Failed.Emoji)
+
+	// the modes of all the files are concatenated, in the order of the files
+	var channels []string
+	for _, cfg := range slackBot.Spec.Pipelines {
+		channels = append(channels, cfg.Channel)
+	}
+	assert.Equal(t, []string{"brie-builds", "cheddar-builds"}, channels)
+	require.Len(t, slackBot.Spec.PullRequests, 1)
+	assert.Equal(t, "brie-reviews", slackBot.Spec.PullRequests[0].Channel)
+}
+
+func TestLoadSlackBotDir_conflict(t *testing.T) {
+	dir := path.Join("test_data", "configdir", "conflicting")
+	_, err := LoadSlackBotDir(dir)
+	assert.EqualError(t, err, "conflicting spec.statuses in "+path.Join(dir, "common.yaml")+" and "+
+		path.Join(dir, "team-brie.yaml"))
+
+	_, err = LoadSlackBotDir(path.Join("test_data", "users"))
+	assert.Error(t, err, "a directory without SlackBots")
+}
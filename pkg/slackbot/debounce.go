@@ -0,0 +1,129 @@
+package slackbot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/pkg/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Debouncer collapses the updates of a message made within a window into a single update, sent once no update has
+// been made for the duration of the window
+type Debouncer struct {
+	// Window is how long to wait for further updates, the updates are sent straight away if it is zero or negative
+	Window time.Duration
+
+	lock    sync.Mutex
+	timers  map[string]*time.Timer
+	pending map[string]func() error
+	// flushed is set once the pending updates were flushed, later updates are sent straight away
+	flushed bool
+	// firing tracks the updates being sent once their window elapsed
+	firing sync.WaitGroup
+}
+
+// NewDebouncer creates a Debouncer which waits for window before sending an update
+func NewDebouncer(window time.Duration) *Debouncer {
+	return &Debouncer{
+		Window: window,
+	}
+}
+
+// Debounce schedules send to be called once no other update has been made for the key during the window, replacing
+// any update already scheduled. If debouncing is disabled send is called straight away.
+func (d *Debouncer) Debounce(key string, send func() error) error {
+	if d == nil || d.Window <= 0 {
+		return send()
+	}
+	d.lock.Lock()
+	if d.flushed {
+		d.lock.Unlock()
+		return send()
+	}
+	defer d.lock.Unlock()
+	if d.timers == nil {
+		d.timers = make(map[string]*time.Timer)
+		d.pending = make(map[string]func() error)
+	}
+	if timer, ok := d.timers[key]; ok {
+		timer.Stop()
+	}
+	d.pending[key] = send
+	d.timers[key] = time.AfterFunc(d.Window, func() {
+		d.fire(key)
+	})
+	return nil
+}
+
+// Cancel discards the update scheduled for the key, if any. An update whose window already elapsed can't be
+// cancelled anymore, so the send functions check the update is still needed once they are called.
+func (d *Debouncer) Cancel(key string) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if timer, ok := d.timers[key]; ok {
+		timer.Stop()
+		delete(d.timers, key)
+		delete(d.pending, key)
+	}
+}
+
+// Flush sends the scheduled updates straight away, and waits for the updates already being sent. Updates made after
+// Flush are sent without being debounced.
+func (d *Debouncer) Flush() error {
+	if d == nil {
+		return nil
+	}
+	d.lock.Lock()
+	pending := d.pending
+	for _, timer := range d.timers {
+		timer.Stop()
+	}
+	d.timers = nil
+	d.pending = nil
+	d.flushed = true
+	d.lock.Unlock()
+
+	var errs []error
+	for key, send := range pending {
+		err := send()
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "flushing debounced update %s", key))
+		}
+	}
+	d.firing.Wait()
+	return utilerrors.NewAggregate(errs)
+}
+
+func (d *Debouncer) fire(key string) {
+	d.lock.Lock()
+	send := d.pending[key]
+	delete(d.timers, key)
+	delete(d.pending, key)
+	if send != nil {
+		d.firing.Add(1)
+	}
+	d.lock.Unlock()
+	if send == nil {
+		return
+	}
+	defer d.firing.Done()
+	err := send()
+	if err != nil {
+		log.Logger().Warnf("failed to send debounced update %s: %v", key, err)
+	}
+}
+
+// isTerminalState returns true if the pipeline has finished
+func isTerminalState(status v1alpha1.PipelineState) bool {
+	switch status {
+	case v1alpha1.SuccessState, v1alpha1.FailureState, v1alpha1.AbortedState:
+		return true
+	}
+	return false
+}
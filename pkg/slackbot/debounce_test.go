@@ -0,0 +1,153 @@
+package slackbot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebouncer_Debounce(t *testing.T) {
+	d := NewDebouncer(20 * time.Millisecond)
+	var lock sync.Mutex
+	sent := []int{}
+	for i := 0; i < 3; i++ {
+		i := i
+		err := d.Debounce("cheese", func() error {
+			lock.Lock()
+			defer lock.Unlock()
+			sent = append(sent, i)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(sent) > 0
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []int{2}, sent)
+}
+
+func TestDebouncer_Disabled(t *testing.T) {
+	var d *Debouncer
+	called := false
+	err := d.Debounce("cheese", func() error {
+		called = true
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestSlackBotOptions_postMessageDebounce(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Debouncer:   NewDebouncer(time.Hour),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	act.Status = v1alpha1.RunningState
+	channel := "#cheese"
+
+	// the first message is posted straight away
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, []slack.Attachment{{Text: "running"}}, nil,
+		true)
+	require.NoError(t, err)
+	require.Len(t, recorder.callsTo("chat.postMessage"), 1)
+
+	// updates of a running pipeline are held back
+	for _, text := range []string{"stage 1", "stage 2"} {
+		err = o.postMessage(channel, false, pipelineMessageType, act, nil, []slack.Attachment{{Text: text}}, nil,
+			true)
+		require.NoError(t, err)
+	}
+	assert.Len(t, recorder.callsTo("chat.update"), 0)
+
+	// the final state is sent straight away, replacing the pending update
+	act.Status = v1alpha1.SuccessState
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, []slack.Attachment{{Text: "succeeded"}}, nil,
+		true)
+	require.NoError(t, err)
+	updates := recorder.callsTo("chat.update")
+	require.Len(t, updates, 1)
+	assert.Contains(t, updates[0].Values.Get("attachments"), "succeeded")
+	assert.Empty(t, o.Debouncer.pending)
+}
+
+func TestDebouncer_Flush(t *testing.T) {
+	d := NewDebouncer(time.Hour)
+	sent := []string{}
+	for _, key := range []string{"cheese", "wine"} {
+		key := key
+		err := d.Debounce(key, func() error {
+			sent = append(sent, key)
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	assert.Empty(t, sent)
+
+	err := d.Flush()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"cheese", "wine"}, sent)
+
+	// updates made once flushed aren't debounced
+	err = d.Debounce("bread", func() error {
+		sent = append(sent, "bread")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, sent, 3)
+}
+
+func TestSlackBotOptions_postMessageDebounce_staleUpdate(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Debouncer:   NewDebouncer(time.Hour),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	act.Status = v1alpha1.RunningState
+	channel := "#cheese"
+
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, []slack.Attachment{{Text: "running"}}, nil,
+		true)
+	require.NoError(t, err)
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, []slack.Attachment{{Text: "stage 1"}}, nil,
+		true)
+	require.NoError(t, err)
+	assert.Empty(t, o.messageLocks, "the message shouldn't stay locked while the update waits")
+	key := channel + "/" + act.Name
+	// the window of the update elapsed just before the final state is posted, which can't cancel it anymore
+	o.Debouncer.lock.Lock()
+	fired := o.Debouncer.pending[key]
+	o.Debouncer.lock.Unlock()
+	require.NotNil(t, fired)
+
+	act.Status = v1alpha1.SuccessState
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, []slack.Attachment{{Text: "succeeded"}}, nil,
+		true)
+	require.NoError(t, err)
+	require.Len(t, client.callsTo("chat.update"), 1)
+
+	// the stale update doesn't overwrite the final state
+	err = fired()
+	require.NoError(t, err)
+	assert.Len(t, client.callsTo("chat.update"), 1)
+	assert.Equal(t, v1alpha1.SuccessState, o.messageReference(channel, act.Name).Status)
+}
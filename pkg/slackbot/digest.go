@@ -0,0 +1,286 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// DefaultGitServer hosts the repositories of the review digest when no Git server is configured
+	DefaultGitServer = "https://github.com"
+
+	reviewDigestMessageType = "digest"
+)
+
+// parseReviewDigestTime returns the location and the time of day (as a duration since midnight) of the digest
+func parseReviewDigestTime(digest *slackapp.ReviewDigest) (*time.Location, time.Duration, error) {
+	location, err := time.LoadLocation(digest.TimeZone)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "loading review digest time zone %s", digest.TimeZone)
+	}
+	timeOfDay, err := parseTimeOfDay(digest.Time)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "parsing review digest time %s", digest.Time)
+	}
+	return location, timeOfDay, nil
+}
+
+// nextReviewDigest returns when the next digest is due after now
+func nextReviewDigest(digest *slackapp.ReviewDigest, now time.Time) (time.Time, error) {
+	location, timeOfDay, err := parseReviewDigestTime(digest)
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := now.In(location)
+	hour, minute := int(timeOfDay/time.Hour), int(timeOfDay%time.Hour/time.Minute)
+	next := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, location)
+	if !next.After(now) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, hour, minute, 0, 0, location)
+	}
+	return next, nil
+}
+
+// RunReviewDigest posts the digest of the pull requests waiting for a review every day at the time of the
+// ReviewDigest, until stop is closed
+func (o *SlackBotOptions) RunReviewDigest(stop <-chan struct{}) {
+	if o.ReviewDigest == nil {
+		return
+	}
+	for {
+		next, err := nextReviewDigest(o.ReviewDigest, time.Now())
+		if err != nil {
+			log.Logger().Warnf("failed to schedule the review digest of %s: %v", o.Name, err)
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+			err := o.PostReviewDigest(context.Background())
+			if err != nil {
+				log.Logger().Warnf("failed to post the review digest of %s: %v", o.Name, err)
+			}
+		}
+	}
+}
+
+// PostReviewDigest posts a message to each channel listing the open pull requests of its repositories which are
+// waiting for a review, the channels without any are skipped
+func (o *SlackBotOptions) PostReviewDigest(ctx context.Context) error {
+	entries, errs := o.reviewDigestEntries(ctx)
+	var channels []string
+	for channel := range entries {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	for _, channel := range channels {
+		err := o.postReviewDigest(ctx, channel, entries[channel])
+		if err != nil {
+			// carry on posting to the other channels
+			errs = append(errs, errors.Wrapf(err, "posting the review digest to %s", channel))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// reviewDigestEntries returns the lines listing the pull requests waiting for a review keyed by channel. Only the
+// repositories of the orgs of the pull request modes are included, see digestRepositories
+func (o *SlackBotOptions) reviewDigestEntries(ctx context.Context) (map[string][]string, []error) {
+	server := strings.TrimSuffix(o.ReviewDigest.GitServer, "/")
+	if server == "" {
+		server = DefaultGitServer
+	}
+	entries := make(map[string][]string)
+	listed := make(map[string]map[string]bool)
+	var errs []error
+	for _, cfg := range o.PullRequests {
+		for _, org := range cfg.Orgs {
+			repos, err := o.digestRepositories(ctx, server, cfg, org)
+			if err != nil {
+				// the repositories listed in the configuration are still included
+				errs = append(errs, errors.Wrapf(err, "listing the repositories of %s", org.Name))
+			}
+			for _, repo := range repos {
+				repoActivity := &record.ActivityRecord{Owner: org.Name, Repo: repo,
+					GitURL: fmt.Sprintf("%s/%s/%s.git", server, org.Name, repo)}
+				prs, err := o.listOpenPullRequests(ctx, repoActivity)
+				if err != nil {
+					errs = append(errs, errors.Wrapf(err, "listing the open pull requests of %s/%s", org.Name,
+						repo))
+					continue
+				}
+				channels := o.ReviewDigest.Channels
+				if len(channels) == 0 {
+					channels = activityChannels(repoActivity, cfg)
+				}
+				statuses := o.statusesFor(cfg, repoActivity)
+				for _, pr := range prs {
+					if pr == nil || pr.Number == nil {
+						continue
+					}
+					activity := *repoActivity
+					activity.Name = strings.ToLower(fmt.Sprintf("%s-%s-pr-%d", org.Name, repo, *pr.Number))
+					activity.Branch = fmt.Sprintf("PR-%d", *pr.Number)
+					entry, err := o.reviewDigestEntry(&activity, cfg, pr, statuses)
+					if err != nil {
+						errs = append(errs, err)
+						continue
+					}
+					if entry == "" {
+						continue
+					}
+					for _, channel := range channels {
+						channel = channelName(channel)
+						if listed[channel] == nil {
+							listed[channel] = make(map[string]bool)
+						}
+						if listed[channel][pr.URL] {
+							continue
+						}
+						listed[channel][pr.URL] = true
+						entries[channel] = append(entries[channel], entry)
+					}
+				}
+			}
+		}
+	}
+	return entries, errs
+}
+
+// digestRepositories returns the names of the repositories of the org to include in the digest. As for the other
+// messages, an org configured without repositories nor repoPattern includes all its repositories, and the repoPattern
+// adds the repositories it matches, so these are listed with the Git provider. The archived repositories are skipped
+func (o *SlackBotOptions) digestRepositories(ctx context.Context, server string, cfg slackapp.SlackBotMode,
+	org slackapp.Org) ([]string, error) {
+	var repos []string
+	for _, repo := range org.Repos {
+		repos = append(repos, repo.Name)
+	}
+	if len(org.Repos) > 0 && cfg.RepoPattern == "" {
+		return repos, nil
+	}
+	// the Git provider only depends on the server and the owner of the URL, not on the repository
+	gitProvider, _, err := o.createGitProviderForURL(fmt.Sprintf("%s/%s/.github.git", server, org.Name))
+	if err != nil {
+		return repos, err
+	}
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+	var orgRepos []*gits.GitRepository
+	err = callWithContext(ctx, fmt.Sprintf("listing repositories of %s", org.Name), func() error {
+		var err error
+		orgRepos, err = gitProvider.ListRepositories(org.Name)
+		return err
+	})
+	if err != nil {
+		return repos, err
+	}
+	for _, repo := range orgRepos {
+		if repo == nil || repo.Archived || util.Contains(repos, repo.Name) {
+			continue
+		}
+		if cfg.RepoPattern == "" || o.matchesRepoPattern(cfg, repo.Name) {
+			repos = append(repos, repo.Name)
+		}
+	}
+	return repos, nil
+}
+
+// reviewDigestEntry returns the line listing the pull request with its review status, or an empty string if the
+// pull request isn't waiting for a review
+func (o *SlackBotOptions) reviewDigestEntry(activity *record.ActivityRecord, cfg slackapp.SlackBotMode,
+	pr *gits.GitPullRequest, statuses slackapp.Statuses) (string, error) {
+	if isDraft(pr) || !matchesLabels(activity, pr, cfg.IgnoreLabels, cfg.IncludeLabels) ||
+		ignoredAuthor(activity, pr, cfg.IgnoreAuthors) {
+		return "", nil
+	}
+	reviewStatus, _, err := o.reviewStatus(activity, pr, statuses)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting the review status of %s", pr.URL)
+	}
+	for _, reviewed := range []*slackapp.Status{
+		getStatus(statuses.Approved, defaultStatuses.Approved),
+		getStatus(statuses.LGTM, defaultStatuses.LGTM),
+		getStatus(statuses.Merging, defaultStatuses.Merging),
+		getStatus(statuses.Queued, defaultStatuses.Queued),
+	} {
+		if reviewStatus == reviewed {
+			return "", nil
+		}
+	}
+	title := fmt.Sprintf("%s/%s#%d %s", activity.Owner, activity.Repo, *pr.Number, pr.Title)
+	return fmt.Sprintf("• %s by %s %s %s", link(title, pr.URL), gitLogin(pr.Author), reviewStatus.Emoji,
+		reviewStatus.Text), nil
+}
+
+// listOpenPullRequests returns the open pull requests of the repository of the activity
+func (o *SlackBotOptions) listOpenPullRequests(ctx context.Context, activity *record.ActivityRecord) (
+	[]*gits.GitPullRequest, error) {
+	gitProvider, _, err := o.createGitProviderForURL(activity.GitURL)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+	var prs []*gits.GitPullRequest
+	err = callWithContext(ctx, fmt.Sprintf("listing pull requests of %s", activity.GitURL), func() error {
+		var err error
+		prs, err = gitProvider.ListOpenPullRequests(activity.Owner, activity.Repo)
+		return err
+	})
+	return prs, err
+}
+
+// postReviewDigest posts the digest listing the entries to the channel
+func (o *SlackBotOptions) postReviewDigest(ctx context.Context, channel string, entries []string) error {
+	title := o.decorateTitle(fmt.Sprintf(o.messageCatalog().reviewDigestTitle, len(entries)))
+	text := title + "\n" + strings.Join(entries, "\n")
+	if o.DryRun {
+		log.Logger().Infof("Dry run, not posting the review digest to %s:\n%s\n", channel, text)
+		return nil
+	}
+	err := o.RateLimiter.Wait(ctx, channel, false)
+	if err != nil {
+		return errors.Wrapf(err, "waiting to post to %s", channel)
+	}
+	if o.webAPIDisabled() {
+		err = o.postIncomingWebhookText(ctx, channel, text)
+		if err != nil {
+			messagesFailed.WithLabelValues(reviewDigestMessageType).Inc()
+			return err
+		}
+		messagesCreated.WithLabelValues(reviewDigestMessageType).Inc()
+		log.Logger().Infof("Sent the review digest of %d pull requests to %s with the incoming webhook\n",
+			len(entries), channel)
+		return nil
+	}
+	workspace, name := splitWorkspaceChannel(channel)
+	channelID := o.resolveChannelID(ctx, workspace, name)
+	err = o.postWithRetry(ctx, "posting review digest", func(ctx context.Context) error {
+		defer observeSlackAPICall("chat.postMessage", time.Now())
+		client := o.slackClientFor(workspace)
+		_, _, _, err := client.SendMessageContext(ctx, channelID, slack.MsgOptionText(text, false))
+		return err
+	})
+	if err != nil {
+		messagesFailed.WithLabelValues(reviewDigestMessageType).Inc()
+		return err
+	}
+	messagesCreated.WithLabelValues(reviewDigestMessageType).Inc()
+	log.Logger().Infof("Sent the review digest of %d pull requests to %s\n", len(entries), channel)
+	return nil
+}
@@ -0,0 +1,144 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openPullRequestsProvider lists the open pull requests of the repositories keyed by owner/repo, and the repositories
+// of the orgs
+type openPullRequestsProvider struct {
+	gits.GitProvider
+	prs   map[string][]*gits.GitPullRequest
+	repos map[string][]*gits.GitRepository
+}
+
+func (p *openPullRequestsProvider) ListOpenPullRequests(owner string, repo string) ([]*gits.GitPullRequest, error) {
+	return p.prs[owner+"/"+repo], nil
+}
+
+func (p *openPullRequestsProvider) ListRepositories(org string) ([]*gits.GitRepository, error) {
+	return p.repos[org], nil
+}
+
+func Test_nextReviewDigest(t *testing.T) {
+	digest := &slackapp.ReviewDigest{Time: "09:30", TimeZone: "Europe/Paris"}
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	next, err := nextReviewDigest(digest, time.Date(2020, 6, 1, 8, 0, 0, 0, paris))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 6, 1, 9, 30, 0, 0, paris), next)
+
+	// once posted the next digest is the next day
+	next, err = nextReviewDigest(digest, time.Date(2020, 6, 1, 9, 30, 0, 0, paris))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 6, 2, 9, 30, 0, 0, paris), next)
+
+	_, err = nextReviewDigest(&slackapp.ReviewDigest{Time: "9h30"}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestSlackBotOptions_PostReviewDigest(t *testing.T) {
+	pr := func(number int, title string, labels ...string) *gits.GitPullRequest {
+		answer := &gits.GitPullRequest{
+			URL:    "https://github.com/cheese/wine/pull/" + title,
+			Number: &number,
+			Title:  title,
+			Author: &gits.GitUser{Login: "someone"},
+		}
+		for i := range labels {
+			answer.Labels = append(answer.Labels, &gits.Label{Name: &labels[i]})
+		}
+		return answer
+	}
+	provider := &openPullRequestsProvider{prs: map[string][]*gits.GitPullRequest{
+		"cheese/wine": {
+			pr(1, "cheddar"),
+			pr(2, "brie", "approved"),
+			pr(3, "WIP: comte"),
+		},
+	}}
+	clients, _ := newReviewClients(nil, provider)
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		GlobalClients: clients,
+		Namespace:     "jx",
+		SlackClient:   client,
+		ReviewDigest:  &slackapp.ReviewDigest{Time: "09:00"},
+		PullRequests: []slackapp.SlackBotMode{
+			{Channel: "#reviews", Orgs: []slackapp.Org{{Name: "cheese", Repos: []slackapp.Repo{{Name: "wine"}}}}},
+			{Channel: "#quiet", Orgs: []slackapp.Org{{Name: "cheese", Repos: []slackapp.Repo{{Name: "cheddar"}}}}},
+		},
+	}
+
+	require.NoError(t, o.PostReviewDigest(context.Background()))
+	posts := client.callsTo("chat.postMessage")
+	require.Len(t, posts, 1, "the channels without pull requests waiting for a review are skipped")
+	assert.Equal(t, "#reviews", posts[0].Values.Get("channel"))
+	text := posts[0].Values.Get("text")
+	assert.Contains(t, text, "1 pull requests waiting for a review")
+	assert.Contains(t, text, "<https://github.com/cheese/wine/pull/cheddar|cheese/wine#1 cheddar> by someone "+
+		":wave: not approved")
+	assert.NotContains(t, text, "brie", "the approved pull requests are not listed")
+	assert.NotContains(t, text, "comte", "the drafts are not listed")
+}
+
+func TestSlackBotOptions_PostReviewDigest_orgRepositories(t *testing.T) {
+	pr := func(repo string, number int) *gits.GitPullRequest {
+		return &gits.GitPullRequest{
+			URL:    fmt.Sprintf("https://github.com/cheese/%s/pull/%d", repo, number),
+			Number: &number,
+			Title:  "Add " + repo,
+			Author: &gits.GitUser{Login: "someone"},
+		}
+	}
+	provider := &openPullRequestsProvider{
+		prs: map[string][]*gits.GitPullRequest{
+			"cheese/wine":     {pr("wine", 1)},
+			"cheese/cheddar":  {pr("cheddar", 2)},
+			"cheese/comte":    {pr("comte", 3)},
+			"cheese/old-brie": {pr("old-brie", 4)},
+		},
+		repos: map[string][]*gits.GitRepository{
+			"cheese": {{Name: "wine"}, {Name: "cheddar"}, {Name: "comte"}, {Name: "old-brie", Archived: true}},
+		},
+	}
+	clients, _ := newReviewClients(nil, provider)
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		GlobalClients: clients,
+		Namespace:     "jx",
+		SlackClient:   client,
+		ReviewDigest:  &slackapp.ReviewDigest{Time: "09:00"},
+		PullRequests: []slackapp.SlackBotMode{
+			{Channel: "#reviews", Orgs: []slackapp.Org{{Name: "cheese"}}},
+			{Channel: "#cheddar", RepoPattern: "^ched", Orgs: []slackapp.Org{
+				{Name: "cheese", Repos: []slackapp.Repo{{Name: "wine"}}},
+			}},
+		},
+	}
+
+	require.NoError(t, o.PostReviewDigest(context.Background()))
+	posts := client.callsTo("chat.postMessage")
+	require.Len(t, posts, 2)
+	assert.Equal(t, "#cheddar", posts[0].Values.Get("channel"))
+	text := posts[0].Values.Get("text")
+	assert.Contains(t, text, "2 pull requests waiting for a review")
+	assert.Contains(t, text, "cheese/wine#1")
+	assert.Contains(t, text, "cheese/cheddar#2")
+
+	// an org configured without repositories nor pattern includes all its repositories which aren't archived
+	assert.Equal(t, "#reviews", posts[1].Values.Get("channel"))
+	text = posts[1].Values.Get("text")
+	assert.Contains(t, text, "3 pull requests waiting for a review")
+	assert.Contains(t, text, "cheese/comte#3")
+	assert.NotContains(t, text, "old-brie")
+}
@@ -0,0 +1,23 @@
+package slackbot
+
+import (
+	"regexp"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+)
+
+// WorkInProgressLabel is the label lighthouse adds to the draft and work in progress pull requests
+const WorkInProgressLabel = "do-not-merge/work-in-progress"
+
+// draftTitleRegex matches the titles of the pull requests marked as work in progress, such as "WIP: ..." or
+// "[Draft] ..."
+var draftTitleRegex = regexp.MustCompile(`(?i)^\W?(WIP|Draft)\b`)
+
+// isDraft returns true if the pull request is a draft. The Git providers don't expose the draft flag of the pull
+// requests, so it relies on the work in progress label and title instead.
+func isDraft(pr *gits.GitPullRequest) bool {
+	if pr == nil {
+		return false
+	}
+	return containsOneOf(pr.Labels, WorkInProgressLabel) || draftTitleRegex.MatchString(pr.Title)
+}
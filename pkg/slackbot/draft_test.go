@@ -0,0 +1,72 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// draftGitProvider returns the pull request from a GitHub provider, the other methods of the provider aren't implemented
+type draftGitProvider struct {
+	gits.GitProvider
+	pr *gits.GitPullRequest
+}
+
+func (p *draftGitProvider) GetPullRequest(string, *gits.GitRepository, int) (*gits.GitPullRequest, error) {
+	return p.pr, nil
+}
+
+func (p *draftGitProvider) Kind() string {
+	return gits.KindGitHub
+}
+
+func Test_isDraft(t *testing.T) {
+	wip := WorkInProgressLabel
+	other := "area/docs"
+	tests := []struct {
+		name string
+		pr   *gits.GitPullRequest
+		want bool
+	}{
+		{name: "no_pull_request"},
+		{name: "ready", pr: &gits.GitPullRequest{Title: "Add drafts", Labels: []*gits.Label{{Name: &other}}}},
+		{name: "label", pr: &gits.GitPullRequest{Title: "Add drafts", Labels: []*gits.Label{{Name: &wip}}}, want: true},
+		{name: "wip_title", pr: &gits.GitPullRequest{Title: "WIP: add drafts"}, want: true},
+		{name: "draft_title", pr: &gits.GitPullRequest{Title: "[Draft] add drafts"}, want: true},
+		{name: "wip_word", pr: &gits.GitPullRequest{Title: "Wipe the cache"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isDraft(tt.pr))
+		})
+	}
+}
+
+func TestSlackBotOptions_ReviewRequestMessage_ignoreDrafts(t *testing.T) {
+	wip := WorkInProgressLabel
+	provider := &draftGitProvider{pr: &gits.GitPullRequest{
+		Title:  "Add drafts",
+		Labels: []*gits.Label{{Name: &wip}},
+	}}
+	clients, _ := newReviewClients(nil, provider)
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		GlobalClients: clients,
+		SlackClient:   client,
+		Timestamps:    make(map[string]map[string]*MessageReference),
+		PullRequests:  []slackapp.SlackBotMode{{Channel: "#cheese", IgnoreDrafts: true}},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	require.NoError(t, o.ReviewRequestMessage(act))
+	assert.Empty(t, client.callsTo("chat.postMessage"))
+
+	// once the pull request is ready for review the next event posts the message
+	provider.pr = &gits.GitPullRequest{Title: "Add drafts"}
+	require.NoError(t, o.ReviewRequestMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+}
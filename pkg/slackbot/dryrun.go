@@ -0,0 +1,25 @@
+package slackbot
+
+import (
+	"encoding/json"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+// logDryRun logs the message which would have been posted to the channel for the activity
+func logDryRun(channel string, activity *record.ActivityRecord, attachments []slack.Attachment,
+	blocks []slack.Block) error {
+	var message interface{} = attachments
+	if len(blocks) > 0 {
+		message = blocks
+	}
+	data, err := json.MarshalIndent(message, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "marshaling dry run message for %s", activity.Name)
+	}
+	log.Logger().Infof("Dry run, not posting message for %s to %s:\n%s\n", activity.Name, channel, string(data))
+	return nil
+}
@@ -0,0 +1,33 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_postMessageDryRun(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		DryRun:      true,
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	attachments, createIfMissing, err := o.createPipelineMessage(act, nil, nil, o.Statuses, false)
+	require.NoError(t, err)
+
+	err = o.postMessage("#test", false, pipelineMessageType, act, nil, attachments, nil, createIfMissing)
+	require.NoError(t, err)
+	err = o.postMessage("U0001", true, pipelineMessageType, act, nil, attachments, nil, createIfMissing)
+	require.NoError(t, err)
+
+	recorder.Lock()
+	defer recorder.Unlock()
+	assert.Empty(t, recorder.calls)
+	assert.Empty(t, o.Timestamps)
+}
@@ -0,0 +1,48 @@
+package slackbot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/slack-go/slack"
+)
+
+// buildDuration returns how long the build of the activity took, or has been running for so far, and false if it is
+// not known because the activity is missing the timestamps
+func buildDuration(activity *record.ActivityRecord, now time.Time) (time.Duration, bool) {
+	if activity.StartTime == nil {
+		return 0, false
+	}
+	end := now
+	if isTerminalState(pipelineStatus(activity)) {
+		if activity.CompletionTime == nil {
+			return 0, false
+		}
+		end = activity.CompletionTime.Time
+	}
+	duration := end.Sub(activity.StartTime.Time)
+	if duration < 0 {
+		return 0, false
+	}
+	return duration.Round(time.Second), true
+}
+
+// buildDurationField returns the field showing how long the build of the activity took, such as 4m32s, or how long it
+// has been running for, nil if it is not known
+func (o *SlackBotOptions) buildDurationField(activity *record.ActivityRecord, now time.Time) []slack.AttachmentField {
+	duration, ok := buildDuration(activity, now)
+	if !ok {
+		return nil
+	}
+	value := duration.String()
+	if pipelineStatus(activity) == v1alpha1.RunningState {
+		value = fmt.Sprintf(o.messageCatalog().runningDuration, value)
+	}
+	return []slack.AttachmentField{{
+		Title: o.messageCatalog().durationFieldTitle,
+		Value: ":stopwatch: " + value,
+		Short: true,
+	}}
+}
@@ -0,0 +1,71 @@
+package slackbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSlackBotOptions_buildDurationField(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	now := time.Now()
+	start := now.Add(-10 * time.Minute)
+	o := &SlackBotOptions{}
+
+	act.Status = v1alpha1.SuccessState
+	act.StartTime = &metav1.Time{Time: start}
+	act.CompletionTime = &metav1.Time{Time: start.Add(4*time.Minute + 32*time.Second + 400*time.Millisecond)}
+	fields := o.buildDurationField(act, now)
+	require.Len(t, fields, 1)
+	assert.Equal(t, "Duration", fields[0].Title)
+	assert.Equal(t, ":stopwatch: 4m32s", fields[0].Value)
+
+	// the running builds show the time elapsed so far
+	act.Status = v1alpha1.RunningState
+	act.CompletionTime = nil
+	fields = o.buildDurationField(act, now)
+	require.Len(t, fields, 1)
+	assert.Equal(t, ":stopwatch: 10m0s so far", fields[0].Value)
+
+	// the duration is omitted when the timestamps are missing
+	act.Status = v1alpha1.FailureState
+	assert.Empty(t, o.buildDurationField(act, now))
+	act.StartTime = nil
+	act.Status = v1alpha1.RunningState
+	assert.Empty(t, o.buildDurationField(act, now))
+
+	// the field is translated in the locale of the bot
+	o.Locale = "fr"
+	act.StartTime = &metav1.Time{Time: start}
+	fields = o.buildDurationField(act, now)
+	require.Len(t, fields, 1)
+	assert.Equal(t, "Durée", fields[0].Title)
+	assert.Equal(t, ":stopwatch: 10m0s pour l'instant", fields[0].Value)
+}
+
+func TestSlackBotOptions_createPipelineMessage_buildDuration(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	act.Status = v1alpha1.SuccessState
+	start := time.Now().Add(-time.Hour)
+	act.StartTime = &metav1.Time{Time: start}
+	act.CompletionTime = &metav1.Time{Time: start.Add(90 * time.Second)}
+
+	o := &SlackBotOptions{}
+	attachments, _, err := o.createPipelineMessage(act, nil, nil, o.Statuses, false)
+	require.NoError(t, err)
+	assert.Empty(t, attachments[0].Fields)
+
+	o.ShowBuildDuration = true
+	attachments, _, err = o.createPipelineMessage(act, nil, nil, o.Statuses, false)
+	require.NoError(t, err)
+	require.Len(t, attachments[0].Fields, 1)
+	assert.Equal(t, ":stopwatch: 1m30s", attachments[0].Fields[0].Value)
+}
@@ -0,0 +1,88 @@
+package slackbot
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// EscalationMentionHere notifies the active members of the channel
+	EscalationMentionHere = "here"
+	// EscalationMentionChannel notifies all the members of the channel
+	EscalationMentionChannel = "channel"
+)
+
+// escalationMentions are the supported values of the EscalationMention of the modes
+var escalationMentions = []string{EscalationMentionHere, EscalationMentionChannel}
+
+// escalationMessageKey is the key of the reply escalating the failure of the activity, so that the channel is only
+// notified once per build
+func escalationMessageKey(activityName string) string {
+	return fmt.Sprintf("%s/escalation", activityName)
+}
+
+// escalate notifies the channel with a reply to the message of the activity, broadcast to the channel, once the
+// pipeline failed for EscalateAfterFailures consecutive builds. Editing the message wouldn't notify anyone, so the
+// escalation is a new message. The channel is only notified once per build.
+func (o *SlackBotOptions) escalate(channel string, cfg slackapp.SlackBotMode, activity *record.ActivityRecord) error {
+	mention := o.escalationMention(channel, cfg, activity)
+	if mention == "" {
+		return nil
+	}
+	parent := o.messageReference(channel, activity.Name)
+	key := escalationMessageKey(activity.Name)
+	if parent == nil || o.messageReference(channel, key) != nil {
+		return nil
+	}
+	text := fmt.Sprintf("%s :rotating_light: this pipeline keeps failing", mention)
+	if o.DryRun {
+		return logDryRun(channel, activity, []slack.Attachment{{Text: text}}, nil)
+	}
+	return o.postThreadReply(channel, false, pipelineMessageType, parent, key, text,
+		[]slack.MsgOption{slack.MsgOptionText(text, false), slack.MsgOptionBroadcast()})
+}
+
+// escalationMention returns the broadcast mention, such as <!here>, notifying the channel once the pipeline failed for
+// EscalateAfterFailures consecutive builds, or an empty string. A success resets the count. The failures are only
+// tracked in memory, so a restart starts counting again. The modes which never mention anyone aren't escalated.
+func (o *SlackBotOptions) escalationMention(channel string, cfg slackapp.SlackBotMode,
+	activity *record.ActivityRecord) string {
+	if cfg.EscalateAfterFailures <= 0 || cfg.MentionPolicy == MentionPolicyNever {
+		return ""
+	}
+	status := pipelineStatus(activity)
+	if status != v1alpha1.FailureState && status != v1alpha1.SuccessState {
+		return ""
+	}
+	key := failureStreakKey(channel, activity)
+
+	o.failureStreaksLock.Lock()
+	defer o.failureStreaksLock.Unlock()
+	if status == v1alpha1.SuccessState {
+		delete(o.escalationStreaks, key)
+		return ""
+	}
+	if o.escalationStreaks == nil {
+		o.escalationStreaks = make(map[string]*failureStreak)
+	}
+	streak := o.escalationStreaks[key]
+	if streak == nil {
+		streak = &failureStreak{}
+		o.escalationStreaks[key] = streak
+	}
+	if streak.lastBuild != activity.BuildIdentifier {
+		streak.failures++
+		streak.lastBuild = activity.BuildIdentifier
+	}
+	if streak.failures < cfg.EscalateAfterFailures {
+		return ""
+	}
+	if cfg.EscalationMention == EscalationMentionChannel {
+		return "<!channel>"
+	}
+	return "<!here>"
+}
@@ -0,0 +1,85 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_escalationMention(t *testing.T) {
+	o := &SlackBotOptions{}
+	cfg := slackapp.SlackBotMode{Channel: "#cheese", EscalateAfterFailures: 2}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+	build := func(number string, status v1alpha1.PipelineState) string {
+		act.BuildIdentifier = number
+		act.Status = status
+		return o.escalationMention("#cheese", cfg, act)
+	}
+
+	assert.Empty(t, build("1", v1alpha1.FailureState))
+	// the updates of the same build are only counted once
+	assert.Empty(t, build("1", v1alpha1.FailureState))
+	assert.Empty(t, build("2", v1alpha1.RunningState))
+	assert.Equal(t, "<!here>", build("2", v1alpha1.FailureState))
+	assert.Equal(t, "<!here>", build("3", v1alpha1.FailureState))
+
+	// a success resets the count
+	assert.Empty(t, build("4", v1alpha1.SuccessState))
+	assert.Empty(t, build("5", v1alpha1.FailureState))
+
+	cfg.EscalationMention = EscalationMentionChannel
+	assert.Equal(t, "<!channel>", build("6", v1alpha1.FailureState))
+
+	// the failures are counted for each channel
+	assert.Empty(t, o.escalationMention("#wine", cfg, act))
+}
+
+func TestSlackBotOptions_escalationMention_disabled(t *testing.T) {
+	o := &SlackBotOptions{}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	act.Status = v1alpha1.FailureState
+	for _, build := range []string{"1", "2", "3"} {
+		act.BuildIdentifier = build
+		assert.Empty(t, o.escalationMention("#cheese", slackapp.SlackBotMode{Channel: "#cheese"}, act))
+	}
+}
+
+func TestSlackBotOptions_escalate(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	cfg := slackapp.SlackBotMode{Channel: "#cheese", EscalateAfterFailures: 2}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+	act.Status = v1alpha1.FailureState
+	for _, build := range []string{"1", "2"} {
+		act.BuildIdentifier = build
+		o.storeMessageReference("#cheese", act.Name, &MessageReference{ChannelID: "C0001", Timestamp: "1.000100"})
+		require.NoError(t, o.escalate("#cheese", cfg, act))
+	}
+	// the reply is only posted once for the build
+	require.NoError(t, o.escalate("#cheese", cfg, act))
+
+	replies := client.callsTo("chat.postMessage")
+	require.Len(t, replies, 1)
+	assert.Equal(t, "1.000100", replies[0].Values.Get("thread_ts"))
+	assert.Equal(t, "true", replies[0].Values.Get("reply_broadcast"))
+	assert.Contains(t, replies[0].Values.Get("text"), "<!here>")
+
+	// the channels which never mention anyone aren't escalated
+	cfg.MentionPolicy = MentionPolicyNever
+	act.BuildIdentifier = "3"
+	assert.Empty(t, o.escalationMention("#cheese", cfg, act))
+}
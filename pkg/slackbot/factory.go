@@ -1,24 +1,39 @@
 package slackbot
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/pkg/errors"
 	"github.com/slack-go/slack"
 
 	"k8s.io/client-go/kubernetes"
 
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	jenkinsv1client "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
 	cmd "github.com/jenkins-x/jx/v2/pkg/cmd/clients"
 	"github.com/jenkins-x/jx/v2/pkg/cmd/opts"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
 	"github.com/jenkins-x/jx/v2/pkg/kube"
 	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
 	v1client "github.com/jenkins-x/slack/pkg/client/clientset/versioned"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
 const (
 	DefaultHmacSecretName = "hmac-token"
 	DefaultPort           = 8080
+	// DefaultTokenSecretKey is the key of the Secret data holding the Slack token
+	DefaultTokenSecretKey = "token"
+	// DefaultCreateIfMissingWindow is how recently an activity must have been updated for a new message to be posted
+	DefaultCreateIfMissingWindow = 24 * time.Hour
 )
 
 // GlobalClients are a set of clients shared for each SlackBot
@@ -31,6 +46,16 @@ type GlobalClients struct {
 	slackClientHelper
 	// TODO not great but needed until Git Provider stuff is better unwound...
 	CommonOptions *opts.CommonOptions
+	// HTTPClient reaches the Slack API and sends the messages to the webhooks, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// SlackAPIURL is the base URL of the Slack API, such as the URL of a Slack-compatible gateway, slack.APIURL if
+	// empty
+	SlackAPIURL string
+	// gitProviderForURL creates the Git provider for a repository, CommonOptions is used if nil
+	gitProviderForURL func(gitURL string) (gits.GitProvider, *gits.GitRepository, error)
+	// gitKinds caches the kinds of the Git providers keyed by host
+	gitKinds     map[string]string
+	gitKindsLock sync.Mutex
 }
 
 type slackWrapper struct{}
@@ -39,15 +64,165 @@ type slackWrapper struct{}
 type SlackBotOptions struct {
 	*GlobalClients
 
-	SlackClient       *slack.Client
-	Name              string
-	Pipelines         []slackapp.SlackBotMode
-	PullRequests      []slackapp.SlackBotMode
-	Namespace         string
-	Statuses          slackapp.Statuses
-	Orgs              []slackapp.Org
-	Timestamps        map[string]map[string]*MessageReference
-	SlackUserResolver *SlackUserResolver
+	SlackClient           SlackClienter
+	Name                  string
+	Pipelines             []slackapp.SlackBotMode
+	PullRequests          []slackapp.SlackBotMode
+	Promotions            []slackapp.PromotionMode
+	Namespace             string
+	Statuses              slackapp.Statuses
+	Orgs                  []slackapp.Org
+	Timestamps            map[string]map[string]*MessageReference
+	TimestampStore        TimestampStore
+	SlackUserResolver     *SlackUserResolver
+	UseBlockKit           bool
+	ThreadStages          bool
+	MaxRetries            int
+	RetryBackoff          time.Duration
+	DryRun                bool
+	LookupUsersByEmail    bool
+	MessagesPerMinute     int
+	RateLimiter           *RateLimiter
+	ReviewMessageTemplate string
+	// MentionAuthorOnRebase mentions the author in the review message when the pull request needs a rebase
+	MentionAuthorOnRebase bool
+	// ClosedReviewMessageTemplate renders the review message once the pull request is merged or closed
+	ClosedReviewMessageTemplate string
+	ShowCommitInfo              bool
+	QuietHours                  *slackapp.QuietHours
+	ReactOnComplete             bool
+	// ShowTestResults adds the test results and coverage recorded on the PipelineActivity to the pipeline message
+	ShowTestResults bool
+	// ShowBuildDuration adds how long the build took, or has been running for, to the pipeline message
+	ShowBuildDuration bool
+	// PipelineButtons are the buttons of the pipeline messages, in order, DefaultPipelineButtons if empty
+	PipelineButtons []slackapp.PipelineButton
+	// ShowCommitMessage adds the first line of the message of the commit the pipeline built to the pipeline message
+	ShowCommitMessage bool
+	// ShowReviewerAvatars shows the profile images of the mentioned reviewers in the review messages, which needs
+	// UseBlockKit
+	ShowReviewerAvatars bool
+	// ApprovalsLabel prefixes the label holding the number of approvals of a pull request, ApprovalsLabelPrefix if
+	// empty
+	ApprovalsLabel string
+	// RequiredApprovalsLabel prefixes the label holding the number of approvals a pull request needs to be merged,
+	// RequiredApprovalsLabelPrefix if empty
+	RequiredApprovalsLabel string
+	// avatars caches the profile images of the Slack users keyed by workspace and ID
+	avatars     map[string]map[string]string
+	avatarsLock sync.Mutex
+	// topics are the topics last set by the bot for the promotions, keyed by channel ID
+	topics     map[string]*channelTopic
+	topicsLock sync.Mutex
+	// MessagePrefix and MessageSuffix are added around the title of the pipeline and review messages
+	MessagePrefix string
+	MessageSuffix string
+	// CreateIfMissingWindow is how recently an activity must have been updated for a new message to be posted,
+	// messages are always posted if it is zero or negative
+	CreateIfMissingWindow time.Duration
+	// Debouncer holds back the updates of a running pipeline for its Window, waiting for further updates before
+	// updating its message
+	Debouncer *Debouncer
+	// ExternalCallTimeout is how long each call to Kubernetes, the Git provider or Slack may take before it is
+	// abandoned, there is no timeout if it is zero or negative
+	ExternalCallTimeout time.Duration
+	// TimestampTTL is how long the references to the messages are kept after they were last updated, they are kept
+	// forever if it is zero or negative
+	TimestampTTL time.Duration
+	// timestampsLock guards Timestamps, which is accessed concurrently when handling several events at once
+	timestampsLock sync.RWMutex
+	// messageLocks serializes the posts of the same message, keyed by channel and activity
+	messageLocks     map[string]*messageLock
+	messageLocksLock sync.Mutex
+	// channelIDs caches the IDs of the channels keyed by workspace and name, channelMisses when the channels which
+	// couldn't be resolved were last looked up
+	channelIDs     map[string]map[string]string
+	channelMisses  map[string]map[string]time.Time
+	channelIDsLock sync.Mutex
+	// CollapseSucceededStages renders the stages which succeeded as a single summary line
+	CollapseSucceededStages bool
+	// GroupSucceededSteps renders the consecutive steps of a stage which succeeded as a single summary line
+	GroupSucceededSteps bool
+	// ReleaseBranches are the branches whose pipelines are named "Release Pipeline", DefaultReleaseBranches if empty
+	ReleaseBranches []string
+	// RawPipelineNames names the pipelines by their owner/repo/branch instead of "Release Pipeline" and the like
+	RawPipelineNames bool
+	// DeliveryMode is how the messages are posted to Slack, DeliveryModeToken if empty
+	DeliveryMode string
+	// IncomingWebhooks are the URLs of the incoming webhooks keyed by channel name, used by DeliveryModeWebhook
+	IncomingWebhooks map[string]string
+	// MaxTitleLength is the number of characters the titles of the pull requests are truncated to in the messages,
+	// DefaultMaxTitleLength if zero and not truncated if negative
+	MaxTitleLength int
+	// StageEmojis maps the known pipeline stage types, such as build or promote, to the emoji prefixing their steps
+	StageEmojis map[string]string
+	// UserGroups maps git team slugs to Slack user group IDs
+	UserGroups map[string]string
+	// UserMappings maps Git logins to Slack user IDs, they are used before resolving the users
+	UserMappings map[string]string
+	// Webhooks receive the messages sent to Slack as JSON, in addition to Slack
+	Webhooks []slackapp.WebhookConfig
+	// WebhookClient sends the messages to the Webhooks, the HTTPClient of the GlobalClients if nil
+	WebhookClient *http.Client
+	// webhookQueue holds the messages waiting to be sent to the Webhooks, pendingWebhooks counts those not sent yet
+	webhookQueue     chan webhookDelivery
+	webhookQueueOnce sync.Once
+	pendingWebhooks  int32
+	// LogURLRewrites rewrites the build logs URLs by scheme, DefaultLogURLRewrites are used if nil
+	LogURLRewrites map[string]LogURLRewrite
+	// Alerter opens incidents when promotions to the AlertEnvironments fail, no incident is opened if nil
+	Alerter           Alerter
+	AlertEnvironments []string
+	// TokenSecretName and TokenSecretKey locate the Slack token, the Slack client is reloaded when it changes
+	TokenSecretName string
+	TokenSecretKey  string
+	token           string
+	// slackClientLock guards SlackClient, which is replaced when the token is rotated
+	slackClientLock sync.RWMutex
+	// inFlight is the number of Slack API calls being made, which Shutdown waits for
+	inFlight int32
+	// PinAfterFailures pins the message of a pipeline which failed for this many consecutive builds, until it
+	// succeeds again. Messages are never pinned if it is zero or negative
+	PinAfterFailures   int
+	failureStreaks     map[string]*failureStreak
+	escalationStreaks  map[string]*failureStreak
+	failureStreaksLock sync.Mutex
+	// PRCacheTTL is how long the pull requests fetched from the Git provider are reused for the events of the same
+	// pull request, they are fetched for every event if it is zero or negative
+	PRCacheTTL  time.Duration
+	prCache     map[string]*pullRequestCacheEntry
+	prCacheLock sync.Mutex
+	// Locale is the locale of the texts of the messages, DefaultLocale if it isn't supported
+	Locale string
+	// UpdateJitter is the maximum random delay waited before updating a message, the messages are updated straight
+	// away if it is zero or negative
+	UpdateJitter time.Duration
+	// jitterSource returns a random number in [0, n), rand.Int63n if nil
+	jitterSource func(n int64) int64
+	// RerunButton adds a Rerun button to the messages of the failed pipelines, which needs the interactivity
+	// endpoint of the serve command
+	RerunButton bool
+	// SlowBuildThreshold is how long a build can run before its message warns that it is slow, builds are never
+	// considered slow if it is zero or negative
+	SlowBuildThreshold time.Duration
+	// PingSlowBuilds also warns the channel once, in a reply to the message, when a build is slow
+	PingSlowBuilds bool
+	// Workspaces are the Slack clients of the other workspaces the modes can post to, keyed by workspace name
+	Workspaces map[string]SlackClienter
+	// BroadcastTerminalToChannel also posts the final status of the pipeline in a reply broadcast to the channel when
+	// the stages are threaded, so that it isn't missed in the thread
+	BroadcastTerminalToChannel bool
+	// ReviewDigest posts a daily digest of the open pull requests waiting for a review, none is posted if nil
+	ReviewDigest *slackapp.ReviewDigest
+	// IssueTracker links the issue keys of the titles of the pull requests in the review messages, none are linked if
+	// nil
+	IssueTracker *slackapp.IssueTracker
+	// MaintenanceWindows are the periods during which the new messages are scheduled to be posted once they end
+	MaintenanceWindows []slackapp.MaintenanceWindow
+	// issueKeys matches the issue keys of the IssueTracker
+	issueKeys *regexp.Regexp
+	// repoPatterns are the compiled repository patterns of the modes, keyed by pattern
+	repoPatterns map[string]*regexp.Regexp
 
 	HmacSecretName string
 	Port           int
@@ -108,9 +283,28 @@ func CreateClients() (*GlobalClients, error) {
 		Factory:           factory,
 		CommonOptions:     &commonOptions,
 		slackClientHelper: &slackWrapper{},
+		HTTPClient:        NewHTTPClient(),
+		SlackAPIURL:       slackAPIURL,
 	}, nil
 }
 
+// SlackClienter is the subset of the Slack API used by the SlackBot, implemented by *slack.Client
+type SlackClienter interface {
+	SendMessageContext(ctx context.Context, channelID string, options ...slack.MsgOption) (string, string, string, error)
+	OpenConversationContext(ctx context.Context, params *slack.OpenConversationParameters) (*slack.Channel, bool, bool,
+		error)
+	AddReactionContext(ctx context.Context, name string, item slack.ItemRef) error
+	RemoveReactionContext(ctx context.Context, name string, item slack.ItemRef) error
+	DeleteMessageContext(ctx context.Context, channel string, messageTimestamp string) (string, string, error)
+	AddPinContext(ctx context.Context, channel string, item slack.ItemRef) error
+	PostEphemeralContext(ctx context.Context, channelID string, userID string, options ...slack.MsgOption) (string,
+		error)
+	RemovePinContext(ctx context.Context, channel string, item slack.ItemRef) error
+	GetConversationsContext(ctx context.Context, params *slack.GetConversationsParameters) ([]slack.Channel, string,
+		error)
+	AuthTestContext(ctx context.Context) (*slack.AuthTestResponse, error)
+}
+
 type slackClientHelper interface {
 	getSlackClient(token string, options ...slack.Option) *slack.Client
 }
@@ -122,38 +316,295 @@ func (s slackWrapper) getSlackClient(token string, options ...slack.Option) *sla
 // CreateSlackBot configures a SlackBot
 func CreateSlackBot(c *GlobalClients, slackBot *slackapp.SlackBot) (*SlackBotOptions, error) {
 
-	// Fetch the resource reference for the token
-	if slackBot.Spec.TokenReference.Kind != "Secret" {
-		return nil, fmt.Errorf("expected token of kind Secret but got %s for %s", slackBot.Spec.TokenReference.Kind,
-			slackBot.Name)
+	var token []byte
+	var tokenSecretName, tokenSecretKey string
+	var incomingWebhooks map[string]string
+	var err error
+	switch slackBot.Spec.DeliveryMode {
+	case "", DeliveryModeToken:
+		// Fetch the resource reference for the token
+		tokenSecretName, tokenSecretKey, err = tokenSecret(slackBot)
+		if err == nil {
+			token, err = readToken(c, tokenSecretName, tokenSecretKey)
+		}
+	case DeliveryModeWebhook:
+		// the messages are posted with the incoming webhooks of the channels, without a token
+		incomingWebhooks, err = readIncomingWebhooks(c, slackBot)
+	default:
+		err = fmt.Errorf("unknown delivery mode %s for %s, must be one of %s", slackBot.Spec.DeliveryMode,
+			slackBot.Name, strings.Join(deliveryModes, ", "))
 	}
-	secret, err := c.KubeClient.CoreV1().Secrets(c.Namespace).Get(slackBot.Spec.TokenReference.Name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
-
-	token, ok := secret.Data["token"]
-	if !ok {
-		return nil, fmt.Errorf("expected key token in field data")
-	}
 	watchNs := c.Namespace
 	if slackBot.Spec.Namespace != "" {
 		watchNs = slackBot.Spec.Namespace
 	}
 
-	slackClient := c.getSlackClient(string(token))
+	maxRetries := DefaultMaxRetries
+	if slackBot.Spec.MaxRetries != nil {
+		maxRetries = *slackBot.Spec.MaxRetries
+	}
+
+	if slackBot.Spec.ReviewMessageTemplate != "" {
+		_, err = parseReviewMessageTemplate(slackBot.Spec.ReviewMessageTemplate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid review message template for %s", slackBot.Name)
+		}
+	}
+	if slackBot.Spec.ClosedReviewMessageTemplate != "" {
+		_, err = parseReviewMessageTemplate(slackBot.Spec.ClosedReviewMessageTemplate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid closed review message template for %s", slackBot.Name)
+		}
+	}
+
+	if errs := validateStatuses(slackBot.Spec.Statuses); len(errs) > 0 {
+		return nil, errors.Wrapf(utilerrors.NewAggregate(errs), "invalid statuses for %s", slackBot.Name)
+	}
+	for _, cfg := range slackBot.Spec.Promotions {
+		if errs := validateStatuses(cfg.Statuses); len(errs) > 0 {
+			return nil, errors.Wrapf(utilerrors.NewAggregate(errs), "invalid promotion statuses for %s", slackBot.Name)
+		}
+	}
+	for _, cfg := range slackBotModes(slackBot) {
+		for _, org := range cfg.Orgs {
+			if errs := validateStatuses(org.Statuses); len(errs) > 0 {
+				return nil, errors.Wrapf(utilerrors.NewAggregate(errs), "invalid statuses of org %s for %s", org.Name,
+					slackBot.Name)
+			}
+		}
+	}
+
+	if slackBot.Spec.ReviewDigest != nil {
+		_, _, err = parseReviewDigestTime(slackBot.Spec.ReviewDigest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid review digest for %s", slackBot.Name)
+		}
+	}
+
+	repoPatterns, err := compileRepoPatterns(slackBotModes(slackBot))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration for %s", slackBot.Name)
+	}
+
+	var issueKeys *regexp.Regexp
+	if slackBot.Spec.IssueTracker != nil {
+		issueKeys, err = compileIssueKeyPattern(slackBot.Spec.IssueTracker)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid issue tracker for %s", slackBot.Name)
+		}
+	}
+
+	createIfMissingWindow := DefaultCreateIfMissingWindow
+	if slackBot.Spec.CreateIfMissingWindow != nil {
+		createIfMissingWindow = slackBot.Spec.CreateIfMissingWindow.Duration
+	}
+
+	updateDebounce := time.Duration(0)
+	if slackBot.Spec.UpdateDebounce != nil {
+		updateDebounce = slackBot.Spec.UpdateDebounce.Duration
+	}
+
+	externalCallTimeout := DefaultExternalCallTimeout
+	if slackBot.Spec.ExternalCallTimeout != nil {
+		externalCallTimeout = slackBot.Spec.ExternalCallTimeout.Duration
+	}
+
+	updateJitter := time.Duration(0)
+	if slackBot.Spec.UpdateJitter != nil {
+		updateJitter = slackBot.Spec.UpdateJitter.Duration
+	}
+
+	slowBuildThreshold := time.Duration(0)
+	if slackBot.Spec.SlowBuildThreshold != nil {
+		slowBuildThreshold = slackBot.Spec.SlowBuildThreshold.Duration
+	}
+
+	prCacheTTL := time.Duration(0)
+	if slackBot.Spec.PRCacheTTL != nil {
+		prCacheTTL = slackBot.Spec.PRCacheTTL.Duration
+	}
+
+	if slackBot.Spec.QuietHours != nil {
+		_, _, _, err = parseQuietHours(slackBot.Spec.QuietHours)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid quiet hours for %s", slackBot.Name)
+		}
+	}
+
+	slackClient := c.newSlackClient(string(token))
+	workspaces, err := createWorkspaceClients(c, slackBot)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the Slack workspaces of %s", slackBot.Name)
+	}
 
 	userResolver := NewSlackUserResolver(slackClient, c.JXClient, watchNs)
+	if slackBot.Spec.LookupUsersByEmail != nil {
+		userResolver.LookupByEmail = *slackBot.Spec.LookupUsersByEmail
+	}
+	if slackBot.Spec.DeliveryMode == DeliveryModeWebhook {
+		// there is no token to look up the users with, only their mappings and linked accounts are used
+		userResolver.LookupByEmail = false
+	}
+
+	// hydrate the timestamps so messages posted before a restart are updated rather than re-created
+	timestampStore := NewConfigMapTimestampStore(c.KubeClient, c.Namespace, timestampsConfigMapName(slackBot.Name))
+	timestamps, err := timestampStore.List()
+	if err != nil {
+		log.Logger().Warnf("failed to load message timestamps for %s: %v", slackBot.Name, err)
+		timestamps = make(map[string]map[string]*MessageReference, 0)
+	}
+	// references persisted before their update time was recorded expire one TTL after the restart
+	now := time.Now()
+	for _, refs := range timestamps {
+		for _, ref := range refs {
+			if ref != nil && ref.LastUpdated.IsZero() {
+				ref.LastUpdated = now
+			}
+		}
+	}
+
+	var alerter Alerter
+	var alertEnvironments []string
+	if alerting := slackBot.Spec.Alerting; alerting != nil {
+		alertEnvironments = alerting.Environments
+		if alerting.PagerDuty != nil {
+			routingKey, err := readSecretKey(c, alerting.PagerDuty.RoutingKeyReference, "routingKey")
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading the PagerDuty routing key for %s", slackBot.Name)
+			}
+			alerter = &PagerDutyAlerter{RoutingKey: routingKey}
+		}
+	}
+
+	pipelines := slackBot.Spec.Pipelines
+	if slackBot.Spec.SkipPendingState {
+		// a shorthand for the createOnStatuses of the pipeline modes which don't configure them
+		pipelines = skipPendingState(pipelines)
+	}
+
+	timestampTTL := DefaultTimestampTTL
+	if slackBot.Spec.TimestampTTL != nil {
+		timestampTTL = slackBot.Spec.TimestampTTL.Duration
+	}
 
 	return &SlackBotOptions{
-		GlobalClients:     c,
-		Name:              slackBot.Name,
-		SlackClient:       slackClient,
-		Pipelines:         slackBot.Spec.Pipelines,
-		PullRequests:      slackBot.Spec.PullRequests,
-		Namespace:         watchNs,
-		Statuses:          slackBot.Spec.Statuses,
-		Timestamps:        make(map[string]map[string]*MessageReference, 0),
-		SlackUserResolver: &userResolver,
+		GlobalClients:               c,
+		Name:                        slackBot.Name,
+		SlackClient:                 slackClient,
+		TokenSecretName:             tokenSecretName,
+		TokenSecretKey:              tokenSecretKey,
+		token:                       string(token),
+		Pipelines:                   pipelines,
+		Promotions:                  slackBot.Spec.Promotions,
+		PullRequests:                slackBot.Spec.PullRequests,
+		Namespace:                   watchNs,
+		Statuses:                    slackBot.Spec.Statuses,
+		Timestamps:                  timestamps,
+		TimestampTTL:                timestampTTL,
+		TimestampStore:              timestampStore,
+		SlackUserResolver:           &userResolver,
+		UseBlockKit:                 slackBot.Spec.UseBlockKit,
+		ThreadStages:                slackBot.Spec.ThreadStages,
+		MaxRetries:                  maxRetries,
+		RetryBackoff:                DefaultRetryBackoff,
+		DryRun:                      slackBot.Spec.DryRun,
+		LookupUsersByEmail:          userResolver.LookupByEmail,
+		MessagesPerMinute:           slackBot.Spec.MessagesPerMinute,
+		RateLimiter:                 NewRateLimiter(slackBot.Spec.MessagesPerMinute),
+		ReviewMessageTemplate:       slackBot.Spec.ReviewMessageTemplate,
+		ClosedReviewMessageTemplate: slackBot.Spec.ClosedReviewMessageTemplate,
+		MentionAuthorOnRebase:       slackBot.Spec.MentionAuthorOnRebase,
+		ShowCommitInfo:              slackBot.Spec.ShowCommitInfo,
+		ShowTestResults:             slackBot.Spec.ShowTestResults,
+		ShowBuildDuration:           slackBot.Spec.ShowBuildDuration,
+		PipelineButtons:             slackBot.Spec.PipelineButtons,
+		ShowCommitMessage:           slackBot.Spec.ShowCommitMessage,
+		ShowReviewerAvatars:         slackBot.Spec.ShowReviewerAvatars,
+		ApprovalsLabel:              slackBot.Spec.ApprovalsLabel,
+		RequiredApprovalsLabel:      slackBot.Spec.RequiredApprovalsLabel,
+		MessagePrefix:               slackBot.Spec.MessagePrefix,
+		MessageSuffix:               slackBot.Spec.MessageSuffix,
+		QuietHours:                  slackBot.Spec.QuietHours,
+		ReactOnComplete:             slackBot.Spec.ReactOnComplete,
+		CreateIfMissingWindow:       createIfMissingWindow,
+		ExternalCallTimeout:         externalCallTimeout,
+		Debouncer:                   NewDebouncer(updateDebounce),
+		UserGroups:                  slackBot.Spec.UserGroups,
+		UserMappings:                slackBot.Spec.UserMappings,
+		Webhooks:                    slackBot.Spec.Webhooks,
+		PinAfterFailures:            slackBot.Spec.PinAfterFailures,
+		PRCacheTTL:                  prCacheTTL,
+		Locale:                      slackBot.Spec.Locale,
+		UpdateJitter:                updateJitter,
+		RerunButton:                 slackBot.Spec.RerunButton,
+		SlowBuildThreshold:          slowBuildThreshold,
+		PingSlowBuilds:              slackBot.Spec.PingSlowBuilds,
+		BroadcastTerminalToChannel:  slackBot.Spec.BroadcastTerminalToChannel,
+		IssueTracker:                slackBot.Spec.IssueTracker,
+		MaintenanceWindows:          slackBot.Spec.MaintenanceWindows,
+		issueKeys:                   issueKeys,
+		Workspaces:                  workspaces,
+		repoPatterns:                repoPatterns,
+		ReviewDigest:                slackBot.Spec.ReviewDigest,
+		StageEmojis:                 slackBot.Spec.StageEmojis,
+		CollapseSucceededStages:     slackBot.Spec.CollapseSucceededStages,
+		GroupSucceededSteps:         slackBot.Spec.GroupSucceededSteps,
+		ReleaseBranches:             slackBot.Spec.ReleaseBranches,
+		RawPipelineNames:            slackBot.Spec.RawPipelineNames,
+		DeliveryMode:                slackBot.Spec.DeliveryMode,
+		IncomingWebhooks:            incomingWebhooks,
+		MaxTitleLength:              slackBot.Spec.MaxTitleLength,
+		Alerter:                     alerter,
+		AlertEnvironments:           alertEnvironments,
 	}, nil
 }
+
+// tokenSecret returns the name of the Secret and the key of its data holding the Slack token of the SlackBot, the
+// TokenSecretRef takes precedence over the TokenReference
+func tokenSecret(slackBot *slackapp.SlackBot) (string, string, error) {
+	if ref := slackBot.Spec.TokenSecretRef; ref != nil {
+		key := ref.Key
+		if key == "" {
+			key = DefaultTokenSecretKey
+		}
+		return ref.Name, key, nil
+	}
+	if slackBot.Spec.TokenReference.Kind != "Secret" {
+		return "", "", fmt.Errorf("expected token of kind Secret but got %s for %s", slackBot.Spec.TokenReference.Kind,
+			slackBot.Name)
+	}
+	return slackBot.Spec.TokenReference.Name, DefaultTokenSecretKey, nil
+}
+
+// readToken returns the token of the SlackBot from the key of its Secret
+func readToken(c *GlobalClients, tokenSecretName string, tokenSecretKey string) ([]byte, error) {
+	secret, err := c.KubeClient.CoreV1().Secrets(c.Namespace).Get(tokenSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	token, ok := secret.Data[tokenSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("expected key %s in field data", tokenSecretKey)
+	}
+	return token, nil
+}
+
+// readSecretKey returns the value of the key of the Secret referenced
+func readSecretKey(c *GlobalClients, ref jenkinsv1.ResourceReference, key string) (string, error) {
+	if ref.Kind != "Secret" {
+		return "", fmt.Errorf("expected reference of kind Secret but got %s", ref.Kind)
+	}
+	secret, err := c.KubeClient.CoreV1().Secrets(c.Namespace).Get(ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("expected key %s in the data of Secret %s", key, ref.Name)
+	}
+	return string(value), nil
+}
@@ -31,7 +31,7 @@ func TestCreateSlackBot(t *testing.T) {
 
 	clients := &GlobalClients{
 		KubeClient:        fakeclient,
-		slackClientHelper: &fakeSlackClient{},
+		slackClientHelper: &fakeSlackClientHelper{},
 	}
 
 	tests := []struct {
@@ -42,6 +42,9 @@ func TestCreateSlackBot(t *testing.T) {
 	}{
 		{name: "secret_does_exist", slackBot: getSlackBot(secretName), want: clients.slackClientHelper.getSlackClient(testToken), wantErr: false},
 		{name: "secret_does_not_exist", slackBot: getSlackBot("does_not_exist"), want: nil, wantErr: true},
+		{name: "invalid_org_status_color", slackBot: getSlackBotWithOrgStatuses(secretName, slackapp.Statuses{
+			Failed: &slackapp.Status{Color: "purple"},
+		}), want: nil, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -75,11 +78,20 @@ func getSlackBot(secretName string) *slackappapi.SlackBot {
 	}
 }
 
-type fakeSlackClient struct {
+func getSlackBotWithOrgStatuses(secretName string, statuses slackapp.Statuses) *slackappapi.SlackBot {
+	slackBot := getSlackBot(secretName)
+	slackBot.Spec.PullRequests = []slackappapi.SlackBotMode{{
+		Channel: "#cheese",
+		Orgs:    []slackappapi.Org{{Name: "cheese", Statuses: statuses}},
+	}}
+	return slackBot
+}
+
+type fakeSlackClientHelper struct {
 	*slack.Client
 }
 
-func (f *fakeSlackClient) getSlackClient(token string, options ...slack.Option) *slack.Client {
+func (f *fakeSlackClientHelper) getSlackClient(token string, options ...slack.Option) *slack.Client {
 	once.Do(startServer)
 	return slack.New(token, slack.OptionAPIURL("http://"+serverAddr+"/"))
 }
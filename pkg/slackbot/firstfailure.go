@@ -0,0 +1,60 @@
+package slackbot
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+)
+
+// failureMessageKey returns the key of the message which notified the failure of the pipeline of the activity, which
+// is shared by all the builds of the pipeline
+func failureMessageKey(activity *record.ActivityRecord) string {
+	return fmt.Sprintf("failure/%s/%s/%s/%s", activity.Owner, activity.Repo, activity.Branch, activity.Context)
+}
+
+// reuseFailureMessage makes the activity update the message which notified the failure of its pipeline in the channel
+// instead of posting a new message, until a build of the pipeline succeeds. It returns true if the message for the
+// activity already notified a failure, so it should be updated silently.
+func (o *SlackBotOptions) reuseFailureMessage(channel string, activity *record.ActivityRecord) bool {
+	if ref := o.messageReference(channel, activity.Name); ref != nil {
+		return ref.NotifiedFailure
+	}
+	failure := o.messageReference(channel, failureMessageKey(activity))
+	if failure == nil || !failure.NotifiedFailure {
+		return false
+	}
+	ref := *failure
+	// the message shows another build, so it always needs updating
+	ref.Hash = ""
+	o.storeMessageReference(channel, activity.Name, &ref)
+	messageLogger(activity, channel, pipelineMessageType).Infof(
+		"Updating the failure already notified for the pipeline of %s\n", activity.Name)
+	return true
+}
+
+// trackFailureNotification records the failure notified by the message posted for the activity in the channel, or
+// forgets about it once the pipeline succeeds
+func (o *SlackBotOptions) trackFailureNotification(channel string, activity *record.ActivityRecord) {
+	ref := o.messageReference(channel, activity.Name)
+	if ref == nil {
+		return
+	}
+	switch pipelineStatus(activity) {
+	case v1alpha1.FailureState:
+		updated := *ref
+		updated.NotifiedFailure = true
+		o.storeMessageReference(channel, activity.Name, &updated)
+		failure := updated
+		o.storeMessageReference(channel, failureMessageKey(activity), &failure)
+	case v1alpha1.SuccessState:
+		if ref.NotifiedFailure {
+			updated := *ref
+			updated.NotifiedFailure = false
+			o.storeMessageReference(channel, activity.Name, &updated)
+		}
+		if o.messageReference(channel, failureMessageKey(activity)) != nil {
+			o.removeMessageReference(channel, failureMessageKey(activity))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_PipelineMessage_notifyOnFirstFailureOnly(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines: []slackapp.SlackBotMode{
+			{Channel: "#cheese", NotifyOnFirstFailureOnly: true},
+		},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+	build := func(number string, status v1alpha1.PipelineState) {
+		act.Name = "jenkins-x-labs-jxl-master-" + number
+		act.BuildIdentifier = number
+		act.Status = status
+		require.NoError(t, o.PipelineMessage(act))
+	}
+
+	build("1", v1alpha1.FailureState)
+	posts := client.callsTo("chat.postMessage")
+	require.Len(t, posts, 1)
+	failureTimestamp := o.Timestamps["#cheese"]["jenkins-x-labs-jxl-master-1"].Timestamp
+
+	// the re-runs update the message of the first failure instead of posting new messages
+	build("2", v1alpha1.RunningState)
+	build("2", v1alpha1.FailureState)
+	build("3", v1alpha1.FailureState)
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+	updates := client.callsTo("chat.update")
+	require.Len(t, updates, 3)
+	for _, update := range updates {
+		assert.Equal(t, failureTimestamp, update.Values.Get("ts"))
+	}
+
+	// a success resets the state, so the next failure is notified again
+	build("4", v1alpha1.SuccessState)
+	assert.Len(t, client.callsTo("chat.update"), 4)
+	assert.Nil(t, o.messageReference("#cheese", failureMessageKey(act)))
+	build("5", v1alpha1.FailureState)
+	assert.Len(t, client.callsTo("chat.postMessage"), 2)
+}
+
+func TestSlackBotOptions_PipelineMessage_notifyEveryFailure(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines:   []slackapp.SlackBotMode{{Channel: "#cheese"}},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+	act.Status = v1alpha1.FailureState
+	for _, number := range []string{"1", "2"} {
+		act.Name = "jenkins-x-labs-jxl-master-" + number
+		act.BuildIdentifier = number
+		require.NoError(t, o.PipelineMessage(act))
+	}
+	assert.Len(t, client.callsTo("chat.postMessage"), 2)
+}
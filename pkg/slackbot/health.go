@@ -0,0 +1,109 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultReadinessTimeout is how long the readiness checks have to complete
+const DefaultReadinessTimeout = 5 * time.Second
+
+// ReadinessCheck returns an error if a service the bot depends on can't be reached
+type ReadinessCheck func(ctx context.Context) error
+
+var readinessChecks = struct {
+	sync.RWMutex
+	checks map[string]ReadinessCheck
+}{checks: map[string]ReadinessCheck{}}
+
+// RegisterReadinessCheck adds a check to the ones run by the readiness handler, replacing any check with the same name
+func RegisterReadinessCheck(name string, check ReadinessCheck) {
+	readinessChecks.Lock()
+	defer readinessChecks.Unlock()
+	readinessChecks.checks[name] = check
+}
+
+// UnregisterReadinessCheck removes a check from the ones run by the readiness handler
+func UnregisterReadinessCheck(name string) {
+	readinessChecks.Lock()
+	defer readinessChecks.Unlock()
+	delete(readinessChecks.checks, name)
+}
+
+// SlackReadinessCheck checks the Slack API can be reached with the token of the client
+func SlackReadinessCheck(client SlackClienter) ReadinessCheck {
+	return func(ctx context.Context) error {
+		_, err := client.AuthTestContext(ctx)
+		return err
+	}
+}
+
+// KubeReadinessCheck checks the Kubernetes API can be reached with a lightweight list in the namespace
+func KubeReadinessCheck(kubeClient kubernetes.Interface, namespace string) ReadinessCheck {
+	return func(ctx context.Context) error {
+		_, err := kubeClient.CoreV1().ConfigMaps(namespace).List(metav1.ListOptions{Limit: 1})
+		return err
+	}
+}
+
+// LivenessHandler reports the bot is alive as long as it can serve HTTP requests
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+}
+
+// ReadinessHandler reports the bot is ready when all the registered readiness checks pass
+func ReadinessHandler(timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		failures := runReadinessChecks(ctx)
+		if len(failures) > 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			for _, failure := range failures {
+				fmt.Fprintln(w, failure)
+			}
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
+
+// runReadinessChecks runs the registered checks concurrently and returns the failures, sorted by check name
+func runReadinessChecks(ctx context.Context) []string {
+	readinessChecks.RLock()
+	checks := make(map[string]ReadinessCheck, len(readinessChecks.checks))
+	for name, check := range readinessChecks.checks {
+		checks[name] = check
+	}
+	readinessChecks.RUnlock()
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	failures := []string{}
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check ReadinessCheck) {
+			defer wg.Done()
+			err := check(ctx)
+			if err != nil {
+				log.Logger().WithField("check", name).Warnf("Readiness check %s failed: %v", name, err)
+				lock.Lock()
+				failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+				lock.Unlock()
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	sort.Strings(failures)
+	return failures
+}
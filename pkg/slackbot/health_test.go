@@ -0,0 +1,56 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLivenessHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestReadinessHandler(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	RegisterReadinessCheck("slack", SlackReadinessCheck(recorder.client()))
+	defer UnregisterReadinessCheck("slack")
+	RegisterReadinessCheck("kubernetes", KubeReadinessCheck(kubefake.NewSimpleClientset(), "jx"))
+	defer UnregisterReadinessCheck("kubernetes")
+
+	w := httptest.NewRecorder()
+	ReadinessHandler(time.Second).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, recorder.callsTo("auth.test"), 1)
+
+	recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":false,"error":"invalid_auth"}`)
+		return true
+	}
+	w = httptest.NewRecorder()
+	ReadinessHandler(time.Second).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "slack: invalid_auth\n", w.Body.String())
+}
+
+func TestReadinessHandler_timeout(t *testing.T) {
+	RegisterReadinessCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	defer UnregisterReadinessCheck("slow")
+
+	w := httptest.NewRecorder()
+	ReadinessHandler(10*time.Millisecond).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,70 @@
+package slackbot
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// slackAPIURL is the base URL of the Slack API the clients created by CreateClients use, slack.APIURL if empty
+var slackAPIURL string
+
+// SetSlackAPIURL sets the base URL of the Slack API, such as the URL of a Slack-compatible gateway, used by the
+// clients created by CreateClients. The default Slack API is used if the URL is empty
+func SetSlackAPIURL(apiURL string) error {
+	if apiURL != "" {
+		u, err := url.Parse(apiURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid Slack API URL %s, expected an http or https URL", apiURL)
+		}
+	}
+	slackAPIURL = apiURL
+	return nil
+}
+
+// NewHTTPClient returns the HTTP client used to reach Slack and the webhooks, which goes through the proxy configured
+// by the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables
+func NewHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	return &http.Client{Transport: transport}
+}
+
+// httpClient returns the HTTP client the Slack API and the webhooks are reached with, http.DefaultClient if none is
+// configured
+func (c *GlobalClients) httpClient() *http.Client {
+	if c == nil || c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// slackAPIURL returns the base URL of the Slack API, with its trailing slash, slack.APIURL if none is configured
+func (c *GlobalClients) slackAPIURL() string {
+	if c == nil || c.SlackAPIURL == "" {
+		return slack.APIURL
+	}
+	return strings.TrimSuffix(c.SlackAPIURL, "/") + "/"
+}
+
+// newSlackClient creates a Slack client for the token, which uses the HTTP client and the Slack API URL configured
+func (c *GlobalClients) newSlackClient(token string) *slack.Client {
+	var options []slack.Option
+	if c.HTTPClient != nil {
+		options = append(options, slack.OptionHTTPClient(c.HTTPClient))
+	}
+	if c.SlackAPIURL != "" {
+		options = append(options, slack.OptionAPIURL(c.slackAPIURL()))
+	}
+	return c.getSlackClient(token, options...)
+}
+
+// NewSlackClient creates a Slack client for the token, which reaches the Slack API set by SetSlackAPIURL through the
+// proxy of the environment
+func NewSlackClient(token string) *slack.Client {
+	c := &GlobalClients{slackClientHelper: &slackWrapper{}, HTTPClient: NewHTTPClient(), SlackAPIURL: slackAPIURL}
+	return c.newSlackClient(token)
+}
@@ -0,0 +1,84 @@
+package slackbot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGlobalClients_newSlackClient(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	transport := &countingTransport{}
+	c := &GlobalClients{
+		slackClientHelper: &slackWrapper{},
+		HTTPClient:        &http.Client{Transport: transport},
+		// the trailing slash the Slack client expects is added
+		SlackAPIURL: recorder.server.URL,
+	}
+
+	_, err := c.newSlackClient(validToken).AuthTestContext(context.Background())
+	require.NoError(t, err)
+	calls := recorder.callsTo("auth.test")
+	require.Len(t, calls, 1, "the Slack API URL should be used")
+	assert.Equal(t, validToken, calls[0].Values.Get("token"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&transport.requests), "the HTTP client should be used")
+}
+
+func TestGlobalClients_slackAPIURL(t *testing.T) {
+	assert.Equal(t, slack.APIURL, (*GlobalClients)(nil).slackAPIURL())
+	assert.Equal(t, slack.APIURL, (&GlobalClients{}).slackAPIURL())
+	c := &GlobalClients{SlackAPIURL: "https://slack.example.com/api"}
+	assert.Equal(t, "https://slack.example.com/api/", c.slackAPIURL())
+	assert.Equal(t, http.DefaultClient, (*GlobalClients)(nil).httpClient())
+}
+
+func TestSlackBotOptions_postIncomingWebhook_httpClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	transport := &countingTransport{}
+	o := &SlackBotOptions{
+		GlobalClients:    &GlobalClients{HTTPClient: &http.Client{Transport: transport}},
+		SlackClient:      &fakeSlackClient{},
+		Timestamps:       make(map[string]map[string]*MessageReference),
+		DeliveryMode:     DeliveryModeWebhook,
+		IncomingWebhooks: map[string]string{"cheese": server.URL},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	act.Status = v1alpha1.SuccessState
+
+	err = o.postMessage("#cheese", false, pipelineMessageType, act, nil, []slack.Attachment{{Text: "build succeeded"}},
+		nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&transport.requests), "the HTTP client should be used")
+}
+
+func TestSetSlackAPIURL(t *testing.T) {
+	defer func() {
+		slackAPIURL = ""
+	}()
+	require.NoError(t, SetSlackAPIURL("https://slack-gateway.example.com/api/"))
+	assert.Equal(t, "https://slack-gateway.example.com/api/", slackAPIURL)
+	require.NoError(t, SetSlackAPIURL(""))
+	assert.Empty(t, slackAPIURL)
+	assert.Error(t, SetSlackAPIURL("slack-gateway.example.com"))
+}
@@ -0,0 +1,150 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DeliveryModeToken posts the messages with the Slack Web API and the token of the bot
+	DeliveryModeToken = "token"
+	// DeliveryModeWebhook posts the messages with the incoming webhooks of the channels, which can't update them
+	DeliveryModeWebhook = "webhook"
+)
+
+// deliveryModes are the ways the messages can be posted to Slack
+var deliveryModes = []string{DeliveryModeToken, DeliveryModeWebhook}
+
+// readIncomingWebhooks returns the incoming webhook URLs of the channels, keyed by channel name in the Secret
+// referenced by the SlackBot
+func readIncomingWebhooks(c *GlobalClients, slackBot *slackapp.SlackBot) (map[string]string, error) {
+	name := slackBot.Spec.IncomingWebhooksSecret
+	if name == "" {
+		return nil, fmt.Errorf("expected an incomingWebhooksSecret for the %s delivery mode of %s", DeliveryModeWebhook,
+			slackBot.Name)
+	}
+	secret, err := c.KubeClient.CoreV1().Secrets(c.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the incoming webhooks of %s", slackBot.Name)
+	}
+	webhooks := make(map[string]string, len(secret.Data))
+	for channel, url := range secret.Data {
+		webhooks[channel] = string(url)
+	}
+	return webhooks, nil
+}
+
+// webAPIDisabled returns true if the messages are posted with incoming webhooks, the bot then has no token to call the
+// Slack Web API with
+func (o *SlackBotOptions) webAPIDisabled() bool {
+	return o.DeliveryMode == DeliveryModeWebhook
+}
+
+// incomingWebhookURL returns the URL of the incoming webhook of the channel, which is configured with or without its
+// leading #
+func (o *SlackBotOptions) incomingWebhookURL(channel string) string {
+	if url := o.IncomingWebhooks[channel]; url != "" {
+		return url
+	}
+	if len(channel) > 0 && channel[0] == '#' {
+		return o.IncomingWebhooks[channel[1:]]
+	}
+	return ""
+}
+
+// webhookState returns the state of the activity which needs a new message to be posted with an incoming webhook when
+// it changes: the status of the pipeline, and for the review messages the statuses of the review and approvals too
+func webhookState(messageType string, activity *record.ActivityRecord, attachments []slack.Attachment) string {
+	state := []string{string(pipelineStatus(activity))}
+	if messageType == pullRequestReviewMessageType && len(attachments) > 0 {
+		// the review message shows its statuses in the fields without a title
+		for _, field := range attachments[0].Fields {
+			if field.Title == "" {
+				state = append(state, field.Value)
+			}
+		}
+	}
+	return strings.Join(state, "\n")
+}
+
+// postIncomingWebhook posts the message to the incoming webhook of the channel. Incoming webhooks can't update the
+// messages they posted, so a new message is only posted when the state of the activity changes, rather than for
+// every event
+func (o *SlackBotOptions) postIncomingWebhook(ctx context.Context, channel string, directMessage bool,
+	messageType string, activity *record.ActivityRecord, messageRef *MessageReference, attachments []slack.Attachment,
+	hash string, createIfMissing bool) error {
+	logger := messageLogger(activity, channel, messageType)
+	if directMessage {
+		logger.Debugf("Not sending direct message for %s, incoming webhooks can only post to channels\n",
+			activity.Name)
+		return nil
+	}
+	url := o.incomingWebhookURL(channel)
+	if url == "" {
+		logger.Warnf("No incoming webhook configured for %s, not posting the message for %s", channel, activity.Name)
+		return nil
+	}
+	state := webhookState(messageType, activity, attachments)
+	if messageRef == nil && !createIfMissing {
+		logger.Infof("No existing message for %s, ignoring\n", activity.Name)
+		return nil
+	}
+	if messageRef != nil && messageRef.WebhookState == state {
+		logger.Infof("State of %s is unchanged, not posting a new message\n", activity.Name)
+		return nil
+	}
+	err := o.RateLimiter.Wait(ctx, channel, directMessage)
+	if err != nil {
+		return errors.Wrapf(err, "waiting to post to %s", channel)
+	}
+	err = o.postWithRetry(ctx, "posting message", func(ctx context.Context) error {
+		defer observeSlackAPICall("incoming-webhook", time.Now())
+		return slack.PostWebhookCustomHTTPContext(ctx, url, o.httpClient(), &slack.WebhookMessage{
+			Attachments: attachments,
+		})
+	})
+	if err != nil {
+		messagesFailed.WithLabelValues(messageType).Inc()
+		return errors.Wrapf(err, "posting the message for %s to the incoming webhook of %s", activity.Name, channel)
+	}
+	logger.Infof("Sent message for %s with the incoming webhook\n", activity.Name)
+	messagesCreated.WithLabelValues(messageType).Inc()
+	var reviewers []string
+	notifiedFailure := false
+	if messageRef != nil {
+		reviewers = messageRef.Reviewers
+		notifiedFailure = messageRef.NotifiedFailure
+	}
+	// the reference has no timestamp, as the message can't be updated, but remembers the state it was posted for
+	o.storeMessageReference(channel, activity.Name, &MessageReference{
+		Hash:            hash,
+		Status:          pipelineStatus(activity),
+		Reviewers:       reviewers,
+		NotifiedFailure: notifiedFailure,
+		WebhookState:    state,
+	})
+	o.emitWebhooks(channel, directMessage, messageType, activity, attachments, nil)
+	return nil
+}
+
+// postIncomingWebhookText posts the text to the incoming webhook of the channel, it is skipped if the channel has none
+func (o *SlackBotOptions) postIncomingWebhookText(ctx context.Context, channel string, text string) error {
+	url := o.incomingWebhookURL(channel)
+	if url == "" {
+		log.Logger().Warnf("No incoming webhook configured for %s, not posting the message", channel)
+		return nil
+	}
+	return o.postWithRetry(ctx, "posting message", func(ctx context.Context) error {
+		defer observeSlackAPICall("incoming-webhook", time.Now())
+		return slack.PostWebhookCustomHTTPContext(ctx, url, o.httpClient(), &slack.WebhookMessage{Text: text})
+	})
+}
@@ -0,0 +1,120 @@
+package slackbot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_postIncomingWebhook(t *testing.T) {
+	var lock sync.Mutex
+	var messages []slack.WebhookMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slack.WebhookMessage
+		err := json.NewDecoder(r.Body).Decode(&message)
+		require.NoError(t, err)
+		lock.Lock()
+		messages = append(messages, message)
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient:      client,
+		Timestamps:       make(map[string]map[string]*MessageReference),
+		DeliveryMode:     DeliveryModeWebhook,
+		IncomingWebhooks: map[string]string{"cheese": server.URL},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	post := func(channel string, directMessage bool, status v1alpha1.PipelineState, text string) {
+		act.Status = status
+		err := o.postMessage(channel, directMessage, pipelineMessageType, act, nil, []slack.Attachment{{Text: text}},
+			nil, true)
+		require.NoError(t, err)
+	}
+
+	post("#cheese", false, v1alpha1.RunningState, "build running")
+	// webhooks can't edit their messages, so the progress of a running build isn't posted again
+	post("#cheese", false, v1alpha1.RunningState, "build running, 2 steps done")
+	post("#cheese", false, v1alpha1.SuccessState, "build succeeded")
+	// the channels without an incoming webhook and the direct messages are skipped
+	post("#wine", false, v1alpha1.SuccessState, "build succeeded")
+	post("U0001", true, v1alpha1.SuccessState, "build succeeded")
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, "build running", messages[0].Attachments[0].Text)
+	assert.Equal(t, "build succeeded", messages[1].Attachments[0].Text)
+	assert.Empty(t, client.calls, "the Web API is not used")
+
+	// the reactions need the timestamp of the message, which incoming webhooks don't return
+	err = o.reactOnComplete("#cheese", act, o.Statuses)
+	require.NoError(t, err)
+	assert.Empty(t, client.calls)
+}
+
+func TestSlackBotOptions_postIncomingWebhook_review(t *testing.T) {
+	var lock sync.Mutex
+	var messages []slack.WebhookMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slack.WebhookMessage
+		err := json.NewDecoder(r.Body).Decode(&message)
+		require.NoError(t, err)
+		lock.Lock()
+		messages = append(messages, message)
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient:      client,
+		Timestamps:       make(map[string]map[string]*MessageReference),
+		DeliveryMode:     DeliveryModeWebhook,
+		IncomingWebhooks: map[string]string{"reviews": server.URL},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Status = v1alpha1.SuccessState
+	post := func(title string, review string) {
+		attachments := []slack.Attachment{{
+			Text: title,
+			Fields: []slack.AttachmentField{
+				{Value: review, Short: true},
+				{Value: ":white_check_mark: build succeeded", Short: true},
+			},
+		}}
+		err := o.postMessage("#reviews", false, pullRequestReviewMessageType, act, nil, attachments, nil, true)
+		require.NoError(t, err)
+	}
+
+	post("Please review", ":eyes: waiting for review")
+	// the title isn't a meaningful change, the approval of the pull request is
+	post("Please review, renamed", ":eyes: waiting for review")
+	post("Please review, renamed", ":white_check_mark: approved")
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, ":white_check_mark: approved", messages[1].Attachments[0].Fields[0].Value)
+
+	// the review digest is posted with the incoming webhook too
+	err = o.postReviewDigest(context.Background(), "#reviews", []string{"<https://github.com/cheese/wine/pull/1|#1>"})
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Contains(t, messages[2].Text, "1 pull requests waiting for a review")
+
+	// nothing else calls the Web API, which has no token in this mode
+	assert.Equal(t, "#reviews", o.resolveChannelID(context.Background(), "", "#reviews"))
+	assert.Empty(t, o.slackAvatar("", "U0001"))
+	assert.NoError(t, o.ReadinessCheck()(context.Background()))
+	assert.Empty(t, client.calls, "the Web API is not used")
+}
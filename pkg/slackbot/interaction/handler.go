@@ -0,0 +1,171 @@
+package interaction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+// DefaultResponseTimeout is how long an action can take to be handled, leaving some margin within the 3 seconds Slack
+// waits for a response
+const DefaultResponseTimeout = 2 * time.Second
+
+// BlockIDSeparator separates the callback ID from the suffix making the IDs of the blocks of a message unique, the
+// suffix is ignored when routing the action
+const BlockIDSeparator = "#"
+
+// Action is an action of a user on a message sent by the bot, such as clicking one of its buttons
+type Action struct {
+	// ID is the part of the callback ID of the message after the prefix of the handler, such as the name of the
+	// PipelineActivity for the pipelineactivity:<name> callback ID
+	ID string
+	// Value is the value of the button clicked
+	Value string
+	// UserID is the Slack ID of the user who clicked the button
+	UserID string
+	// ChannelID is the ID of the channel of the message
+	ChannelID string
+}
+
+// ActionHandler handles the actions on the messages whose callback ID starts with the prefix it is registered for.
+// The message returned, if any, is sent in response to the action.
+type ActionHandler func(ctx context.Context, action Action) (*slack.Msg, error)
+
+// Handler handles the interactive callbacks Slack sends when users act on the messages of the bot, dispatching them to
+// the ActionHandler registered for the prefix of their callback ID
+type Handler struct {
+	// SigningSecret is used to verify that the requests come from Slack
+	SigningSecret string
+	// ResponseTimeout is how long an action can take to be handled
+	ResponseTimeout time.Duration
+
+	handlers     map[string]ActionHandler
+	handlersLock sync.RWMutex
+}
+
+// Register registers the handler for the actions on the messages whose callback ID is the prefix followed by a colon,
+// such as pipelineactivity for the pipelineactivity:<name> callback ID. It replaces the handler already registered
+// for the prefix, if any.
+func (h *Handler) Register(prefix string, handler ActionHandler) {
+	h.handlersLock.Lock()
+	defer h.handlersLock.Unlock()
+	if h.handlers == nil {
+		h.handlers = make(map[string]ActionHandler)
+	}
+	h.handlers[prefix] = handler
+}
+
+// ServeHTTP verifies and handles an interactive callback
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	callback, err := h.parse(r)
+	if err != nil {
+		log.Logger().Warnf("rejecting interactive callback: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	callbackID, action, ok := actionOf(callback)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	parts := strings.SplitN(callbackID, ":", 2)
+	h.handlersLock.RLock()
+	handler := h.handlers[parts[0]]
+	h.handlersLock.RUnlock()
+	if handler == nil || len(parts) != 2 {
+		log.Logger().Debugf("ignoring interactive callback %s, no handler is registered for it", callbackID)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	action.ID = parts[1]
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.responseTimeout())
+	defer cancel()
+	msg, err := handler(ctx, action)
+	if err != nil {
+		log.Logger().Warnf("failed to handle the %s action on %s: %v", action.Value, callbackID, err)
+		msg = &slack.Msg{
+			ResponseType: slack.ResponseTypeEphemeral,
+			Text:         fmt.Sprintf("Failed to %s %s", action.Value, action.ID),
+		}
+	}
+	if msg == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(msg)
+	if err != nil {
+		log.Logger().Warnf("failed to write interactive callback response: %v", err)
+	}
+}
+
+// parse verifies the signature of the request and parses the interactive callback from its payload
+func (h *Handler) parse(r *http.Request) (slack.InteractionCallback, error) {
+	callback := slack.InteractionCallback{}
+	verifier, err := slack.NewSecretsVerifier(r.Header, h.SigningSecret)
+	if err != nil {
+		return callback, err
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return callback, err
+	}
+	_, err = verifier.Write(body)
+	if err != nil {
+		return callback, err
+	}
+	err = verifier.Ensure()
+	if err != nil {
+		return callback, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	err = json.Unmarshal([]byte(r.FormValue("payload")), &callback)
+	if err != nil {
+		return callback, errors.Wrap(err, "parsing interactive callback payload")
+	}
+	return callback, nil
+}
+
+// actionOf returns the callback ID of the message and the first action of the callback. The callback ID of the
+// messages made of blocks is the ID of the block of the action, without its suffix.
+func actionOf(callback slack.InteractionCallback) (string, Action, bool) {
+	action := Action{
+		UserID:    callback.User.ID,
+		ChannelID: callback.Channel.ID,
+	}
+	switch {
+	case len(callback.ActionCallback.AttachmentActions) > 0:
+		action.Value = callback.ActionCallback.AttachmentActions[0].Value
+		return callback.CallbackID, action, true
+	case len(callback.ActionCallback.BlockActions) > 0:
+		action.Value = callback.ActionCallback.BlockActions[0].Value
+		blockID := callback.ActionCallback.BlockActions[0].BlockID
+		if i := strings.LastIndex(blockID, BlockIDSeparator); i >= 0 {
+			blockID = blockID[:i]
+		}
+		return blockID, action, true
+	}
+	return "", action, false
+}
+
+func (h *Handler) responseTimeout() time.Duration {
+	if h.ResponseTimeout > 0 {
+		return h.ResponseTimeout
+	}
+	return DefaultResponseTimeout
+}
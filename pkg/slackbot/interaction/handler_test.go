@@ -0,0 +1,142 @@
+package interaction
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const signingSecret = "cheese"
+
+func newInteractionRequest(t *testing.T, callback slack.InteractionCallback, secret string) *http.Request {
+	payload, err := json.Marshal(callback)
+	require.NoError(t, err)
+	body := url.Values{"payload": {string(payload)}}.Encode()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, err = mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func attachmentCallback(callbackID string, value string) slack.InteractionCallback {
+	callback := slack.InteractionCallback{
+		Type:       slack.InteractionTypeInteractionMessage,
+		CallbackID: callbackID,
+		User:       slack.User{ID: "U2147483697"},
+		ActionCallback: slack.ActionCallbacks{
+			AttachmentActions: []*slack.AttachmentAction{{Name: value, Value: value}},
+		},
+	}
+	callback.Channel.ID = "C024BE91L"
+	return callback
+}
+
+// recordingHandler records the actions it handles, replying with the text
+func recordingHandler(actions *[]Action, text string, err error) ActionHandler {
+	return func(ctx context.Context, action Action) (*slack.Msg, error) {
+		*actions = append(*actions, action)
+		if err != nil {
+			return nil, err
+		}
+		return &slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: text}, nil
+	}
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	var actions []Action
+	h := &Handler{SigningSecret: signingSecret}
+	h.Register("pipelineactivity", recordingHandler(&actions, "Rerunning", nil))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newInteractionRequest(t, attachmentCallback("pipelineactivity:cheese-wine-1", "rerun"),
+		signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	msg := slack.Msg{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, slack.ResponseTypeEphemeral, msg.ResponseType)
+	assert.Equal(t, "Rerunning", msg.Text)
+	assert.Equal(t, []Action{{ID: "cheese-wine-1", Value: "rerun", UserID: "U2147483697", ChannelID: "C024BE91L"}},
+		actions)
+}
+
+func TestHandler_ServeHTTPBlockActions(t *testing.T) {
+	var actions []Action
+	h := &Handler{SigningSecret: signingSecret}
+	h.Register("pipelineactivity", recordingHandler(&actions, "Rerunning", nil))
+
+	callback := slack.InteractionCallback{
+		Type: slack.InteractionTypeBlockActions,
+		User: slack.User{ID: "U2147483697"},
+		ActionCallback: slack.ActionCallbacks{
+			BlockActions: []*slack.BlockAction{
+				{ActionID: "pipelineactivity:cheese-wine-1-0-3", BlockID: "pipelineactivity:cheese-wine-1#2",
+					Value: "rerun"},
+			},
+		},
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newInteractionRequest(t, callback, signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, actions, 1)
+	assert.Equal(t, "cheese-wine-1", actions[0].ID)
+	assert.Equal(t, "rerun", actions[0].Value)
+}
+
+func TestHandler_ServeHTTPUnknownCallback(t *testing.T) {
+	var actions []Action
+	h := &Handler{SigningSecret: signingSecret}
+	h.Register("pipelineactivity", recordingHandler(&actions, "Rerunning", nil))
+
+	for _, callbackID := range []string{"preview:cheese-wine-1", "pipelineactivity", ""} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newInteractionRequest(t, attachmentCallback(callbackID, "rerun"), signingSecret))
+		assert.Equal(t, http.StatusOK, w.Code, callbackID)
+		assert.Empty(t, w.Body.String(), callbackID)
+	}
+	assert.Empty(t, actions)
+}
+
+func TestHandler_ServeHTTPHandlerError(t *testing.T) {
+	var actions []Action
+	h := &Handler{SigningSecret: signingSecret}
+	h.Register("pipelineactivity", recordingHandler(&actions, "", fmt.Errorf("not found")))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newInteractionRequest(t, attachmentCallback("pipelineactivity:cheese-wine-1", "rerun"),
+		signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	msg := slack.Msg{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, slack.ResponseTypeEphemeral, msg.ResponseType)
+	assert.Equal(t, "Failed to rerun cheese-wine-1", msg.Text)
+}
+
+func TestHandler_ServeHTTPInvalidSignature(t *testing.T) {
+	var actions []Action
+	h := &Handler{SigningSecret: signingSecret}
+	h.Register("pipelineactivity", recordingHandler(&actions, "Rerunning", nil))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newInteractionRequest(t, attachmentCallback("pipelineactivity:cheese-wine-1", "rerun"), "wine"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, actions)
+}
@@ -0,0 +1,59 @@
+package slackbot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/slack-go/slack"
+)
+
+// DefaultIssueKeyPattern matches the issue keys such as JX-123 when the issue tracker has no key pattern
+const DefaultIssueKeyPattern = `\b[A-Z][A-Z0-9]+-[0-9]+\b`
+
+// compileIssueKeyPattern compiles the key pattern of the issue tracker, or the DefaultIssueKeyPattern
+func compileIssueKeyPattern(tracker *slackapp.IssueTracker) (*regexp.Regexp, error) {
+	pattern := tracker.KeyPattern
+	if pattern == "" {
+		pattern = DefaultIssueKeyPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyPattern %s: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// issueKeys returns the distinct issue keys of the title, in order
+func issueKeys(re *regexp.Regexp, title string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range re.FindAllString(title, -1) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// issueFields returns the field linking the issues referenced by the title of the pull request to the issue tracker,
+// or nothing if there is no issue tracker or no issue key in the title
+func (o *SlackBotOptions) issueFields(pr *gits.GitPullRequest) []slack.AttachmentField {
+	if o.IssueTracker == nil || o.issueKeys == nil || pr == nil {
+		return nil
+	}
+	var links []string
+	for _, key := range issueKeys(o.issueKeys, pr.Title) {
+		links = append(links, link(key, o.IssueTracker.URL+key))
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return []slack.AttachmentField{{
+		Value: ":ticket: " + strings.Join(links, ", "),
+		Short: true,
+	}}
+}
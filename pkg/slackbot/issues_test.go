@@ -0,0 +1,45 @@
+package slackbot
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_issueKeys(t *testing.T) {
+	re := regexp.MustCompile(DefaultIssueKeyPattern)
+	assert.Equal(t, []string{"JX-123", "CHEESE-4"}, issueKeys(re, "JX-123 CHEESE-4: fix JX-123 again"))
+	assert.Empty(t, issueKeys(re, "fix the build of jx-123 on x86-64"))
+}
+
+func TestSlackBotOptions_issueFields(t *testing.T) {
+	pr := &gits.GitPullRequest{Title: "JX-123 add cheddar, see also JX-7"}
+	o := &SlackBotOptions{}
+
+	// nothing is rendered without an issue tracker
+	assert.Empty(t, o.issueFields(pr))
+
+	o.IssueTracker = &slackapp.IssueTracker{URL: "https://issues.example.com/browse/"}
+	var err error
+	o.issueKeys, err = compileIssueKeyPattern(o.IssueTracker)
+	require.NoError(t, err)
+	fields := o.issueFields(pr)
+	require.Len(t, fields, 1)
+	assert.Equal(t, ":ticket: <https://issues.example.com/browse/JX-123|JX-123>, "+
+		"<https://issues.example.com/browse/JX-7|JX-7>", fields[0].Value)
+
+	// nothing is rendered when the title has no issue key
+	assert.Empty(t, o.issueFields(&gits.GitPullRequest{Title: "add brie"}))
+
+	o.IssueTracker.KeyPattern = `#[0-9]+`
+	o.issueKeys, err = compileIssueKeyPattern(o.IssueTracker)
+	require.NoError(t, err)
+	assert.Empty(t, o.issueFields(pr))
+
+	_, err = compileIssueKeyPattern(&slackapp.IssueTracker{KeyPattern: "JX-(\\d+"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package slackbot
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// updateJitter returns the random delay to wait before updating a message, up to UpdateJitter
+func (o *SlackBotOptions) updateJitter() time.Duration {
+	if o.UpdateJitter <= 0 {
+		return 0
+	}
+	random := o.jitterSource
+	if random == nil {
+		random = rand.Int63n
+	}
+	return time.Duration(random(int64(o.UpdateJitter)))
+}
+
+// waitUpdateJitter waits for a random delay before a message is updated, so that the updates of the many activities
+// resynced at once by the informer are spread instead of being sent to Slack in a burst
+func (o *SlackBotOptions) waitUpdateJitter(ctx context.Context) error {
+	delay := o.updateJitter()
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "waiting %s before updating the message", delay)
+	case <-time.After(delay):
+		return nil
+	}
+}
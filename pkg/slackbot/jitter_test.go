@@ -0,0 +1,53 @@
+package slackbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_PipelineMessage_updateJitter(t *testing.T) {
+	client := &fakeSlackClient{}
+	var jitters []int64
+	o := &SlackBotOptions{
+		SlackClient:  client,
+		Timestamps:   make(map[string]map[string]*MessageReference),
+		Pipelines:    []slackapp.SlackBotMode{{Channel: "#cheese"}},
+		UpdateJitter: time.Second,
+		jitterSource: func(n int64) int64 {
+			jitters = append(jitters, n)
+			return int64(time.Millisecond)
+		},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+
+	// the new message is posted straight away
+	act.Status = v1alpha1.RunningState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+	assert.Empty(t, jitters)
+
+	// the updates wait for a random delay up to the jitter
+	act.Status = v1alpha1.SuccessState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.update"), 1)
+	assert.Equal(t, []int64{int64(time.Second)}, jitters)
+}
+
+func TestSlackBotOptions_updateJitter(t *testing.T) {
+	o := &SlackBotOptions{}
+	assert.Equal(t, time.Duration(0), o.updateJitter())
+
+	o.UpdateJitter = time.Second
+	for i := 0; i < 10; i++ {
+		jitter := o.updateJitter()
+		assert.True(t, jitter >= 0 && jitter < time.Second, "jitter %s out of range", jitter)
+	}
+}
@@ -1,16 +1,17 @@
 package slackbot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"sort"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/jx"
 	lhutil "github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/test-infra/prow/pluginhelp"
@@ -76,13 +77,15 @@ func (s *SlackBots) processPR(owner, repo string, number int) error {
 	// This is the trigger. Working out the correct slack message is a bit tricky,
 	// as we have a 1:n mapping between PRs and PipelineActivities (which store the message info).
 	// The algorithm in use just picks the earliest pipeline activity as determined by build number
-	acts, err := s.getPipelineActivities(owner, repo, number)
+	ctx, cancel := s.withTimeout(context.Background())
+	defer cancel()
+	acts, err := s.getPipelineActivities(ctx, owner, repo, number)
 
 	if err != nil {
 		return err
 	}
 	if len(acts.Items) > 0 {
-		sort.Sort(byBuildNumber(acts.Items))
+		sortPipelineActivities(acts.Items)
 		act := acts.Items[0]
 		ar, err := jx.ConvertPipelineActivity(&act)
 		if err != nil {
@@ -152,13 +155,12 @@ func (s *SlackBots) handleLighthouseEvent(r *http.Request) error {
 		}
 	}
 	if activity != nil {
-		// now we can just run the bots for the activity
+		// now we can just run the bots for the activity, a bot failing to post doesn't stop the others
+		var errs []error
 		for _, bot := range s.Items {
-			err := bot.PipelineMessage(activity)
-			if err != nil {
-				return err
-			}
+			errs = append(errs, bot.PipelineMessage(activity), bot.PromotionMessage(activity))
 		}
+		return utilerrors.NewAggregate(errs)
 	}
 
 	return nil
@@ -5,6 +5,8 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -22,6 +24,8 @@ import (
 	"github.com/jenkins-x/slack/pkg/client/clientset/versioned/fake"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slacktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -66,6 +70,37 @@ func TestLighthouseDelivery(t *testing.T) {
 
 }
 
+func TestSlackBots_handleLighthouseEvent(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	payload, err := json.Marshal(act)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set("User-Agent", util.LighthouseUserAgent)
+	req.Header.Set(util.LighthousePayloadTypeHeader, util.LighthousePayloadTypeActivity)
+	mac := hmac.New(sha256.New, nil)
+	_, err = mac.Write(payload)
+	require.NoError(t, err)
+	req.Header.Set(util.LighthouseSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	// the bot failing to post doesn't stop the following bots
+	failing := &fakeSlackClient{err: errors.New("channel_not_found")}
+	working := &fakeSlackClient{}
+	s := &SlackBots{GlobalClients: &GlobalClients{}}
+	for _, client := range []*fakeSlackClient{failing, working} {
+		s.Items = append(s.Items, &SlackBotOptions{
+			SlackClient: client,
+			Timestamps:  make(map[string]map[string]*MessageReference),
+			Pipelines:   []slackappapi.SlackBotMode{{Channel: "#cheese"}},
+		})
+	}
+	err = s.handleLighthouseEvent(req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "channel_not_found")
+	assert.Len(t, working.callsTo("chat.postMessage"), 1)
+}
+
 func createServer(customizers ...func(slacktest.Customize)) *slacktest.Server {
 	s := slacktest.NewTestServer()
 	for _, c := range customizers {
@@ -90,14 +125,14 @@ func createSlackBot() *slackappapi.SlackBot {
 				Channel: testChannel,
 				Orgs: []slackappapi.Org{{
 					Name:  testOrgName,
-					Repos: []string{testRepoName},
+					Repos: []slackappapi.Repo{{Name: testRepoName}},
 				}},
 			}},
 			Pipelines: []slackappapi.SlackBotMode{{
 				Channel: testChannel,
 				Orgs: []slackappapi.Org{{
 					Name:  testOrgName,
-					Repos: []string{testRepoName},
+					Repos: []slackappapi.Repo{{Name: testRepoName}},
 				}},
 			}},
 		},
@@ -0,0 +1,122 @@
+package slackbot
+
+import (
+	"reflect"
+	"sort"
+
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+)
+
+// DefaultLocale is the locale of the messages when none is configured
+const DefaultLocale = "en"
+
+// messageCatalog holds the texts of the messages sent by the bot in a locale
+type messageCatalog struct {
+	// statuses are the default statuses, with their text translated
+	statuses slackapp.Statuses
+	// reviewMessageTemplate is the template of the review message, unless the bot configures one
+	reviewMessageTemplate string
+	// closedReviewMessageTemplate is the template of the review message once the pull request is closed, unless the
+	// bot configures one
+	closedReviewMessageTemplate string
+	// reviewerThreadReply is the format of the threaded reply mentioning the reviewers
+	reviewerThreadReply string
+	// approvalProgress is the format of the number of approvals of a pull request out of the number required
+	approvalProgress string
+	// reviewDigestTitle is the format of the title of the daily digest of the pull requests waiting for a review
+	reviewDigestTitle string
+	// commitFieldTitle is the title of the field showing the commit message of a pipeline
+	commitFieldTitle string
+	// durationFieldTitle is the title of the field showing how long the build of a pipeline took
+	durationFieldTitle string
+	// runningDuration is the format of the time a build has been running for
+	runningDuration string
+}
+
+// messageCatalogs are the catalogs of the supported locales
+var messageCatalogs = map[string]messageCatalog{
+	DefaultLocale: {
+		statuses: defaultStatuses,
+		reviewMessageTemplate: "{{ .Mentions }} {{ if .Mentions }}please{{ else }}Please{{ end }} review {{ .PRLink }} " +
+			"created on {{ .Repo }} by {{ .Author }}",
+		closedReviewMessageTemplate: "{{ .PRLink }} on {{ .Repo }} by {{ .Author }} was {{ .State }}",
+		reviewerThreadReply:         "%s please review",
+		approvalProgress:            "%d/%d approvals",
+		reviewDigestTitle:           "%d pull requests waiting for a review",
+		commitFieldTitle:            "Commit",
+		durationFieldTitle:          "Duration",
+		runningDuration:             "%s so far",
+	},
+	"fr": {
+		statuses: translateStatuses(defaultStatuses, map[string]string{
+			"Merged":        "fusionnée",
+			"Closed":        "fermée sans être fusionnée",
+			"Merging":       "en cours de fusion",
+			"Queued":        "en file d'attente de fusion",
+			"Rebase":        "à rebaser",
+			"Aborted":       "build annulé",
+			"Errored":       "build en erreur",
+			"Failed":        "build en échec",
+			"Approved":      "approuvée",
+			"NotApproved":   "non approuvée",
+			"NeedsOkToTest": "attend /ok-to-test",
+			"Hold":          "en pause",
+			"Pending":       "build en attente",
+			"Running":       "build en cours",
+			"Succeeded":     "build réussi",
+			"Skipped":       "build ignoré",
+		}),
+		reviewMessageTemplate: "{{ .Mentions }} {{ if .Mentions }}merci{{ else }}Merci{{ end }} de relire {{ .PRLink }} " +
+			"créée sur {{ .Repo }} par {{ .Author }}",
+		closedReviewMessageTemplate: "{{ .PRLink }} sur {{ .Repo }} par {{ .Author }} a été " +
+			"{{ if eq .State \"merged\" }}fusionnée{{ else }}fermée{{ end }}",
+		reviewerThreadReply: "%s merci de relire",
+		approvalProgress:    "%d/%d approbations",
+		reviewDigestTitle:   "%d pull requests en attente de relecture",
+		commitFieldTitle:    "Commit",
+		durationFieldTitle:  "Durée",
+		runningDuration:     "%s pour l'instant",
+	},
+}
+
+// supportedLocales returns the locales which have a message catalog, sorted
+func supportedLocales() []string {
+	var locales []string
+	for locale := range messageCatalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// messageCatalog returns the catalog of the locale of the bot, falling back to the default locale
+func (o *SlackBotOptions) messageCatalog() messageCatalog {
+	if catalog, ok := messageCatalogs[o.Locale]; ok {
+		return catalog
+	}
+	return messageCatalogs[DefaultLocale]
+}
+
+// localizedStatuses returns the statuses of the bot, falling back to the statuses of its locale
+func (o *SlackBotOptions) localizedStatuses() slackapp.Statuses {
+	return mergeStatuses(o.Statuses, o.messageCatalog().statuses)
+}
+
+// translateStatuses returns a copy of the statuses with their text replaced by the one of the texts, keyed by the
+// name of the status field. The statuses which have no text keep theirs.
+func translateStatuses(statuses slackapp.Statuses, texts map[string]string) slackapp.Statuses {
+	translated := statuses
+	s := reflect.ValueOf(statuses)
+	t := reflect.ValueOf(&translated).Elem()
+	for i := 0; i < s.NumField(); i++ {
+		status, ok := s.Field(i).Interface().(*slackapp.Status)
+		text, found := texts[s.Type().Field(i).Name]
+		if !ok || status == nil || !found {
+			continue
+		}
+		copied := *status
+		copied.Text = text
+		t.Field(i).Set(reflect.ValueOf(&copied))
+	}
+	return translated
+}
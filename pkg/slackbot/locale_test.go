@@ -0,0 +1,35 @@
+package slackbot
+
+import (
+	"testing"
+
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackBotOptions_localizedStatuses(t *testing.T) {
+	o := &SlackBotOptions{
+		Locale:   "fr",
+		Statuses: slackapp.Statuses{Merged: &slackapp.Status{Emoji: ":tada:", Text: "mergée"}},
+	}
+	statuses := o.localizedStatuses()
+	// the statuses of the bot take precedence over the ones of the locale
+	assert.Equal(t, &slackapp.Status{Emoji: ":tada:", Text: "mergée"}, statuses.Merged)
+	assert.Equal(t, &slackapp.Status{Emoji: ":white_check_mark:", Text: "build réussi"}, statuses.Succeeded)
+	assert.Equal(t, defaultStatuses.LGTM, statuses.LGTM)
+	// the default statuses aren't modified
+	assert.Equal(t, "build succeeded", defaultStatuses.Succeeded.Text)
+
+	o.Locale = ""
+	assert.Equal(t, defaultStatuses.Succeeded, o.localizedStatuses().Succeeded)
+}
+
+func Test_messageCatalogs(t *testing.T) {
+	for locale, catalog := range messageCatalogs {
+		_, err := parseReviewMessageTemplate(catalog.reviewMessageTemplate)
+		assert.NoError(t, err, locale)
+		_, err = parseReviewMessageTemplate(catalog.closedReviewMessageTemplate)
+		assert.NoError(t, err, locale)
+	}
+	assert.Equal(t, []string{"en", "fr"}, supportedLocales())
+}
@@ -0,0 +1,51 @@
+package slackbot
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// LogFormatText is the default, human readable, log format
+	LogFormatText = "text"
+	// LogFormatJSON logs one JSON object per line, with the structured fields as keys
+	LogFormatJSON = "json"
+)
+
+// SetLogFormat configures the format of the logs, an empty format keeps the one configured by the environment
+func SetLogFormat(format string) error {
+	// make sure the logger is initialized first, otherwise it would reset the formatter later on
+	log.Logger()
+	switch format {
+	case "":
+	case LogFormatText:
+		logrus.SetFormatter(log.NewJenkinsXTextFormat())
+	case LogFormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("invalid log format %s, must be one of %s or %s", format, LogFormatText, LogFormatJSON)
+	}
+	return nil
+}
+
+// activityLogger returns a logger with the fields identifying the activity, so that the logs can be aggregated per
+// repository or status
+func activityLogger(activity *record.ActivityRecord) *logrus.Entry {
+	return log.Logger().WithFields(logrus.Fields{
+		"activity": activity.Name,
+		"owner":    activity.Owner,
+		"repo":     activity.Repo,
+		"status":   string(pipelineStatus(activity)),
+	})
+}
+
+// messageLogger returns a logger with the fields identifying a message about the activity
+func messageLogger(activity *record.ActivityRecord, channel string, messageType string) *logrus.Entry {
+	return activityLogger(activity).WithFields(logrus.Fields{
+		"channel":     channel,
+		"messageType": messageType,
+	})
+}
@@ -0,0 +1,45 @@
+package slackbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetLogFormat(t *testing.T) {
+	defer logrus.SetFormatter(log.NewJenkinsXTextFormat())
+
+	err := SetLogFormat("xml")
+	assert.Error(t, err)
+
+	err = SetLogFormat(LogFormatJSON)
+	require.NoError(t, err)
+	act := &record.ActivityRecord{
+		Name:   "cheese-wine-pr-1-1",
+		Owner:  "cheese",
+		Repo:   "wine",
+		Status: "Running",
+	}
+	out := logrus.StandardLogger().Out
+	var buf bytes.Buffer
+	logrus.SetOutput(&buf)
+	messageLogger(act, "#cheese", pipelineMessageType).Info("Channel message sent")
+	logrus.SetOutput(out)
+
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(buf.Bytes(), &fields)
+	require.NoError(t, err)
+	assert.Equal(t, "cheese-wine-pr-1-1", fields["activity"])
+	assert.Equal(t, "cheese", fields["owner"])
+	assert.Equal(t, "wine", fields["repo"])
+	assert.Equal(t, "Running", fields["status"])
+	assert.Equal(t, "#cheese", fields["channel"])
+	assert.Equal(t, "pipeline", fields["messageType"])
+	assert.Equal(t, "Channel message sent", fields["msg"])
+}
@@ -0,0 +1,57 @@
+package slackbot
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// LogURLRewrite transforms the URL of build logs in a storage bucket into a public HTTPS URL
+type LogURLRewrite func(u *url.URL) string
+
+// DefaultLogURLRewrites are the rewrites of the build logs URLs, keyed by scheme, used when
+// SlackBotOptions.LogURLRewrites is nil
+var DefaultLogURLRewrites = map[string]LogURLRewrite{
+	"gs":     GCSLogURLRewrite,
+	"s3":     S3LogURLRewrite,
+	"azblob": AzureBlobLogURLRewrite(os.Getenv("AZURE_STORAGE_ACCOUNT")),
+}
+
+// GCSLogURLRewrite rewrites gs://bucket/path URLs to the Google Cloud Storage browser
+func GCSLogURLRewrite(u *url.URL) string {
+	return fmt.Sprintf("https://storage.cloud.google.com/%s%s", u.Host, u.EscapedPath())
+}
+
+// S3LogURLRewrite rewrites s3://bucket/path URLs to the virtual hosted style URL of the object
+func S3LogURLRewrite(u *url.URL) string {
+	return fmt.Sprintf("https://%s.s3.amazonaws.com%s", u.Host, u.EscapedPath())
+}
+
+// AzureBlobLogURLRewrite returns a rewrite of azblob://container/path URLs to the blob in the given storage account.
+// URLs are left unchanged if the account is empty, as they can't be resolved.
+func AzureBlobLogURLRewrite(account string) LogURLRewrite {
+	return func(u *url.URL) string {
+		if account == "" {
+			return u.String()
+		}
+		return fmt.Sprintf("https://%s.blob.core.windows.net/%s%s", account, u.Host, u.EscapedPath())
+	}
+}
+
+// logURL returns the public URL of the build logs, URLs with an unknown scheme are returned unchanged
+func (o *SlackBotOptions) logURL(logURL string) string {
+	rewrites := o.LogURLRewrites
+	if rewrites == nil {
+		rewrites = DefaultLogURLRewrites
+	}
+	u, err := url.Parse(logURL)
+	if err != nil || u.Host == "" {
+		return logURL
+	}
+	rewrite, ok := rewrites[strings.ToLower(u.Scheme)]
+	if !ok || rewrite == nil {
+		return logURL
+	}
+	return rewrite(u)
+}
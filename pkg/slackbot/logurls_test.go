@@ -0,0 +1,49 @@
+package slackbot
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackBotOptions_logURL(t *testing.T) {
+	o := &SlackBotOptions{
+		LogURLRewrites: map[string]LogURLRewrite{
+			"gs":     GCSLogURLRewrite,
+			"s3":     S3LogURLRewrite,
+			"azblob": AzureBlobLogURLRewrite("cheese"),
+		},
+	}
+	tests := []struct {
+		name   string
+		logURL string
+		want   string
+	}{
+		{"gs", "gs://jx-logs/jenkins-x/logs/wine/master/1.log",
+			"https://storage.cloud.google.com/jx-logs/jenkins-x/logs/wine/master/1.log"},
+		{"s3", "s3://jx-logs/jenkins-x/logs/wine/master/1.log",
+			"https://jx-logs.s3.amazonaws.com/jenkins-x/logs/wine/master/1.log"},
+		{"azblob", "azblob://jx-logs/jenkins-x/logs/wine/master/1.log",
+			"https://cheese.blob.core.windows.net/jx-logs/jenkins-x/logs/wine/master/1.log"},
+		{"https", "https://logs.example.com/wine/1.log", "https://logs.example.com/wine/1.log"},
+		{"unknown", "minio://jx-logs/wine/1.log", "minio://jx-logs/wine/1.log"},
+		{"invalid", "gs://%zz", "gs://%zz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, o.logURL(tt.logURL))
+		})
+	}
+}
+
+func TestAzureBlobLogURLRewrite_withoutAccount(t *testing.T) {
+	u, err := url.Parse("azblob://jx-logs/wine/1.log")
+	assert.NoError(t, err)
+	assert.Equal(t, "azblob://jx-logs/wine/1.log", AzureBlobLogURLRewrite("")(u))
+}
+
+func TestSlackBotOptions_logURLDefaults(t *testing.T) {
+	o := &SlackBotOptions{}
+	assert.Equal(t, "https://storage.cloud.google.com/jx-logs/1.log", o.logURL("gs://jx-logs/1.log"))
+}
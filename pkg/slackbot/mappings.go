@@ -1,5 +1,7 @@
 package slackbot
 
+import "strings"
+
 var (
 	// contains mappings of known pipeline steps to user friendly slack descriptions
 	slackMessageMapping = map[string]string{
@@ -14,3 +16,32 @@ func getUserFriendlyMapping(stepName string) string {
 	}
 	return stepName
 }
+
+// stageType returns the known pipeline stage type a step name starts with, e.g. build for "build make linux", or an
+// empty string if it doesn't start with a known stage type
+func stageType(stepName string) string {
+	fields := strings.Fields(stepName)
+	if len(fields) == 0 {
+		return ""
+	}
+	for _, t := range knownPipelineStageTypes {
+		if strings.EqualFold(t, fields[0]) {
+			return t
+		}
+	}
+	return ""
+}
+
+// stageEmoji returns the emoji configured for the stage type of the step name, or an empty string if there is none
+func (o *SlackBotOptions) stageEmoji(stepName string) string {
+	t := stageType(stepName)
+	if t == "" {
+		return ""
+	}
+	for k, emoji := range o.StageEmojis {
+		if strings.EqualFold(k, t) {
+			return emoji
+		}
+	}
+	return ""
+}
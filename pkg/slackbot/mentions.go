@@ -0,0 +1,86 @@
+package slackbot
+
+import (
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/users"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+)
+
+const (
+	// MentionPolicyAlways mentions the reviewers in the review messages and the author when the pipeline fails
+	MentionPolicyAlways = "always"
+	// MentionPolicyNever never mentions anyone, the users are only named
+	MentionPolicyNever = "never"
+	// MentionPolicyOnFailure only mentions the reviewers once the pipeline of the pull request failed, and the author
+	// when it fails
+	MentionPolicyOnFailure = "on-failure"
+)
+
+// mentionPolicies are the supported values of the MentionPolicy of the modes
+var mentionPolicies = []string{MentionPolicyAlways, MentionPolicyNever, MentionPolicyOnFailure}
+
+// listsReviewers returns true if the review messages of the mode list the requested reviewers
+func listsReviewers(cfg slackapp.SlackBotMode) bool {
+	return cfg.NotifyReviewers || cfg.MentionPolicy == MentionPolicyAlways
+}
+
+// mentionsReviewers returns true if the review message for the activity mentions the reviewers and the author,
+// rather than only naming them. With NotifyReviewersAfterGreen nobody is mentioned until the build succeeds.
+func mentionsReviewers(cfg slackapp.SlackBotMode, activity *record.ActivityRecord) bool {
+	if cfg.NotifyReviewersAfterGreen && pipelineStatus(activity) != v1alpha1.SuccessState {
+		return false
+	}
+	switch cfg.MentionPolicy {
+	case MentionPolicyNever:
+		return false
+	case MentionPolicyOnFailure:
+		return isFailedState(pipelineStatus(activity))
+	}
+	return true
+}
+
+// mentionsAuthorOnFailure returns true if the pipeline messages of the mode mention the author of the pull request
+// when the pipeline fails
+func mentionsAuthorOnFailure(cfg slackapp.SlackBotMode) bool {
+	switch cfg.MentionPolicy {
+	case MentionPolicyAlways, MentionPolicyOnFailure:
+		return true
+	case MentionPolicyNever:
+		return false
+	}
+	return cfg.MentionAuthorOnFailure
+}
+
+// linkUser names the user, linking to their profile if they have one, without mentioning them. The Git user is
+// used when the user has no profile or couldn't be resolved
+func linkUser(user *jenkinsv1.User, gitUser *gits.GitUser) string {
+	if user != nil && user.Spec.Name != "" && user.Spec.URL != "" {
+		return link(user.Spec.Name, user.Spec.URL)
+	}
+	if gitUser != nil && gitUser.Login != "" && gitUser.URL != "" {
+		return link(gitUser.Login, gitUser.URL)
+	}
+	if user != nil && user.Spec.Name != "" {
+		return user.Spec.Name
+	}
+	if user != nil && user.Spec.Login != "" {
+		return user.Spec.Login
+	}
+	return gitLogin(gitUser)
+}
+
+// linkReviewer names the requested reviewer, a user or a team, without mentioning them
+func (o *SlackBotOptions) linkReviewer(reviewer *gits.GitUser, resolver *users.GitUserResolver) string {
+	if team, ok := o.teamReviewer(reviewer); ok {
+		return team
+	}
+	user, err := resolver.Resolve(reviewer)
+	if err != nil {
+		// the reviewer is named by their login
+		user = nil
+	}
+	return linkUser(user, reviewer)
+}
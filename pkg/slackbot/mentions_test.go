@@ -0,0 +1,143 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mentionPolicy(t *testing.T) {
+	failed := &record.ActivityRecord{Status: v1alpha1.FailureState}
+	succeeded := &record.ActivityRecord{Status: v1alpha1.SuccessState}
+	tests := []struct {
+		name                        string
+		cfg                         slackapp.SlackBotMode
+		wantListsReviewers          bool
+		wantMentionsOnSuccess       bool
+		wantMentionsOnFailure       bool
+		wantMentionsAuthorOnFailure bool
+	}{
+		{name: "no policy", cfg: slackapp.SlackBotMode{NotifyReviewers: true, MentionAuthorOnFailure: true},
+			wantListsReviewers: true, wantMentionsOnSuccess: true, wantMentionsOnFailure: true,
+			wantMentionsAuthorOnFailure: true},
+		{name: "no policy nor notifications", cfg: slackapp.SlackBotMode{},
+			wantMentionsOnSuccess: true, wantMentionsOnFailure: true},
+		{name: "always", cfg: slackapp.SlackBotMode{MentionPolicy: MentionPolicyAlways},
+			wantListsReviewers: true, wantMentionsOnSuccess: true, wantMentionsOnFailure: true,
+			wantMentionsAuthorOnFailure: true},
+		{name: "never", cfg: slackapp.SlackBotMode{NotifyReviewers: true, MentionAuthorOnFailure: true,
+			MentionPolicy: MentionPolicyNever}, wantListsReviewers: true},
+		{name: "on failure", cfg: slackapp.SlackBotMode{NotifyReviewers: true, MentionPolicy: MentionPolicyOnFailure},
+			wantListsReviewers: true, wantMentionsOnFailure: true, wantMentionsAuthorOnFailure: true},
+		{name: "after green", cfg: slackapp.SlackBotMode{NotifyReviewers: true, NotifyReviewersAfterGreen: true},
+			wantListsReviewers: true, wantMentionsOnSuccess: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantListsReviewers, listsReviewers(tt.cfg))
+			assert.Equal(t, tt.wantMentionsOnSuccess, mentionsReviewers(tt.cfg, succeeded))
+			assert.Equal(t, tt.wantMentionsOnFailure, mentionsReviewers(tt.cfg, failed))
+			assert.Equal(t, tt.wantMentionsAuthorOnFailure, mentionsAuthorOnFailure(tt.cfg))
+		})
+	}
+}
+
+func Test_mentionsReviewersAfterGreen(t *testing.T) {
+	cfg := slackapp.SlackBotMode{NotifyReviewers: true, NotifyReviewersAfterGreen: true}
+	for _, status := range []v1alpha1.PipelineState{v1alpha1.PendingState, v1alpha1.RunningState,
+		v1alpha1.FailureState, v1alpha1.AbortedState} {
+		assert.False(t, mentionsReviewers(cfg, &record.ActivityRecord{Status: status}),
+			"the reviewers shouldn't be mentioned while the build is %s", status)
+	}
+	assert.True(t, mentionsReviewers(cfg, &record.ActivityRecord{Status: v1alpha1.SuccessState}))
+
+	// the mention policy still applies once the build succeeded
+	cfg.MentionPolicy = MentionPolicyNever
+	assert.False(t, mentionsReviewers(cfg, &record.ActivityRecord{Status: v1alpha1.SuccessState}))
+}
+
+func TestSlackBotOptions_ReviewRequestMessage_notifyReviewersAfterGreen(t *testing.T) {
+	provider := &draftGitProvider{GitProvider: &usersGitProvider{}, pr: &gits.GitPullRequest{
+		URL:                "https://github.com/jenkins-x-labs/jxl/pull/83",
+		Title:              "Add cheddar",
+		Author:             &gits.GitUser{Login: "brie", URL: "https://github.com/brie"},
+		RequestedReviewers: []*gits.GitUser{{Login: "cheddar", URL: "https://github.com/cheddar"}},
+	}}
+	clients, _ := newReviewClients(nil, provider)
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		GlobalClients: clients,
+		SlackClient:   client,
+		Timestamps:    make(map[string]map[string]*MessageReference),
+		UserMappings:  map[string]string{"brie": "U1", "cheddar": "U2"},
+		PullRequests: []slackapp.SlackBotMode{
+			{Channel: "#cheese", NotifyReviewers: true, NotifyReviewersAfterGreen: true},
+		},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	setStatus := func(status v1alpha1.PipelineState) {
+		act.Status = status
+		for _, stage := range act.Stages {
+			stage.Status = status
+		}
+	}
+	setStatus(v1alpha1.RunningState)
+
+	require.NoError(t, o.ReviewRequestMessage(act))
+	posts := client.callsTo("chat.postMessage")
+	require.Len(t, posts, 1)
+	assert.NotContains(t, posts[0].Values.Get("attachments"), "@U2")
+
+	// once green the message is updated with the mentions, which only notify the reviewers in a threaded reply
+	setStatus(v1alpha1.SuccessState)
+	require.NoError(t, o.ReviewRequestMessage(act))
+	updates := client.callsTo("chat.update")
+	require.Len(t, updates, 1)
+	assert.Contains(t, updates[0].Values.Get("attachments"), "@U2")
+	posts = client.callsTo("chat.postMessage")
+	require.Len(t, posts, 2)
+	assert.Equal(t, o.messageReference("#cheese", act.Name).Timestamp, posts[1].Values.Get("thread_ts"))
+	assert.Contains(t, posts[1].Values.Get("text"), "<@U2>")
+
+	// the reviewers are only notified once
+	require.NoError(t, o.ReviewRequestMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 2)
+}
+
+func TestSlackBotOptions_createReviewersMessage_withoutMentions(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	clients, resolver := newReviewClients(nil, &usersGitProvider{})
+	o := &SlackBotOptions{
+		GlobalClients: clients,
+		UserMappings:  map[string]string{"brie": "U1", "cheddar": "U2"},
+	}
+	pr := &gits.GitPullRequest{
+		URL:                "https://github.com/jenkins-x-labs/jxl/pull/83",
+		Title:              "Add cheddar",
+		Author:             &gits.GitUser{Login: "brie", URL: "https://github.com/brie"},
+		RequestedReviewers: []*gits.GitUser{{Login: "cheddar", URL: "https://github.com/cheddar"}},
+	}
+
+	attachments, reviewers, _, err := o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{listReviewers: true, mention: true})
+	require.NoError(t, err)
+	assert.Contains(t, attachments[0].Text, "<@U2> please review")
+	assert.Contains(t, attachments[0].Text, "by <@U1>")
+	assert.Len(t, reviewers, 1)
+
+	// the users are still named, but nobody is notified
+	attachments, reviewers, _, err = o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{listReviewers: true})
+	require.NoError(t, err)
+	assert.Contains(t, attachments[0].Text, "<https://github.com/cheddar|cheddar> please review")
+	assert.Contains(t, attachments[0].Text, "by <https://github.com/brie|brie>")
+	assert.NotContains(t, attachments[0].Text, "<@")
+	assert.Empty(t, reviewers)
+}
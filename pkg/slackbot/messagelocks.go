@@ -0,0 +1,41 @@
+package slackbot
+
+import "sync"
+
+// messageLock serializes the posts of a message, counting the posts holding or waiting for it so that it can be
+// forgotten once there are none
+type messageLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// lockMessage locks the message of the activity in the channel until the returned function is first called, so that
+// a concurrent post of the same message sees the reference stored by the first one
+func (o *SlackBotOptions) lockMessage(channel string, name string) func() {
+	key := channel + "/" + name
+	o.messageLocksLock.Lock()
+	if o.messageLocks == nil {
+		o.messageLocks = make(map[string]*messageLock)
+	}
+	lock := o.messageLocks[key]
+	if lock == nil {
+		lock = &messageLock{}
+		o.messageLocks[key] = lock
+	}
+	lock.waiters++
+	o.messageLocksLock.Unlock()
+
+	lock.Lock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			lock.Unlock()
+			o.messageLocksLock.Lock()
+			lock.waiters--
+			if lock.waiters == 0 {
+				delete(o.messageLocks, key)
+			}
+			o.messageLocksLock.Unlock()
+		})
+	}
+}
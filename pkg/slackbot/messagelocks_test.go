@@ -0,0 +1,40 @@
+package slackbot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_postMessage_concurrentEvents(t *testing.T) {
+	// the calls take long enough for both events to be handled before the first message is posted
+	client := &fakeSlackClient{delay: 50 * time.Millisecond}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, text := range []string{"build pending", "build running"} {
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			errs[i] = o.postMessage("#cheese", false, pullRequestReviewMessageType, act, nil,
+				[]slack.Attachment{{Text: text}}, nil, true)
+		}(i, text)
+	}
+	wg.Wait()
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+
+	assert.Len(t, client.callsTo("chat.postMessage"), 1, "a single message should be posted")
+	assert.Len(t, client.callsTo("chat.update"), 1, "the second event should update the message")
+	assert.Empty(t, o.messageLocks, "the locks should be released")
+}
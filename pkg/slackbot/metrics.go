@@ -0,0 +1,69 @@
+package slackbot
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	metricsNamespace = "slackbot"
+)
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	messagesCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_created_total",
+		Help:      "The number of Slack messages created",
+	}, []string{"type"})
+
+	messagesUpdated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_updated_total",
+		Help:      "The number of Slack messages updated",
+	}, []string{"type"})
+
+	messagesDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_deleted_total",
+		Help:      "The number of Slack messages deleted",
+	}, []string{"type"})
+
+	messagesFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_failed_total",
+		Help:      "The number of Slack messages which failed to be sent",
+	}, []string{"type"})
+
+	unresolvedSlackUsers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "unresolved_slack_users_total",
+		Help:      "The number of times a Git user couldn't be resolved to a Slack user",
+	}, []string{"provider"})
+
+	slackAPILatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "slack_api_call_duration_seconds",
+		Help:      "The latency of Slack API calls",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(messagesCreated, messagesUpdated, messagesDeleted, messagesFailed,
+		unresolvedSlackUsers, slackAPILatency)
+}
+
+// MetricsHandler returns the handler which serves the Prometheus metrics of the bot
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
+
+// observeSlackAPICall records the latency of a call to the Slack API method which started at start
+func observeSlackAPICall(method string, start time.Time) {
+	slackAPILatency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,48 @@
+package slackbot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_postMessageMetrics(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	created := testutil.ToFloat64(messagesCreated.WithLabelValues(pipelineMessageType))
+	updated := testutil.ToFloat64(messagesUpdated.WithLabelValues(pipelineMessageType))
+	failed := testutil.ToFloat64(messagesFailed.WithLabelValues(pipelineMessageType))
+
+	for i := 0; i < 2; i++ {
+		attachments := []slack.Attachment{{Text: fmt.Sprintf("update %d", i)}}
+		err = o.postMessage("#test", false, pipelineMessageType, act, nil, attachments, nil, true)
+		require.NoError(t, err)
+	}
+	recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+		w.WriteHeader(http.StatusBadRequest)
+		return true
+	}
+	err = o.postMessage("#test", false, pipelineMessageType, act, nil, []slack.Attachment{{Text: "failed"}}, nil, true)
+	require.Error(t, err)
+
+	assert.Equal(t, created+1, testutil.ToFloat64(messagesCreated.WithLabelValues(pipelineMessageType)))
+	assert.Equal(t, updated+1, testutil.ToFloat64(messagesUpdated.WithLabelValues(pipelineMessageType)))
+	assert.Equal(t, failed+1, testutil.ToFloat64(messagesFailed.WithLabelValues(pipelineMessageType)))
+
+	w := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, w.Body.String(), "slackbot_slack_api_call_duration_seconds")
+}
@@ -0,0 +1,112 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+// failureStreak tracks the consecutive failed builds of a pipeline in a channel
+type failureStreak struct {
+	failures int
+	// lastBuild is the last build counted, so that the updates of a build are only counted once
+	lastBuild string
+	// pinned is the message pinned for the failures, nil if none is
+	pinned *MessageReference
+}
+
+// pinOnSustainedFailure pins the message posted for the activity in the channel once the pipeline failed for
+// PinAfterFailures consecutive builds, replacing the message pinned for the previous failure. The pinned message is
+// unpinned once the pipeline succeeds. The failures are only tracked in memory, so a restart starts counting again.
+func (o *SlackBotOptions) pinOnSustainedFailure(channel string, activity *record.ActivityRecord) error {
+	if o.PinAfterFailures <= 0 {
+		return nil
+	}
+	status := pipelineStatus(activity)
+	if status != v1alpha1.FailureState && status != v1alpha1.SuccessState {
+		return nil
+	}
+	messageRef := o.messageReference(channel, activity.Name)
+	if messageRef == nil || messageRef.Timestamp == "" {
+		return nil
+	}
+	key := failureStreakKey(channel, activity)
+
+	var pin, unpin *MessageReference
+	o.failureStreaksLock.Lock()
+	if o.failureStreaks == nil {
+		o.failureStreaks = make(map[string]*failureStreak)
+	}
+	streak := o.failureStreaks[key]
+	if streak == nil {
+		streak = &failureStreak{}
+		o.failureStreaks[key] = streak
+	}
+	if status == v1alpha1.FailureState {
+		if streak.lastBuild != activity.BuildIdentifier {
+			streak.failures++
+			streak.lastBuild = activity.BuildIdentifier
+		}
+		if streak.failures >= o.PinAfterFailures &&
+			(streak.pinned == nil || streak.pinned.Timestamp != messageRef.Timestamp) {
+			pin, unpin = messageRef, streak.pinned
+		}
+	} else {
+		unpin = streak.pinned
+		delete(o.failureStreaks, key)
+	}
+	o.failureStreaksLock.Unlock()
+
+	if unpin != nil {
+		err := o.unpinMessage(unpin)
+		if err != nil {
+			return errors.Wrapf(err, "unpinning the previous failure of %s", activity.Name)
+		}
+	}
+	if pin == nil {
+		return nil
+	}
+	err := o.pinMessage(pin)
+	if err != nil {
+		return errors.Wrapf(err, "pinning the message for %s after %d failures", activity.Name, streak.failures)
+	}
+	o.failureStreaksLock.Lock()
+	streak.pinned = pin
+	o.failureStreaksLock.Unlock()
+	return nil
+}
+
+// failureStreakKey returns the key the failures of the pipeline of the activity are tracked by in the channel
+func failureStreakKey(channel string, activity *record.ActivityRecord) string {
+	return fmt.Sprintf("%s %s/%s/%s/%s", channel, activity.Owner, activity.Repo, activity.Branch, activity.Context)
+}
+
+func (o *SlackBotOptions) pinMessage(ref *MessageReference) error {
+	return o.postWithRetry(context.Background(), "pinning message", func(ctx context.Context) error {
+		defer observeSlackAPICall("pins.add", time.Now())
+		err := o.slackClientFor(ref.Workspace).AddPinContext(ctx, ref.ChannelID, slack.NewRefToMessage(ref.ChannelID,
+			ref.Timestamp))
+		if err != nil && err.Error() == "already_pinned" {
+			return nil
+		}
+		return err
+	})
+}
+
+func (o *SlackBotOptions) unpinMessage(ref *MessageReference) error {
+	return o.postWithRetry(context.Background(), "unpinning message", func(ctx context.Context) error {
+		defer observeSlackAPICall("pins.remove", time.Now())
+		err := o.slackClientFor(ref.Workspace).RemovePinContext(ctx, ref.ChannelID, slack.NewRefToMessage(ref.ChannelID,
+			ref.Timestamp))
+		if err != nil && (err.Error() == "no_pin" || err.Error() == "message_not_found") {
+			// the message was already unpinned, or deleted
+			return nil
+		}
+		return err
+	})
+}
@@ -0,0 +1,87 @@
+package slackbot
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_pinOnSustainedFailure(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient:      client,
+		Timestamps:       make(map[string]map[string]*MessageReference),
+		PinAfterFailures: 2,
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+	channel := "#cheese"
+	build := func(number, timestamp string, status v1alpha1.PipelineState) {
+		act.Name = "jenkins-x-labs-jxl-master-" + number
+		act.BuildIdentifier = number
+		act.Status = status
+		o.storeMessageReference(channel, act.Name, &MessageReference{ChannelID: "C1", Timestamp: timestamp})
+		err := o.pinOnSustainedFailure(channel, act)
+		require.NoError(t, err)
+	}
+
+	build("1", "1.000100", v1alpha1.FailureState)
+	assert.Len(t, client.callsTo("pins.add"), 0)
+
+	// the updates of the same build are only counted once
+	build("1", "1.000100", v1alpha1.FailureState)
+	assert.Len(t, client.callsTo("pins.add"), 0)
+
+	build("2", "2.000100", v1alpha1.RunningState)
+	assert.Len(t, client.callsTo("pins.add"), 0)
+	build("2", "2.000100", v1alpha1.FailureState)
+	pins := client.callsTo("pins.add")
+	require.Len(t, pins, 1)
+	assert.Equal(t, "C1", pins[0].Values.Get("channel"))
+	assert.Equal(t, "2.000100", pins[0].Values.Get("timestamp"))
+
+	// the latest failure replaces the pinned message
+	build("3", "3.000100", v1alpha1.FailureState)
+	pins = client.callsTo("pins.add")
+	require.Len(t, pins, 2)
+	assert.Equal(t, "3.000100", pins[1].Values.Get("timestamp"))
+	unpins := client.callsTo("pins.remove")
+	require.Len(t, unpins, 1)
+	assert.Equal(t, "2.000100", unpins[0].Values.Get("timestamp"))
+
+	// a success unpins the message and starts counting again
+	build("4", "4.000100", v1alpha1.SuccessState)
+	unpins = client.callsTo("pins.remove")
+	require.Len(t, unpins, 2)
+	assert.Equal(t, "3.000100", unpins[1].Values.Get("timestamp"))
+	build("5", "5.000100", v1alpha1.FailureState)
+	assert.Len(t, client.callsTo("pins.add"), 2)
+
+	// other branches are counted separately
+	act.Branch = "feature"
+	build("6", "6.000100", v1alpha1.FailureState)
+	assert.Len(t, client.callsTo("pins.add"), 2)
+}
+
+func TestSlackBotOptions_pinOnSustainedFailure_disabled(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	act.Status = v1alpha1.FailureState
+	o.storeMessageReference("#cheese", act.Name, &MessageReference{ChannelID: "C1", Timestamp: "1.000100"})
+	for i := 0; i < 3; i++ {
+		act.BuildIdentifier = strconv.Itoa(i)
+		require.NoError(t, o.pinOnSustainedFailure("#cheese", act))
+	}
+	assert.Len(t, client.callsTo("pins.add"), 0)
+}
@@ -0,0 +1,115 @@
+package slackbot
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/users"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+)
+
+// pullRequestCacheEntry is a pull request fetched from the Git provider, which is reused until it expires
+type pullRequestCacheEntry struct {
+	pr       *gits.GitPullRequest
+	resolver *users.GitUserResolver
+	expires  time.Time
+	// activity and status are the activity the pull request was fetched for and its status at the time
+	activity string
+	status   v1alpha1.PipelineState
+}
+
+// pullRequestCacheKey returns the key of the pull request of the activity in the cache
+func pullRequestCacheKey(activity *record.ActivityRecord, prn int) string {
+	details := createPipelineDetails(activity)
+	return fmt.Sprintf("%s/%s/%d", details.GitOwner, details.GitRepository, prn)
+}
+
+// cachedPullRequest returns the pull request fetched for a previous event of the same pull request, or nil if there
+// is none or it expired. Once the pipeline reaches a terminal state the pull request is fetched again, as its labels
+// and reviewers are shown in the final messages, then reused for the other messages about the same state.
+func (o *SlackBotOptions) cachedPullRequest(key string, activity *record.ActivityRecord) (*gits.GitPullRequest,
+	*users.GitUserResolver) {
+	if o.PRCacheTTL <= 0 {
+		return nil, nil
+	}
+	o.prCacheLock.Lock()
+	defer o.prCacheLock.Unlock()
+	entry, ok := o.prCache[key]
+	if !ok {
+		return nil, nil
+	}
+	status := pipelineStatus(activity)
+	terminal := isTerminalState(status) && (entry.activity != activity.Name || entry.status != status)
+	if terminal || time.Now().After(entry.expires) {
+		delete(o.prCache, key)
+		return nil, nil
+	}
+	return entry.pr, entry.resolver
+}
+
+// cachePullRequest stores the pull request fetched for the activity, forgetting the entries which expired
+func (o *SlackBotOptions) cachePullRequest(key string, activity *record.ActivityRecord, pr *gits.GitPullRequest,
+	resolver *users.GitUserResolver) {
+	if o.PRCacheTTL <= 0 || pr == nil {
+		return
+	}
+	now := time.Now()
+	o.prCacheLock.Lock()
+	defer o.prCacheLock.Unlock()
+	if o.prCache == nil {
+		o.prCache = make(map[string]*pullRequestCacheEntry)
+	}
+	for k, entry := range o.prCache {
+		if now.After(entry.expires) {
+			delete(o.prCache, k)
+		}
+	}
+	o.prCache[key] = &pullRequestCacheEntry{
+		pr:       pr,
+		resolver: resolver,
+		expires:  now.Add(o.PRCacheTTL),
+		activity: activity.Name,
+		status:   pipelineStatus(activity),
+	}
+}
+
+// createGitProviderForURL creates the Git provider for the repository
+func (c *GlobalClients) createGitProviderForURL(gitURL string) (gits.GitProvider, *gits.GitRepository, error) {
+	if c.gitProviderForURL != nil {
+		return c.gitProviderForURL(gitURL)
+	}
+	return c.CommonOptions.CreateGitProviderForURLWithoutKind(gitURL)
+}
+
+// gitKind returns the kind of the Git provider hosting the URL, such as gitlab. The kind is only guessed from the URL
+// when there is no Git provider for it
+func (c *GlobalClients) gitKind(gitURL string) string {
+	if c == nil || (c.gitProviderForURL == nil && c.CommonOptions == nil) {
+		return gitKindFromURL(gitURL)
+	}
+	host := ""
+	if u, err := url.Parse(gitURL); err == nil {
+		host = u.Host
+	}
+	c.gitKindsLock.Lock()
+	kind, ok := c.gitKinds[host]
+	c.gitKindsLock.Unlock()
+	if ok && host != "" {
+		return kind
+	}
+	provider, _, err := c.createGitProviderForURL(gitURL)
+	if err != nil || provider == nil {
+		return gitKindFromURL(gitURL)
+	}
+	kind = provider.Kind()
+	c.gitKindsLock.Lock()
+	if c.gitKinds == nil {
+		c.gitKinds = make(map[string]string)
+	}
+	c.gitKinds[host] = kind
+	c.gitKindsLock.Unlock()
+	return kind
+}
@@ -0,0 +1,72 @@
+package slackbot
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingGitProvider counts the pull requests fetched, the other methods of the provider aren't implemented
+type countingGitProvider struct {
+	gits.GitProvider
+	calls int32
+}
+
+func (p *countingGitProvider) GetPullRequest(owner string, repo *gits.GitRepository,
+	number int) (*gits.GitPullRequest, error) {
+	atomic.AddInt32(&p.calls, 1)
+	return &gits.GitPullRequest{Owner: owner, Repo: repo.Name, Number: &number}, nil
+}
+
+func TestSlackBotOptions_getPullRequestCache(t *testing.T) {
+	provider := &countingGitProvider{}
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			gitProviderForURL: func(gitURL string) (gits.GitProvider, *gits.GitRepository, error) {
+				gitInfo, err := gits.ParseGitURL(gitURL)
+				return provider, gitInfo, err
+			},
+		},
+		PRCacheTTL: time.Minute,
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	act.Status = v1alpha1.RunningState
+	ctx := context.Background()
+
+	// two rapid events on the same pull request only fetch it once
+	pr, _, err := o.getPullRequest(ctx, act)
+	require.NoError(t, err)
+	require.NotNil(t, pr)
+	cached, _, err := o.getPullRequest(ctx, act)
+	require.NoError(t, err)
+	assert.Same(t, pr, cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.calls))
+
+	// the pull request is fetched again once the pipeline completes, then reused for the same state
+	act.Status = v1alpha1.SuccessState
+	_, _, err = o.getPullRequest(ctx, act)
+	require.NoError(t, err)
+	_, _, err = o.getPullRequest(ctx, act)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.calls))
+
+	// and once it expires
+	o.prCache[pullRequestCacheKey(act, *pr.Number)].expires = time.Now().Add(-time.Second)
+	_, _, err = o.getPullRequest(ctx, act)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&provider.calls))
+
+	// without a TTL the pull request is fetched for every event
+	o.PRCacheTTL = 0
+	_, _, err = o.getPullRequest(ctx, act)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&provider.calls))
+}
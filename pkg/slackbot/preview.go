@@ -0,0 +1,20 @@
+package slackbot
+
+import (
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+)
+
+// previewApplicationURL returns the URL of the application deployed by the last preview step of the activity, or an
+// empty string if there is none
+func previewApplicationURL(pa *jenkinsv1.PipelineActivity) string {
+	answer := ""
+	if pa == nil {
+		return answer
+	}
+	for _, step := range pa.Spec.Steps {
+		if step.Preview != nil && step.Preview.ApplicationURL != "" {
+			answer = step.Preview.ApplicationURL
+		}
+	}
+	return answer
+}
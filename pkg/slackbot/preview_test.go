@@ -0,0 +1,35 @@
+package slackbot
+
+import (
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_previewApplicationURL(t *testing.T) {
+	pa := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jenkins-x-labs-slack-pr-83-1",
+			Namespace: "jx",
+		},
+		Spec: jenkinsv1.PipelineActivitySpec{
+			Steps: []jenkinsv1.PipelineActivityStep{
+				{Kind: jenkinsv1.ActivityStepKindTypeStage, Stage: &jenkinsv1.StageActivityStep{}},
+				{Kind: jenkinsv1.ActivityStepKindTypePreview, Preview: &jenkinsv1.PreviewActivityStep{
+					ApplicationURL: "http://slack-jx-jenkins-x-labs-slack-pr-83.example.com",
+				}},
+			},
+		},
+	}
+	noPreview := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jenkins-x-labs-slack-pr-84-1",
+			Namespace: "jx",
+		},
+	}
+	assert.Equal(t, "http://slack-jx-jenkins-x-labs-slack-pr-83.example.com", previewApplicationURL(pa))
+	assert.Empty(t, previewApplicationURL(noPreview))
+	assert.Empty(t, previewApplicationURL(nil))
+}
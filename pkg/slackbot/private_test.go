@@ -0,0 +1,54 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_createPipelineMessage_private(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	o := &SlackBotOptions{ShowCommitInfo: true}
+
+	attachments, _, err := o.createPipelineMessage(act, nil, nil, o.Statuses, true)
+	require.NoError(t, err)
+	require.NotEmpty(t, attachments)
+	attachment := attachments[0]
+	assert.Contains(t, attachment.Title, "<https://github.com/jenkins-x-labs/jxl.git|private repository>")
+	assert.NotContains(t, attachment.Title, "|jxl>")
+	assert.NotContains(t, attachment.Fallback, "github.com/jenkins-x-labs/jxl")
+	assert.Empty(t, attachment.Footer, "the commit is not shown")
+
+	// the repository is still linked for those with access
+	var urls []string
+	for _, action := range attachment.Actions {
+		urls = append(urls, action.URL)
+	}
+	assert.Contains(t, urls, "https://github.com/jenkins-x-labs/jxl.git")
+}
+
+func TestSlackBotOptions_createReviewersMessage_private(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	clients, resolver := newReviewClients(nil, nil)
+	o := &SlackBotOptions{GlobalClients: clients}
+	pr := &gits.GitPullRequest{
+		URL:   "https://github.com/jenkins-x-labs/jxl/pull/83",
+		Title: "Add secret cheddar",
+	}
+
+	attachments, _, _, err := o.createReviewersMessage(act, nil, pr, resolver, o.Statuses,
+		reviewMessageOptions{mention: true, private: true})
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	text := attachments[0].Text
+	assert.Contains(t, text, "<https://github.com/jenkins-x-labs/jxl/pull/83|Pull Request #83>")
+	assert.Contains(t, text, "<https://github.com/jenkins-x-labs/jxl.git|private repository>")
+	assert.NotContains(t, text, "secret cheddar")
+	assert.NotContains(t, text, "|jxl>")
+	assert.NotContains(t, attachments[0].Fallback, "secret cheddar")
+}
@@ -0,0 +1,200 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/jx"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const promotionMessageType = "promotion"
+
+// PromotionMessage posts a message for each promotion to an environment of the release built by the activity
+func (o *SlackBotOptions) PromotionMessage(activity *record.ActivityRecord) error {
+	if activity.Name == "" {
+		return fmt.Errorf("PipelineActivity name cannot be empty")
+	}
+	if len(o.Promotions) == 0 || !(hasPromoteStage(activity.Stages) || hasPromoteStage(activity.Steps)) {
+		return nil
+	}
+	// the environments and promotion PRs aren't part of the activity record, they are only on the PipelineActivity
+	pa, err := o.getPipelineActivity(activity.Name)
+	if err != nil {
+		return errors.Wrapf(err, "getting PipelineActivity %s", activity.Name)
+	}
+	promotions := promoteSteps(pa)
+	ctx := context.Background()
+	var errs []error
+	for _, cfg := range o.Promotions {
+		enabled, _, _, err := o.isEnabled(ctx, activity, cfg.SlackBotMode)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if !enabled {
+			continue
+		}
+		for _, promote := range promotions {
+			if len(cfg.Environments) > 0 && !util.Contains(cfg.Environments, promote.Environment) {
+				continue
+			}
+			// the statuses of the promotions take precedence over the statuses of the org
+			overrides := mergeStatuses(cfg.Statuses, o.statusesFor(cfg.SlackBotMode, activity))
+			attachments := o.createPromotionMessage(activity, pa.Spec.Version, promote, overrides)
+			var blocks []slack.Block
+			if o.UseBlockKit {
+				blocks = attachmentsToBlocks(attachments)
+				attachments = nil
+			}
+			// each environment gets its own message, which is distinct from the pipeline message
+			promotionActivity := *activity
+			promotionActivity.Name = promotionMessageKey(activity.Name, promote.Environment)
+			for _, channel := range activityChannels(activity, cfg.SlackBotMode) {
+				err := o.postMessage(channel, false, promotionMessageType, &promotionActivity, nil, attachments,
+					blocks, true)
+				if err != nil {
+					// carry on posting to the other channels
+					errs = append(errs, errors.Wrapf(err, "error posting promotion of %s to %s to channel %s",
+						activity.Name, promote.Environment, channel))
+					continue
+				}
+				messageLogger(&promotionActivity, channel, promotionMessageType).Infof(
+					"Promotion message sent to %s\n", channel)
+				if cfg.UpdateTopic && jx.ToPipelineState(promote.Status) == v1alpha1.SuccessState {
+					success := promotionStatus(overrides, o.localizedStatuses(), v1alpha1.SuccessState)
+					topic := releaseTopic(activity, pa.Spec.Version, success)
+					err = o.updateChannelTopic(channel, &promotionActivity, topic)
+					if err != nil {
+						errs = append(errs, errors.Wrapf(err, "error updating the topic of channel %s for %s",
+							channel, activity.Name))
+					}
+				}
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// createPromotionMessage returns the attachments describing the promotion to an environment
+func (o *SlackBotOptions) createPromotionMessage(activity *record.ActivityRecord, version string,
+	promote *jenkinsv1.PromoteActivityStep, overrides slackapp.Statuses) []slack.Attachment {
+	state := jx.ToPipelineState(promote.Status)
+	status := promotionStatus(overrides, o.localizedStatuses(), state)
+	release := fmt.Sprintf("%s/%s", activity.Owner, activity.Repo)
+	if version != "" {
+		release = fmt.Sprintf("%s %s", release, version)
+	}
+	text := fmt.Sprintf("Promotion of %s to %s", release, promote.Environment)
+	if status != nil {
+		text = strings.TrimSpace(fmt.Sprintf("%s %s %s", status.Emoji, text, status.Text))
+	}
+	color := attachmentColor(state)
+	if status != nil && status.Color != "" {
+		color = status.Color
+	}
+	attachment := slack.Attachment{
+		CallbackID: fmt.Sprintf("promotion:%s", activity.Name),
+		Fallback:   text,
+		Title:      text,
+		Color:      color,
+		Fields: []slack.AttachmentField{
+			{
+				Title: "Environment",
+				Value: promote.Environment,
+				Short: true,
+			},
+		},
+	}
+	if promote.PullRequest != nil && promote.PullRequest.PullRequestURL != "" {
+		prURL := promote.PullRequest.PullRequestURL
+		attachment.Fields = append(attachment.Fields, slack.AttachmentField{
+			Title: "Pull Request",
+			Value: link(pullRequestNameForKind(o.gitKind(prURL), prURL), prURL),
+			Short: true,
+		})
+		attachment.Actions = append(attachment.Actions, slack.AttachmentAction{
+			Name: "promotion-pr",
+			Text: "Promotion PR",
+			Type: "button",
+			URL:  prURL,
+		})
+	}
+	if promote.ApplicationURL != "" {
+		attachment.Actions = append(attachment.Actions, slack.AttachmentAction{
+			Name: "application",
+			Text: "Application",
+			Type: "button",
+			URL:  promote.ApplicationURL,
+		})
+	}
+	return []slack.Attachment{attachment}
+}
+
+// promotionStatus returns the status to use for a promotion in the given state, the promotion overrides take
+// precedence over the statuses of the bot, which take precedence over the defaults
+func promotionStatus(overrides slackapp.Statuses, statuses slackapp.Statuses,
+	state v1alpha1.PipelineState) *slackapp.Status {
+	for _, s := range []slackapp.Statuses{overrides, statuses, defaultStatuses} {
+		if status := statusForState(s, state); status != nil {
+			return status
+		}
+	}
+	return nil
+}
+
+func statusForState(statuses slackapp.Statuses, state v1alpha1.PipelineState) *slackapp.Status {
+	switch state {
+	case v1alpha1.FailureState:
+		return statuses.Failed
+	case v1alpha1.AbortedState:
+		return statuses.Aborted
+	case v1alpha1.SuccessState:
+		return statuses.Succeeded
+	case v1alpha1.RunningState:
+		return statuses.Running
+	case v1alpha1.PendingState:
+		return statuses.Pending
+	}
+	return nil
+}
+
+// hasPromoteStage returns true if one of the stages or steps is a promotion
+func hasPromoteStage(stages []*record.ActivityStageOrStep) bool {
+	for _, stage := range stages {
+		if stage == nil {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(stage.Name)), "promote") {
+			return true
+		}
+		if hasPromoteStage(stage.Stages) || hasPromoteStage(stage.Steps) {
+			return true
+		}
+	}
+	return false
+}
+
+// promoteSteps returns the promotions of the PipelineActivity
+func promoteSteps(pa *jenkinsv1.PipelineActivity) []*jenkinsv1.PromoteActivityStep {
+	var answer []*jenkinsv1.PromoteActivityStep
+	for _, step := range pa.Spec.Steps {
+		if step.Promote != nil && step.Promote.Environment != "" {
+			answer = append(answer, step.Promote)
+		}
+	}
+	return answer
+}
+
+// promotionMessageKey returns the key of the promotion message of the activity to the environment
+func promotionMessageKey(name string, environment string) string {
+	return fmt.Sprintf("%s/promote-%s", name, environment)
+}
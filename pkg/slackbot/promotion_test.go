@@ -0,0 +1,208 @@
+package slackbot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSlackBotOptions_PromotionMessage(t *testing.T) {
+	promote := func(env string, status jenkinsv1.ActivityStatusType) jenkinsv1.PipelineActivityStep {
+		return jenkinsv1.PipelineActivityStep{
+			Kind: jenkinsv1.ActivityStepKindTypePromote,
+			Promote: &jenkinsv1.PromoteActivityStep{
+				CoreActivityStep: jenkinsv1.CoreActivityStep{Status: status},
+				Environment:      env,
+				PullRequest: &jenkinsv1.PromotePullRequestStep{
+					PullRequestURL: "https://github.com/cheese/environment-" + env + "/pull/7",
+				},
+			},
+		}
+	}
+	pa := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cheese-wine-master-3",
+			Namespace: "jx",
+		},
+		Spec: jenkinsv1.PipelineActivitySpec{
+			Version: "1.0.3",
+			Steps: []jenkinsv1.PipelineActivityStep{
+				promote("staging", jenkinsv1.ActivityStatusTypeSucceeded),
+				promote("production", jenkinsv1.ActivityStatusTypeRunning),
+			},
+		},
+	}
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: "jx",
+			JXClient:  jxfake.NewSimpleClientset(pa),
+		},
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Promotions: []slackapp.PromotionMode{
+			{
+				SlackBotMode: slackapp.SlackBotMode{Channel: "releases"},
+				Environments: []string{"staging"},
+				Statuses: slackapp.Statuses{
+					Succeeded: &slackapp.Status{Emoji: ":rocket:", Text: "done"},
+				},
+			},
+		},
+	}
+	act := &record.ActivityRecord{
+		Name:   "cheese-wine-master-3",
+		Owner:  "cheese",
+		Repo:   "wine",
+		Branch: "master",
+		Stages: []*record.ActivityStageOrStep{
+			{
+				Name: "from build pack",
+				Steps: []*record.ActivityStageOrStep{
+					{Name: "build make"},
+					{Name: "promote jx promote"},
+				},
+			},
+		},
+	}
+
+	err := o.PromotionMessage(act)
+	require.NoError(t, err)
+	posts := recorder.callsTo("chat.postMessage")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "#releases", posts[0].Values.Get("channel"))
+	var attachments []slack.Attachment
+	err = json.Unmarshal([]byte(posts[0].Values.Get("attachments")), &attachments)
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	assert.Equal(t, ":rocket: Promotion of cheese/wine 1.0.3 to staging done", attachments[0].Title)
+	assert.Equal(t, "<https://github.com/cheese/environment-staging/pull/7|#7>", attachments[0].Fields[1].Value)
+	assert.NotNil(t, o.Timestamps["#releases"][promotionMessageKey(act.Name, "staging")])
+	assert.Nil(t, o.Timestamps["#releases"][act.Name])
+
+	// activities without promote stages are ignored
+	act.Stages = nil
+	err = o.PromotionMessage(act)
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("chat.postMessage"), 1)
+}
+
+func TestSlackBotOptions_PromotionMessage_updateTopic(t *testing.T) {
+	promote := func(env string, status jenkinsv1.ActivityStatusType) jenkinsv1.PipelineActivityStep {
+		return jenkinsv1.PipelineActivityStep{
+			Kind: jenkinsv1.ActivityStepKindTypePromote,
+			Promote: &jenkinsv1.PromoteActivityStep{
+				CoreActivityStep: jenkinsv1.CoreActivityStep{Status: status},
+				Environment:      env,
+			},
+		}
+	}
+	pa := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cheese-wine-master-3",
+			Namespace: "jx",
+		},
+		Spec: jenkinsv1.PipelineActivitySpec{
+			Version: "1.0.3",
+			Steps: []jenkinsv1.PipelineActivityStep{
+				promote("staging", jenkinsv1.ActivityStatusTypeSucceeded),
+				promote("production", jenkinsv1.ActivityStatusTypeRunning),
+			},
+		},
+	}
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: "jx",
+			JXClient:  jxfake.NewSimpleClientset(pa),
+		},
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Promotions: []slackapp.PromotionMode{
+			{
+				SlackBotMode: slackapp.SlackBotMode{Channel: "releases"},
+				UpdateTopic:  true,
+				Statuses: slackapp.Statuses{
+					Succeeded: &slackapp.Status{Emoji: ":rocket:"},
+				},
+			},
+			{
+				SlackBotMode: slackapp.SlackBotMode{Channel: "deployments"},
+			},
+		},
+	}
+	act := &record.ActivityRecord{
+		Name:   "cheese-wine-master-3",
+		Owner:  "cheese",
+		Repo:   "wine",
+		Branch: "master",
+		Steps: []*record.ActivityStageOrStep{
+			{Name: "promote jx promote"},
+		},
+	}
+
+	err := o.PromotionMessage(act)
+	require.NoError(t, err)
+	assert.Len(t, client.callsTo("chat.postMessage"), 4)
+	// only the successful promotion to staging updates the topic, of the channel which opted in
+	topics := client.callsTo("conversations.setTopic")
+	require.Len(t, topics, 1)
+	assert.Equal(t, "C0001", topics[0].Values.Get("channel"))
+	assert.Equal(t, ":rocket: wine 1.0.3 released", topics[0].Values.Get("topic"))
+
+	// the topic isn't set again when it doesn't change
+	err = o.PromotionMessage(act)
+	require.NoError(t, err)
+	assert.Len(t, client.callsTo("conversations.setTopic"), 1)
+
+	// when it was updated recently, only the latest release is shown once the interval elapsed
+	promotionActivity := *act
+	promotionActivity.Name = promotionMessageKey(act.Name, "staging")
+	o.topicsLock.Lock()
+	o.topics["C0001"].updated = time.Now().Add(-topicUpdateInterval + 100*time.Millisecond)
+	o.topicsLock.Unlock()
+	err = o.updateChannelTopic("#releases", &promotionActivity, ":rocket: wine 1.0.4 released")
+	require.NoError(t, err)
+	err = o.updateChannelTopic("#releases", &promotionActivity, ":rocket: wine 1.0.5 released")
+	require.NoError(t, err)
+	assert.Len(t, client.callsTo("conversations.setTopic"), 1)
+	assert.Eventually(t, func() bool {
+		return len(client.callsTo("conversations.setTopic")) == 2
+	}, time.Second, 10*time.Millisecond)
+	topics = client.callsTo("conversations.setTopic")
+	assert.Equal(t, ":rocket: wine 1.0.5 released", topics[1].Values.Get("topic"))
+
+	o.topicsLock.Lock()
+	o.topics["C0001"].updated = time.Now().Add(-topicUpdateInterval)
+	o.topicsLock.Unlock()
+	err = o.updateChannelTopic("#releases", &promotionActivity, ":rocket: wine 1.0.6 released")
+	require.NoError(t, err)
+	topics = client.callsTo("conversations.setTopic")
+	require.Len(t, topics, 3)
+	assert.Equal(t, ":rocket: wine 1.0.6 released", topics[2].Values.Get("topic"))
+}
+
+func Test_releaseTopic(t *testing.T) {
+	act := &record.ActivityRecord{Repo: "wine"}
+	assert.Equal(t, ":rocket: wine 1.0.3 released", releaseTopic(act, "1.0.3", &slackapp.Status{Emoji: ":rocket:"}))
+	assert.Equal(t, "wine released", releaseTopic(act, "", nil))
+}
+
+func Test_promotionStatus(t *testing.T) {
+	overrides := slackapp.Statuses{Failed: &slackapp.Status{Emoji: ":fire:"}}
+	statuses := slackapp.Statuses{Failed: &slackapp.Status{Emoji: ":x:"}, Running: &slackapp.Status{Emoji: ":runner:"}}
+	assert.Equal(t, ":fire:", promotionStatus(overrides, statuses, "failure").Emoji)
+	assert.Equal(t, ":runner:", promotionStatus(overrides, statuses, "running").Emoji)
+	assert.Equal(t, defaultStatuses.Succeeded, promotionStatus(overrides, statuses, "success"))
+}
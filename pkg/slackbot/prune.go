@@ -0,0 +1,100 @@
+package slackbot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/pkg/errors"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// OrphanedMessage is a stored reference to a message posted for a PipelineActivity which no longer exists
+type OrphanedMessage struct {
+	Channel string
+	// Name is the name the reference is stored with, which is the name of the activity for the pipeline messages
+	Name string
+	// Activity is the name of the PipelineActivity the message was posted for
+	Activity  string
+	Reference *MessageReference
+}
+
+// FindOrphanedMessages returns the stored references to messages whose PipelineActivity no longer exists, sorted by
+// channel and name
+func (o *SlackBotOptions) FindOrphanedMessages() ([]OrphanedMessage, error) {
+	o.timestampsLock.RLock()
+	timestamps := copyTimestamps(o.Timestamps)
+	o.timestampsLock.RUnlock()
+
+	exists := make(map[string]bool)
+	var orphans []OrphanedMessage
+	for channel, refs := range timestamps {
+		if channel == alertsReferenceChannel {
+			// the alerts aren't Slack messages, their references expire with the TimestampTTL
+			continue
+		}
+		for name, ref := range refs {
+			activity := referenceActivityName(name)
+			if ref == nil || activity == "" {
+				continue
+			}
+			found, ok := exists[activity]
+			if !ok {
+				_, err := o.getPipelineActivity(activity)
+				if err != nil && !kubeerrors.IsNotFound(err) {
+					return nil, errors.Wrapf(err, "getting PipelineActivity %s", activity)
+				}
+				found = err == nil
+				exists[activity] = found
+			}
+			if !found {
+				orphans = append(orphans, OrphanedMessage{
+					Channel:   channel,
+					Name:      name,
+					Activity:  activity,
+					Reference: ref,
+				})
+			}
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].Channel != orphans[j].Channel {
+			return orphans[i].Channel < orphans[j].Channel
+		}
+		return orphans[i].Name < orphans[j].Name
+	})
+	return orphans, nil
+}
+
+// PruneOrphanedMessages deletes the messages whose PipelineActivity no longer exists from Slack, and forgets their
+// references. With DryRun the orphaned messages are only returned.
+func (o *SlackBotOptions) PruneOrphanedMessages() ([]OrphanedMessage, error) {
+	orphans, err := o.FindOrphanedMessages()
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, orphan := range orphans {
+		if o.DryRun {
+			log.Logger().Infof("Dry run, not deleting orphaned message for %s in %s\n", orphan.Name, orphan.Channel)
+			continue
+		}
+		err := o.deleteMessage(orphan.Channel, orphan.Name)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "deleting orphaned message for %s in %s", orphan.Name,
+				orphan.Channel))
+		}
+	}
+	return orphans, utilerrors.NewAggregate(errs)
+}
+
+// referenceActivityName returns the name of the PipelineActivity the reference stored with the name is for, the
+// threaded replies and promotion messages are stored as the name of the activity followed by a path. It returns an
+// empty string for the references which are shared by the builds of a pipeline.
+func referenceActivityName(name string) string {
+	if strings.HasPrefix(name, "failure/") {
+		return ""
+	}
+	return strings.SplitN(name, "/", 2)[0]
+}
@@ -0,0 +1,61 @@
+package slackbot
+
+import (
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSlackBotOptions_PruneOrphanedMessages(t *testing.T) {
+	pa := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "cheese-wine-master-1", Namespace: "jx"},
+	}
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: "jx",
+			JXClient:  jxfake.NewSimpleClientset(pa),
+		},
+		SlackClient: client,
+		Timestamps: map[string]map[string]*MessageReference{
+			"#cheese": {
+				"cheese-wine-master-1":                 {ChannelID: "C1", Timestamp: "1.000100"},
+				"cheese-wine-master-2":                 {ChannelID: "C1", Timestamp: "2.000100"},
+				"cheese-wine-master-2/promote-staging": {ChannelID: "C1", Timestamp: "3.000100"},
+				"failure/cheese/wine/master/release":   {ChannelID: "C1", Timestamp: "2.000100"},
+			},
+			"#wine": {
+				"cheese-wine-master-1/stage": {ChannelID: "C2", Timestamp: "4.000100"},
+			},
+		},
+		DryRun: true,
+	}
+
+	orphans, err := o.PruneOrphanedMessages()
+	require.NoError(t, err)
+	require.Len(t, orphans, 2)
+	assert.Equal(t, "cheese-wine-master-2", orphans[0].Name)
+	assert.Equal(t, "cheese-wine-master-2/promote-staging", orphans[1].Name)
+	assert.Equal(t, "cheese-wine-master-2", orphans[1].Activity)
+	assert.Empty(t, client.callsTo("chat.delete"))
+	assert.Len(t, o.Timestamps["#cheese"], 4)
+
+	o.DryRun = false
+	orphans, err = o.PruneOrphanedMessages()
+	require.NoError(t, err)
+	assert.Len(t, orphans, 2)
+	deletes := client.callsTo("chat.delete")
+	require.Len(t, deletes, 2)
+	assert.Nil(t, o.messageReference("#cheese", "cheese-wine-master-2"))
+	assert.Nil(t, o.messageReference("#cheese", "cheese-wine-master-2/promote-staging"))
+	assert.NotNil(t, o.messageReference("#cheese", "cheese-wine-master-1"))
+	assert.NotNil(t, o.messageReference("#cheese", "failure/cheese/wine/master/release"))
+
+	orphans, err = o.FindOrphanedMessages()
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+}
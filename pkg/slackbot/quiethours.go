@@ -0,0 +1,78 @@
+package slackbot
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// defaultQuietStatuses are the statuses suppressed during quiet hours when none are configured, failures are always
+// posted unless explicitly configured
+var defaultQuietStatuses = []v1alpha1.PipelineState{v1alpha1.TriggeredState, v1alpha1.PendingState,
+	v1alpha1.RunningState}
+
+// parseQuietHours returns the location, start and end (as durations since midnight) of the quiet hours
+func parseQuietHours(q *slackapp.QuietHours) (*time.Location, time.Duration, time.Duration, error) {
+	location, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return nil, 0, 0, errors.Wrapf(err, "loading quiet hours time zone %s", q.TimeZone)
+	}
+	start, err := parseTimeOfDay(q.Start)
+	if err != nil {
+		return nil, 0, 0, errors.Wrapf(err, "parsing quiet hours start %s", q.Start)
+	}
+	end, err := parseTimeOfDay(q.End)
+	if err != nil {
+		return nil, 0, 0, errors.Wrapf(err, "parsing quiet hours end %s", q.End)
+	}
+	return location, start, end, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// inQuietHours returns true if now is within the quiet hours and the status is one of the suppressed statuses
+func inQuietHours(q *slackapp.QuietHours, status v1alpha1.PipelineState, now time.Time) (bool, error) {
+	if q == nil {
+		return false, nil
+	}
+	if !isQuietStatus(q, status) {
+		return false, nil
+	}
+	location, start, end, err := parseQuietHours(q)
+	if err != nil {
+		return false, err
+	}
+	now = now.In(location)
+	sinceMidnight := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	if start <= end {
+		return sinceMidnight >= start && sinceMidnight < end, nil
+	}
+	// the window spans midnight
+	return sinceMidnight >= start || sinceMidnight < end, nil
+}
+
+func isQuietStatus(q *slackapp.QuietHours, status v1alpha1.PipelineState) bool {
+	if len(q.Statuses) == 0 {
+		for _, s := range defaultQuietStatuses {
+			if s == status {
+				return true
+			}
+		}
+		return false
+	}
+	for _, s := range q.Statuses {
+		if strings.EqualFold(s, string(status)) {
+			return true
+		}
+	}
+	return false
+}
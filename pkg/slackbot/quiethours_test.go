@@ -0,0 +1,51 @@
+package slackbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_inQuietHours(t *testing.T) {
+	overnight := &slackapp.QuietHours{TimeZone: "Europe/Paris", Start: "22:00", End: "07:00"}
+	daytime := &slackapp.QuietHours{Start: "12:00", End: "14:00", Statuses: []string{"Running", "Failure"}}
+	// 23:30 in Paris during summer time
+	night := time.Date(2020, 6, 1, 21, 30, 0, 0, time.UTC)
+	// 09:00 in Paris during summer time
+	morning := time.Date(2020, 6, 1, 7, 0, 0, 0, time.UTC)
+	lunch := time.Date(2020, 6, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		quiet  *slackapp.QuietHours
+		status v1alpha1.PipelineState
+		now    time.Time
+		want   bool
+	}{
+		{name: "not_configured", quiet: nil, status: v1alpha1.RunningState, now: night, want: false},
+		{name: "running_at_night", quiet: overnight, status: v1alpha1.RunningState, now: night, want: true},
+		{name: "running_in_the_morning", quiet: overnight, status: v1alpha1.RunningState, now: morning, want: false},
+		{name: "failure_exempt_by_default", quiet: overnight, status: v1alpha1.FailureState, now: night,
+			want: false},
+		{name: "configured_statuses", quiet: daytime, status: v1alpha1.FailureState, now: lunch, want: true},
+		{name: "not_a_configured_status", quiet: daytime, status: v1alpha1.PendingState, now: lunch, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := inQuietHours(tt.quiet, tt.status, tt.now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_inQuietHoursInvalid(t *testing.T) {
+	_, err := inQuietHours(&slackapp.QuietHours{Start: "10pm", End: "07:00"}, v1alpha1.RunningState, time.Now())
+	assert.Error(t, err)
+	_, err = inQuietHours(&slackapp.QuietHours{TimeZone: "Cheese/Wine", Start: "22:00", End: "07:00"},
+		v1alpha1.RunningState, time.Now())
+	assert.Error(t, err)
+}
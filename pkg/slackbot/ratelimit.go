@@ -0,0 +1,94 @@
+package slackbot
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// directMessageBucket is the key of the bucket shared by all direct messages
+	directMessageBucket = "@direct-messages"
+)
+
+// RateLimiter limits the rate at which messages are posted using a token bucket per channel. Direct messages share a
+// single bucket as they are subject to a different Slack rate limit tier.
+type RateLimiter struct {
+	MessagesPerMinute int
+	// Now returns the current time, it can be replaced by tests
+	Now func() time.Time
+	// Sleep waits for the duration or until the context is done, it can be replaced by tests
+	Sleep func(ctx context.Context, d time.Duration) error
+
+	lock    sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing messagesPerMinute messages per minute for each channel
+func NewRateLimiter(messagesPerMinute int) *RateLimiter {
+	return &RateLimiter{
+		MessagesPerMinute: messagesPerMinute,
+		Now:               time.Now,
+		Sleep:             sleepContext,
+	}
+}
+
+// Wait blocks until a message can be posted to the channel, or direct message if directMessage is true
+func (l *RateLimiter) Wait(ctx context.Context, channel string, directMessage bool) error {
+	if l == nil || l.MessagesPerMinute <= 0 {
+		return nil
+	}
+	key := channel
+	if directMessage {
+		key = directMessageBucket
+	}
+	delay := l.reserve(key)
+	if delay <= 0 {
+		return nil
+	}
+	return l.Sleep(ctx, delay)
+}
+
+// reserve takes a token from the bucket, returning how long to wait until the token is available
+func (l *RateLimiter) reserve(key string) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.Now()
+	capacity := float64(l.MessagesPerMinute)
+	perSecond := capacity / 60
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{
+			tokens: capacity,
+			last:   now,
+		}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.last).Seconds() * perSecond
+	if bucket.tokens > capacity {
+		bucket.tokens = capacity
+	}
+	bucket.last = now
+	bucket.tokens--
+	if bucket.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.tokens / perSecond * float64(time.Second))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	now := time.Now()
+	var slept []time.Duration
+	l := NewRateLimiter(2)
+	l.Now = func() time.Time {
+		return now
+	}
+	l.Sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+	ctx := context.Background()
+
+	// the bucket starts full so the first messages are not delayed
+	require.NoError(t, l.Wait(ctx, "#cheese", false))
+	require.NoError(t, l.Wait(ctx, "#cheese", false))
+	assert.Empty(t, slept)
+
+	// the third has to wait for a token to be refilled
+	require.NoError(t, l.Wait(ctx, "#cheese", false))
+	assert.Equal(t, []time.Duration{30 * time.Second}, slept)
+
+	// other channels and direct messages have their own buckets
+	require.NoError(t, l.Wait(ctx, "#wine", false))
+	require.NoError(t, l.Wait(ctx, "U0001", true))
+	require.NoError(t, l.Wait(ctx, "U0002", true))
+	assert.Len(t, slept, 1)
+	require.NoError(t, l.Wait(ctx, "U0003", true))
+	assert.Len(t, slept, 2)
+}
+
+func TestRateLimiter_WaitDisabled(t *testing.T) {
+	var l *RateLimiter
+	assert.NoError(t, l.Wait(context.Background(), "#cheese", false))
+	assert.NoError(t, NewRateLimiter(0).Wait(context.Background(), "#cheese", false))
+}
@@ -0,0 +1,78 @@
+package slackbot
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+// reactionName returns the name of the reaction for the emoji, e.g. red_circle for :red_circle:
+func reactionName(emoji string) string {
+	return strings.Trim(emoji, ":")
+}
+
+// terminalReaction returns the reaction for the status if the pipeline has finished, or an empty string if not
+func terminalReaction(statuses slackapp.Statuses, status v1alpha1.PipelineState) string {
+	switch status {
+	case v1alpha1.SuccessState:
+		return reactionName(getStatus(statuses.Succeeded, defaultStatuses.Succeeded).Emoji)
+	case v1alpha1.FailureState:
+		return reactionName(getStatus(statuses.Failed, defaultStatuses.Failed).Emoji)
+	case v1alpha1.AbortedState:
+		return reactionName(getStatus(statuses.Aborted, defaultStatuses.Aborted).Emoji)
+	}
+	return ""
+}
+
+// reactOnComplete adds a reaction for the final state of the pipeline to the message posted for the activity in the
+// channel, replacing the reaction added for a previous final state
+func (o *SlackBotOptions) reactOnComplete(channel string, activity *record.ActivityRecord,
+	statuses slackapp.Statuses) error {
+	reaction := terminalReaction(statuses, pipelineStatus(activity))
+	if reaction == "" {
+		return nil
+	}
+	messageRef := o.messageReference(channel, activity.Name)
+	// the messages posted with incoming webhooks have no timestamp to react to
+	if messageRef == nil || messageRef.Timestamp == "" || messageRef.Reaction == reaction {
+		return nil
+	}
+	ctx := context.Background()
+	item := slack.NewRefToMessage(messageRef.ChannelID, messageRef.Timestamp)
+	if messageRef.Reaction != "" {
+		err := o.postWithRetry(ctx, "removing reaction", func(ctx context.Context) error {
+			defer observeSlackAPICall("reactions.remove", time.Now())
+			err := o.slackClientFor(messageRef.Workspace).RemoveReactionContext(ctx, messageRef.Reaction, item)
+			if err != nil && err.Error() == "no_reaction" {
+				// the reaction was already removed
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return errors.Wrapf(err, "removing reaction %s from message for %s", messageRef.Reaction,
+				activity.Name)
+		}
+	}
+	err := o.postWithRetry(ctx, "adding reaction", func(ctx context.Context) error {
+		defer observeSlackAPICall("reactions.add", time.Now())
+		err := o.slackClientFor(messageRef.Workspace).AddReactionContext(ctx, reaction, item)
+		if err != nil && err.Error() == "already_reacted" {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "adding reaction %s to message for %s", reaction, activity.Name)
+	}
+	ref := *messageRef
+	ref.Reaction = reaction
+	o.storeMessageReference(channel, activity.Name, &ref)
+	return nil
+}
@@ -0,0 +1,58 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_reactOnComplete(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		SlackClient:     recorder.client(),
+		Timestamps:      make(map[string]map[string]*MessageReference),
+		ReactOnComplete: true,
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	// the status of the last stage takes precedence over the status of the activity
+	act.Stages = nil
+	channel := "#cheese"
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, nil, nil, true)
+	require.NoError(t, err)
+
+	// no reaction while the pipeline is running
+	act.Status = v1alpha1.RunningState
+	err = o.reactOnComplete(channel, act, o.Statuses)
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("reactions.add"), 0)
+
+	act.Status = v1alpha1.FailureState
+	err = o.reactOnComplete(channel, act, o.Statuses)
+	require.NoError(t, err)
+	adds := recorder.callsTo("reactions.add")
+	require.Len(t, adds, 1)
+	assert.Equal(t, "red_circle", adds[0].Values.Get("name"))
+	assert.Equal(t, o.Timestamps[channel][act.Name].Timestamp, adds[0].Values.Get("timestamp"))
+
+	// reacting again with the same state does nothing
+	err = o.reactOnComplete(channel, act, o.Statuses)
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("reactions.add"), 1)
+
+	// a re-run which succeeds replaces the failure reaction
+	act.Status = v1alpha1.SuccessState
+	err = o.reactOnComplete(channel, act, o.Statuses)
+	require.NoError(t, err)
+	removes := recorder.callsTo("reactions.remove")
+	require.Len(t, removes, 1)
+	assert.Equal(t, "red_circle", removes[0].Values.Get("name"))
+	adds = recorder.callsTo("reactions.add")
+	require.Len(t, adds, 2)
+	assert.Equal(t, "white_check_mark", adds[1].Values.Get("name"))
+	assert.Equal(t, "white_check_mark", o.Timestamps[channel][act.Name].Reaction)
+}
@@ -0,0 +1,32 @@
+package slackbot
+
+import (
+	"github.com/jenkins-x/lighthouse/pkg/jx"
+	"github.com/pkg/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// ReplayPipelineActivity sends the messages for the PipelineActivity with the given name as the watch loop would when
+// it is updated, which combined with DryRun previews the messages without posting them
+func (o *SlackBotOptions) ReplayPipelineActivity(name string) error {
+	pa, err := o.getPipelineActivity(name)
+	if err != nil {
+		return errors.Wrapf(err, "getting PipelineActivity %s", name)
+	}
+	activity, err := jx.ConvertPipelineActivity(pa)
+	if err != nil {
+		return errors.Wrapf(err, "converting PipelineActivity %s", name)
+	}
+	activityLogger(activity).Infof("Replaying %s with SlackBot %s\n", name, o.Name)
+	var errs []error
+	if err := o.PipelineMessage(activity); err != nil {
+		errs = append(errs, errors.Wrapf(err, "replaying the pipeline message for %s", name))
+	}
+	if err := o.PromotionMessage(activity); err != nil {
+		errs = append(errs, errors.Wrapf(err, "replaying the promotion messages for %s", name))
+	}
+	if err := o.ReviewRequestMessage(activity); err != nil {
+		errs = append(errs, errors.Wrapf(err, "replaying the review request message for %s", name))
+	}
+	return utilerrors.NewAggregate(errs)
+}
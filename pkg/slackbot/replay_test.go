@@ -0,0 +1,52 @@
+package slackbot
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_ReplayPipelineActivity(t *testing.T) {
+	data, err := ioutil.ReadFile(path.Join("test_data", "bot", "stage_multiple_steps.yaml"))
+	require.NoError(t, err)
+	pa := &jenkinsv1.PipelineActivity{}
+	err = yaml.Unmarshal(data, pa)
+	require.NoError(t, err)
+	// not a pull request, so that the git provider isn't needed
+	pa.Labels["branch"] = "master"
+	pa.Spec.GitBranch = "master"
+
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: pa.Namespace,
+			JXClient:  jxfake.NewSimpleClientset(pa),
+		},
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines:   []slackapp.SlackBotMode{{Channel: "builds"}},
+		DryRun:      true,
+	}
+
+	err = o.ReplayPipelineActivity(pa.Name)
+	require.NoError(t, err)
+	assert.Empty(t, recorder.callsTo("chat.postMessage"))
+
+	o.DryRun = false
+	err = o.ReplayPipelineActivity(pa.Name)
+	require.NoError(t, err)
+	posts := recorder.callsTo("chat.postMessage")
+	require.Len(t, posts, 1)
+	assert.Equal(t, "#builds", posts[0].Values.Get("channel"))
+
+	err = o.ReplayPipelineActivity("missing")
+	assert.Error(t, err)
+}
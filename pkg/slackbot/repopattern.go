@@ -0,0 +1,52 @@
+package slackbot
+
+import (
+	"regexp"
+
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// compileRepoPatterns compiles the repository patterns of the modes, keyed by pattern, so that they are only compiled
+// once
+func compileRepoPatterns(modes []slackapp.SlackBotMode) (map[string]*regexp.Regexp, error) {
+	patterns := make(map[string]*regexp.Regexp)
+	for _, cfg := range modes {
+		if cfg.RepoPattern == "" || patterns[cfg.RepoPattern] != nil {
+			continue
+		}
+		re, err := regexp.Compile(cfg.RepoPattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid repoPattern %s", cfg.RepoPattern)
+		}
+		patterns[cfg.RepoPattern] = re
+	}
+	return patterns, nil
+}
+
+// slackBotModes returns the modes of the pipelines, pull requests and promotions of the SlackBot
+func slackBotModes(slackBot *slackapp.SlackBot) []slackapp.SlackBotMode {
+	modes := append([]slackapp.SlackBotMode{}, slackBot.Spec.Pipelines...)
+	modes = append(modes, slackBot.Spec.PullRequests...)
+	for _, cfg := range slackBot.Spec.Promotions {
+		modes = append(modes, cfg.SlackBotMode)
+	}
+	return modes
+}
+
+// matchesRepoPattern returns true if the mode has a repository pattern matching the repository. The patterns compiled
+// when the bot was created are reused, invalid patterns never match
+func (o *SlackBotOptions) matchesRepoPattern(cfg slackapp.SlackBotMode, repo string) bool {
+	if cfg.RepoPattern == "" {
+		return false
+	}
+	re, ok := o.repoPatterns[cfg.RepoPattern]
+	if !ok {
+		var err error
+		re, err = regexp.Compile(cfg.RepoPattern)
+		if err != nil {
+			return false
+		}
+	}
+	return re.MatchString(repo)
+}
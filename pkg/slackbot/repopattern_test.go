@@ -0,0 +1,67 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_compileRepoPatterns(t *testing.T) {
+	patterns, err := compileRepoPatterns([]slackapp.SlackBotMode{
+		{RepoPattern: "^team-foo-"},
+		{RepoPattern: "^team-foo-"},
+		{},
+	})
+	require.NoError(t, err)
+	assert.Len(t, patterns, 1)
+
+	_, err = compileRepoPatterns([]slackapp.SlackBotMode{{RepoPattern: "team-(foo"}})
+	assert.EqualError(t, err, "invalid repoPattern team-(foo: error parsing regexp: missing closing ): `team-(foo`")
+}
+
+func TestSlackBotOptions_isEnabled_repoPattern(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	o := &SlackBotOptions{}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		cfg  slackapp.SlackBotMode
+		want bool
+	}{
+		{name: "no_filter", cfg: slackapp.SlackBotMode{}, want: true},
+		{name: "pattern_matches", cfg: slackapp.SlackBotMode{RepoPattern: "^jx"}, want: true},
+		{name: "pattern_does_not_match", cfg: slackapp.SlackBotMode{RepoPattern: "^team-foo-"}, want: false},
+		{
+			name: "org_without_repos_and_pattern",
+			cfg: slackapp.SlackBotMode{RepoPattern: "^team-foo-",
+				Orgs: []slackapp.Org{{Name: "jenkins-x-labs"}}},
+			want: false,
+		},
+		{
+			name: "org_repos_or_pattern",
+			cfg: slackapp.SlackBotMode{RepoPattern: "^team-foo-",
+				Orgs: []slackapp.Org{{Name: "jenkins-x-labs", Repos: []slackapp.Repo{{Name: "jxl"}}}}},
+			want: true,
+		},
+		{
+			name: "other_org",
+			cfg: slackapp.SlackBotMode{RepoPattern: "^jx",
+				Orgs: []slackapp.Org{{Name: "jenkins-x"}}},
+			want: false,
+		},
+		{name: "invalid_pattern", cfg: slackapp.SlackBotMode{RepoPattern: "jx(l"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enabled, _, _, err := o.isEnabled(ctx, act, tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, enabled)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package slackbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/slack/pkg/slackbot/interaction"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// PipelineActivityCallbackPrefix is the prefix of the callback ID of the pipeline messages, followed by the name of
+	// the PipelineActivity
+	PipelineActivityCallbackPrefix = "pipelineactivity"
+	// RerunAnnotation is set on a PipelineActivity to the time a user asked to rerun its pipeline from Slack, for the
+	// automation rebuilding the pipelines to watch
+	RerunAnnotation = "slack.apps.jenkins-x.io/rerun-requested"
+	// RerunRequestedByAnnotation is set on a PipelineActivity to the Slack ID of the user who asked to rerun it
+	RerunRequestedByAnnotation = "slack.apps.jenkins-x.io/rerun-requested-by"
+
+	rerunActionValue = "rerun"
+)
+
+// rerunAction returns the button asking to rerun a failed pipeline
+func rerunAction() slack.AttachmentAction {
+	return slack.AttachmentAction{
+		Name:  rerunActionValue,
+		Type:  "button",
+		Text:  "Rerun",
+		Value: rerunActionValue,
+		Confirm: &slack.ConfirmationField{
+			Text:   "Rerun this pipeline?",
+			OkText: "Rerun",
+		},
+	}
+}
+
+// RegisterActions registers the handlers of the actions on the messages of the bot
+func (o *SlackBotOptions) RegisterActions(handler *interaction.Handler) {
+	handler.Register(PipelineActivityCallbackPrefix, o.RerunAction)
+}
+
+// RerunAction annotates the PipelineActivity of the pipeline message whose Rerun button was clicked, recording when
+// and by whom the rerun was requested. The other buttons of the message are links so they are ignored.
+func (o *SlackBotOptions) RerunAction(ctx context.Context, action interaction.Action) (*slack.Msg, error) {
+	if action.Value != rerunActionValue {
+		return nil, nil
+	}
+	// both annotations are set with a single merge patch, which doesn't need to read the PipelineActivity first
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				RerunAnnotation:            time.Now().UTC().Format(time.RFC3339),
+				RerunRequestedByAnnotation: action.UserID,
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshaling the rerun patch of PipelineActivity %s", action.ID)
+	}
+	_, err = o.JXClient.JenkinsV1().PipelineActivities(o.Namespace).Patch(action.ID, types.MergePatchType, patch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "annotating PipelineActivity %s", action.ID)
+	}
+	log.Logger().Infof("Rerun of %s requested by %s\n", action.ID, action.UserID)
+	return &slack.Msg{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         fmt.Sprintf("Requested a rerun of %s", action.ID),
+	}, nil
+}
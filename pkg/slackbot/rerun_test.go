@@ -0,0 +1,69 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/slack/pkg/slackbot/interaction"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSlackBotOptions_RerunAction(t *testing.T) {
+	pa := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: "jenkins-x-labs-jxl-master-1", Namespace: "jx"},
+	}
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: "jx",
+			JXClient:  jxfake.NewSimpleClientset(pa),
+		},
+		Namespace: "jx",
+	}
+	ctx := context.Background()
+
+	msg, err := o.RerunAction(ctx, interaction.Action{ID: pa.Name, Value: "rerun", UserID: "U1"})
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, slack.ResponseTypeEphemeral, msg.ResponseType)
+	annotated, err := o.getPipelineActivity(pa.Name)
+	require.NoError(t, err)
+	assert.NotEmpty(t, annotated.Annotations[RerunAnnotation])
+	assert.Equal(t, "U1", annotated.Annotations[RerunRequestedByAnnotation])
+
+	// the link buttons of the message are ignored
+	msg, err = o.RerunAction(ctx, interaction.Action{ID: pa.Name, UserID: "U1"})
+	assert.NoError(t, err)
+	assert.Nil(t, msg)
+
+	_, err = o.RerunAction(ctx, interaction.Action{ID: "missing", Value: "rerun", UserID: "U1"})
+	assert.Error(t, err)
+}
+
+func TestSlackBotOptions_createPipelineMessage_rerunButton(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+	act.Status = v1alpha1.FailureState
+	o := &SlackBotOptions{RerunButton: true}
+
+	attachments, _, err := o.createPipelineMessage(act, nil, nil, o.Statuses, false)
+	require.NoError(t, err)
+	assert.Contains(t, attachments[0].Actions, rerunAction())
+	blocks := attachmentsToBlocks(attachments)
+	actionBlock, ok := blocks[1].(*slack.ActionBlock)
+	require.True(t, ok, "expected an action block but got %T", blocks[1])
+	assert.Equal(t, "pipelineactivity:"+act.Name, actionBlock.BlockID)
+
+	// only the failed pipelines can be rerun
+	act.Status = v1alpha1.SuccessState
+	attachments, _, err = o.createPipelineMessage(act, nil, nil, o.Statuses, false)
+	require.NoError(t, err)
+	assert.NotContains(t, attachments[0].Actions, rerunAction())
+}
@@ -0,0 +1,72 @@
+package slackbot
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// DefaultMaxRetries is the number of times a failed Slack API call is retried by default
+	DefaultMaxRetries = 3
+	// DefaultRetryBackoff is the initial delay before retrying a failed Slack API call, it doubles on each retry
+	DefaultRetryBackoff = time.Second
+)
+
+// retryable is implemented by the Slack errors which can be retried, such as rate limits and server errors
+type retryable interface {
+	Retryable() bool
+}
+
+// postWithRetry calls the Slack API using post, retrying with exponential backoff up to MaxRetries times if it fails
+// with a retryable error. Rate limited calls wait for the delay requested by Slack instead. Each attempt is given
+// its own context, which times out after the ExternalCallTimeout.
+func (o *SlackBotOptions) postWithRetry(ctx context.Context, description string,
+	post func(ctx context.Context) error) error {
+	atomic.AddInt32(&o.inFlight, 1)
+	defer atomic.AddInt32(&o.inFlight, -1)
+	backoff := o.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		if ctx.Err() != nil {
+			return contextError(ctx, description)
+		}
+		attemptCtx, cancel := o.withTimeout(ctx)
+		err := post(attemptCtx)
+		timedOut := attemptCtx.Err() == context.DeadlineExceeded
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if timedOut {
+			return errors.Wrapf(err, "timed out %s after %s", description, o.ExternalCallTimeout)
+		}
+		if attempt >= o.MaxRetries || !isRetryable(err) {
+			return err
+		}
+		delay := backoff
+		if rateLimited, ok := errors.Cause(err).(*slack.RateLimitedError); ok {
+			delay = rateLimited.RetryAfter
+		} else {
+			backoff *= 2
+		}
+		log.Logger().Warnf("%s failed, retrying in %s (attempt %d of %d): %v", description, delay, attempt+1,
+			o.MaxRetries, err)
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%s cancelled while waiting to retry after %v", description, err)
+		case <-time.After(delay):
+		}
+	}
+}
+
+func isRetryable(err error) bool {
+	r, ok := errors.Cause(err).(retryable)
+	return ok && r.Retryable()
+}
@@ -0,0 +1,77 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_postMessageRetriesRateLimit(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	rateLimited := false
+	recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+		if call.Method == "chat.postMessage" && !rateLimited {
+			rateLimited = true
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return true
+		}
+		return false
+	}
+	o := &SlackBotOptions{
+		SlackClient:  recorder.client(),
+		Timestamps:   make(map[string]map[string]*MessageReference),
+		MaxRetries:   DefaultMaxRetries,
+		RetryBackoff: time.Millisecond,
+	}
+	act, err := getPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	err = o.postMessage("#test", false, pipelineMessageType, act, nil, nil, nil, true)
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("chat.postMessage"), 2)
+	assert.NotNil(t, o.Timestamps["#test"][act.Name])
+}
+
+func TestSlackBotOptions_postWithRetry(t *testing.T) {
+	o := &SlackBotOptions{
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	}
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{name: "success", err: nil, wantCalls: 1},
+		{name: "not_retryable", err: fmt.Errorf("channel_not_found"), wantCalls: 1},
+		{name: "retryable", err: retryableError{}, wantCalls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := o.postWithRetry(context.Background(), "test", func(ctx context.Context) error {
+				calls++
+				return tt.err
+			})
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+type retryableError struct{}
+
+func (retryableError) Error() string {
+	return "server error"
+}
+
+func (retryableError) Retryable() bool {
+	return true
+}
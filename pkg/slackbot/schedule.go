@@ -0,0 +1,217 @@
+package slackbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/jenkins-x/slack/pkg/slackbot/interaction"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+// MessageScheduler schedules messages to be posted by Slack later, such as at the end of a maintenance window
+type MessageScheduler interface {
+	ScheduleMessageContext(ctx context.Context, channelID string, postAt time.Time, options ...slack.MsgOption) (
+		string, string, error)
+	DeleteScheduledMessageContext(ctx context.Context, params *slack.DeleteScheduledMessageParameters) (bool, error)
+}
+
+// HistoryReader reads the messages of the Slack channels, implemented by *slack.Client
+type HistoryReader interface {
+	GetConversationHistoryContext(ctx context.Context, params *slack.GetConversationHistoryParameters) (
+		*slack.GetConversationHistoryResponse, error)
+}
+
+// scheduledMessageDelay is how long after the end of the maintenance window the scheduled messages are looked for,
+// as Slack may post them a bit late
+const scheduledMessageDelay = 5 * time.Minute
+
+// slackScheduler schedules messages with the Slack API. The Slack library can schedule messages but doesn't return
+// their ID, which is needed to cancel them
+type slackScheduler struct {
+	*slack.Client
+	token  string
+	apiURL string
+	// httpClient sends the requests, http.DefaultClient if nil
+	httpClient *http.Client
+}
+
+// ScheduleMessageContext schedules the message to be posted to the channel at postAt, returning the ID of the channel
+// and of the scheduled message
+func (s *slackScheduler) ScheduleMessageContext(ctx context.Context, channelID string, postAt time.Time,
+	options ...slack.MsgOption) (string, string, error) {
+	options = append(options, slack.MsgOptionSchedule(strconv.FormatInt(postAt.Unix(), 10)))
+	endpoint, values, err := slack.UnsafeApplyMsgOptions(s.token, channelID, s.apiURL, options...)
+	if err != nil {
+		return "", "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := s.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("scheduling message: %s", resp.Status)
+	}
+	response := struct {
+		slack.SlackResponse
+		Channel            string `json:"channel"`
+		ScheduledMessageID string `json:"scheduled_message_id"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return "", "", errors.Wrap(err, "decoding the scheduled message")
+	}
+	return response.Channel, response.ScheduledMessageID, response.Err()
+}
+
+// maintenanceWindowEnd returns the end of the maintenance window now is in, merging the overlapping windows, and
+// false if now is not in a maintenance window
+func maintenanceWindowEnd(windows []slackapp.MaintenanceWindow, now time.Time) (time.Time, bool) {
+	end, active := now, false
+	for extended := true; extended; {
+		extended = false
+		for _, window := range windows {
+			if !end.Before(window.Start.Time) && end.Before(window.End.Time) {
+				end, active, extended = window.End.Time, true, true
+			}
+		}
+	}
+	return end, active
+}
+
+// messageScheduler returns the scheduler of the messages of the workspace, or nil if its messages can't be scheduled
+func (o *SlackBotOptions) messageScheduler(workspace string) MessageScheduler {
+	client := o.slackClientFor(workspace)
+	if scheduler, ok := client.(MessageScheduler); ok {
+		return scheduler
+	}
+	if c, ok := client.(*slack.Client); ok && workspace == "" {
+		o.slackClientLock.RLock()
+		defer o.slackClientLock.RUnlock()
+		return &slackScheduler{Client: c, token: o.token, apiURL: o.slackAPIURL(), httpClient: o.httpClient()}
+	}
+	return nil
+}
+
+// scheduleMessage schedules the new message for the activity to be posted to the channel at postAt, cancelling the
+// message already scheduled for the activity, which it supersedes. The reference to the scheduled message is stored
+// like the ones of the posted messages, so that it survives restarts. It returns false if the message can't be
+// scheduled and should be posted straight away instead
+func (o *SlackBotOptions) scheduleMessage(ctx context.Context, channel string, workspace string, channelID string,
+	messageType string, activity *record.ActivityRecord, messageRef *MessageReference, options []slack.MsgOption,
+	hash string, postAt time.Time) (bool, error) {
+	scheduler := o.messageScheduler(workspace)
+	if scheduler == nil {
+		log.Logger().Warnf("cannot schedule the message for %s in %s, posting it now", activity.Name, channel)
+		return false, nil
+	}
+	if messageRef != nil {
+		err := o.cancelScheduledMessage(ctx, messageRef)
+		if err != nil {
+			return true, errors.Wrapf(err, "cancelling the scheduled message for %s in %s", activity.Name, channel)
+		}
+	}
+	var scheduledChannelID, id string
+	err := o.postWithRetry(ctx, "scheduling message", func(ctx context.Context) error {
+		defer observeSlackAPICall("chat.scheduleMessage", time.Now())
+		var err error
+		scheduledChannelID, id, err = scheduler.ScheduleMessageContext(ctx, channelID, postAt, options...)
+		return err
+	})
+	if err != nil {
+		messagesFailed.WithLabelValues(messageType).Inc()
+		return true, errors.Wrapf(err, "scheduling the message for %s in %s", activity.Name, channel)
+	}
+	o.storeMessageReference(channel, activity.Name, &MessageReference{
+		Workspace:          workspace,
+		ChannelID:          scheduledChannelID,
+		Hash:               hash,
+		Status:             pipelineStatus(activity),
+		ScheduledMessageID: id,
+		ScheduledPostAt:    postAt,
+	})
+	messageLogger(activity, channel, messageType).Infof("Scheduled message for %s at %s\n", activity.Name, postAt)
+	return true, nil
+}
+
+// cancelScheduledMessage deletes the message scheduled for the reference if it is not posted yet
+func (o *SlackBotOptions) cancelScheduledMessage(ctx context.Context, messageRef *MessageReference) error {
+	if messageRef.ScheduledMessageID == "" || !time.Now().Before(messageRef.ScheduledPostAt) {
+		return nil
+	}
+	scheduler := o.messageScheduler(messageRef.Workspace)
+	if scheduler == nil {
+		return nil
+	}
+	return o.postWithRetry(ctx, "cancelling scheduled message", func(ctx context.Context) error {
+		defer observeSlackAPICall("chat.deleteScheduledMessage", time.Now())
+		_, err := scheduler.DeleteScheduledMessageContext(ctx, &slack.DeleteScheduledMessageParameters{
+			Channel:            messageRef.ChannelID,
+			ScheduledMessageID: messageRef.ScheduledMessageID,
+		})
+		return err
+	})
+}
+
+// findScheduledMessage returns the timestamp of the message Slack posted for the scheduled message of the reference,
+// found in the history of its channel around the time it was due, and an empty string if it isn't found. Slack doesn't
+// tell the timestamp of the scheduled messages, so the message is the one whose callback or block IDs end with the name
+// of the activity
+func (o *SlackBotOptions) findScheduledMessage(ctx context.Context, activityName string,
+	messageRef *MessageReference) (string, error) {
+	reader, ok := o.slackClientFor(messageRef.Workspace).(HistoryReader)
+	if !ok || messageRef.ChannelID == "" {
+		return "", nil
+	}
+	var history *slack.GetConversationHistoryResponse
+	err := o.postWithRetry(ctx, "reading channel history", func(ctx context.Context) error {
+		defer observeSlackAPICall("conversations.history", time.Now())
+		var err error
+		history, err = reader.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+			ChannelID: messageRef.ChannelID,
+			Oldest:    strconv.FormatInt(messageRef.ScheduledPostAt.Add(-time.Minute).Unix(), 10),
+			Latest:    strconv.FormatInt(messageRef.ScheduledPostAt.Add(scheduledMessageDelay).Unix(), 10),
+			Inclusive: true,
+			Limit:     200,
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	suffix := ":" + activityName
+	for _, message := range history.Messages {
+		for _, attachment := range message.Attachments {
+			if strings.HasSuffix(attachment.CallbackID, suffix) {
+				return message.Timestamp, nil
+			}
+		}
+		for _, block := range message.Blocks.BlockSet {
+			if action, ok := block.(*slack.ActionBlock); ok {
+				blockID := strings.SplitN(action.BlockID, interaction.BlockIDSeparator, 2)[0]
+				if strings.HasSuffix(blockID, suffix) {
+					return message.Timestamp, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,140 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_maintenanceWindowEnd(t *testing.T) {
+	at := func(hour int) metav1.Time {
+		return metav1.NewTime(time.Date(2020, 6, 1, hour, 0, 0, 0, time.UTC))
+	}
+	windows := []slackapp.MaintenanceWindow{
+		{Start: at(22), End: at(23)},
+		{Start: at(10), End: at(12)},
+		{Start: at(11), End: at(13)},
+	}
+
+	_, active := maintenanceWindowEnd(windows, at(9).Time)
+	assert.False(t, active)
+	end, active := maintenanceWindowEnd(windows, at(22).Time)
+	assert.True(t, active)
+	assert.Equal(t, at(23).Time, end)
+
+	// the overlapping windows are merged
+	end, active = maintenanceWindowEnd(windows, at(10).Time)
+	assert.True(t, active)
+	assert.Equal(t, at(13).Time, end)
+
+	_, active = maintenanceWindowEnd(windows, at(23).Time)
+	assert.False(t, active, "the window is over at its end")
+}
+
+func TestSlackBotOptions_PipelineMessage_maintenanceWindow(t *testing.T) {
+	client := &fakeSlackClient{}
+	end := time.Now().Add(time.Hour).Truncate(time.Second)
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines:   []slackapp.SlackBotMode{{Channel: "#cheese"}},
+		MaintenanceWindows: []slackapp.MaintenanceWindow{{
+			Start: metav1.NewTime(time.Now().Add(-time.Hour)),
+			End:   metav1.NewTime(end),
+		}},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+
+	act.Status = v1alpha1.RunningState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Empty(t, client.callsTo("chat.postMessage"))
+	scheduled := client.callsTo("chat.scheduleMessage")
+	require.Len(t, scheduled, 1)
+	assert.Equal(t, fmt.Sprint(end.Unix()), scheduled[0].Values.Get("post_at"))
+
+	// the scheduled message is superseded by the next one
+	act.Status = v1alpha1.SuccessState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.scheduleMessage"), 2)
+	deleted := client.callsTo("chat.deleteScheduledMessage")
+	require.Len(t, deleted, 1)
+	assert.NotEmpty(t, deleted[0].Values.Get("scheduled_message_id"))
+	assert.Empty(t, client.callsTo("chat.postMessage"))
+	ref := o.messageReference("#cheese", act.Name)
+	require.NotNil(t, ref)
+	// the reference is stored, so that the scheduled message survives restarts
+	assert.NotEmpty(t, ref.ScheduledMessageID)
+	assert.NotEqual(t, deleted[0].Values.Get("scheduled_message_id"), ref.ScheduledMessageID)
+	assert.Equal(t, end, ref.ScheduledPostAt)
+
+	// once Slack posted the scheduled message, it is found in the history of the channel and updated
+	o.MaintenanceWindows = nil
+	ref.ScheduledPostAt = time.Now().Add(-time.Minute)
+	client.history = []slack.Message{
+		{Msg: slack.Msg{Timestamp: "1.000200", Attachments: []slack.Attachment{{CallbackID: "preview:other"}}}},
+		{Msg: slack.Msg{Timestamp: "1.000100", Attachments: []slack.Attachment{
+			{CallbackID: PipelineActivityCallbackPrefix + ":" + act.Name},
+		}}},
+	}
+	act.Status = v1alpha1.FailureState
+	require.NoError(t, o.PipelineMessage(act))
+	history := client.callsTo("conversations.history")
+	require.Len(t, history, 1)
+	assert.Equal(t, fmt.Sprint(ref.ScheduledPostAt.Add(-time.Minute).Unix()), history[0].Values.Get("oldest"))
+	assert.Empty(t, client.callsTo("chat.postMessage"))
+	updates := client.callsTo("chat.update")
+	require.Len(t, updates, 1)
+	assert.Equal(t, "1.000100", updates[0].Values.Get("ts"))
+	ref = o.messageReference("#cheese", act.Name)
+	assert.Equal(t, "1.000100", ref.Timestamp)
+	assert.Empty(t, ref.ScheduledMessageID)
+
+	// a scheduled message which can't be found is posted again with the latest status
+	posted := *act
+	posted.Name = "jenkins-x-labs-jxl-master-13"
+	o.storeMessageReference("#cheese", posted.Name, &MessageReference{ChannelID: "C0001",
+		ScheduledMessageID: "Q0002", ScheduledPostAt: time.Now().Add(-time.Minute)})
+	require.NoError(t, o.PipelineMessage(&posted))
+	assert.Len(t, client.callsTo("conversations.history"), 2)
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+
+	// once the window is over the messages are posted straight away
+	other := *act
+	other.Name = "jenkins-x-labs-jxl-master-14"
+	require.NoError(t, o.PipelineMessage(&other))
+	assert.Len(t, client.callsTo("chat.postMessage"), 2)
+}
+
+func Test_slackScheduler(t *testing.T) {
+	var values map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat.scheduleMessage", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		values = r.PostForm
+		fmt.Fprint(w, `{"ok": true, "channel": "C0001", "scheduled_message_id": "Q1298393284"}`)
+	}))
+	defer server.Close()
+	scheduler := &slackScheduler{token: validToken, apiURL: server.URL + "/"}
+
+	postAt := time.Date(2020, 6, 1, 23, 0, 0, 0, time.UTC)
+	channelID, id, err := scheduler.ScheduleMessageContext(context.Background(), "C0001", postAt,
+		slack.MsgOptionText("cheese", false))
+	require.NoError(t, err)
+	assert.Equal(t, "C0001", channelID)
+	assert.Equal(t, "Q1298393284", id)
+	assert.Equal(t, []string{fmt.Sprint(postAt.Unix())}, values["post_at"])
+	assert.Equal(t, []string{"cheese"}, values["text"])
+}
@@ -0,0 +1,146 @@
+package slackbot
+
+import (
+	"context"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+// ErrMissingScope is the error of the scope checks for the scopes the token lacks
+var ErrMissingScope = errors.New("missing_scope")
+
+// probeChannel, probeUser and probeTimestamp don't exist, so that the probes fail once the scope has been checked
+// rather than changing anything
+const (
+	probeChannel        = "C00000000"
+	probePrivateChannel = "G00000000"
+	probeUser           = "U00000000"
+	probeTimestamp      = "0000000000.000000"
+	probeEmail          = "slack-check@example.invalid"
+)
+
+// ScopeCheck is the result of checking a scope the bot uses
+type ScopeCheck struct {
+	Scope string
+	// Essential is true for the scopes the messages can't be posted without, the others are used by some features
+	Essential bool
+	// Err is nil if the token has the scope, ErrMissingScope if it doesn't, or the error of the check
+	Err error
+}
+
+// scopeProbe checks a scope with a low-impact call, which is expected to fail with one of the errors for a token with
+// the scope
+type scopeProbe struct {
+	scope     string
+	essential bool
+	expected  []string
+	call      func(ctx context.Context, client *slack.Client) error
+}
+
+var scopeProbes = []scopeProbe{
+	{
+		scope:     "chat:write",
+		essential: true,
+		expected:  []string{"channel_not_found", "message_not_found"},
+		call: func(ctx context.Context, client *slack.Client) error {
+			_, _, err := client.DeleteMessageContext(ctx, probeChannel, probeTimestamp)
+			return err
+		},
+	},
+	{
+		scope: "channels:read",
+		call: func(ctx context.Context, client *slack.Client) error {
+			_, _, err := client.GetConversationsContext(ctx, &slack.GetConversationsParameters{Limit: 1})
+			return err
+		},
+	},
+	{
+		scope:    "im:write",
+		expected: []string{"user_not_found", "user_disabled"},
+		call: func(ctx context.Context, client *slack.Client) error {
+			_, _, _, err := client.OpenConversationContext(ctx, &slack.OpenConversationParameters{
+				Users: []string{probeUser},
+			})
+			return err
+		},
+	},
+	{
+		scope:    "reactions:write",
+		expected: []string{"channel_not_found", "message_not_found"},
+		call: func(ctx context.Context, client *slack.Client) error {
+			return client.AddReactionContext(ctx, "white_check_mark", slack.NewRefToMessage(probeChannel,
+				probeTimestamp))
+		},
+	},
+	{
+		scope:    "pins:write",
+		expected: []string{"channel_not_found", "message_not_found", "no_pin"},
+		call: func(ctx context.Context, client *slack.Client) error {
+			return client.RemovePinContext(ctx, probeChannel, slack.NewRefToMessage(probeChannel, probeTimestamp))
+		},
+	},
+	{
+		scope:    "channels:manage",
+		expected: []string{"channel_not_found"},
+		call: func(ctx context.Context, client *slack.Client) error {
+			_, err := client.SetTopicOfConversationContext(ctx, probeChannel, "")
+			return err
+		},
+	},
+	{
+		scope:    "groups:write",
+		expected: []string{"channel_not_found"},
+		call: func(ctx context.Context, client *slack.Client) error {
+			_, err := client.SetTopicOfConversationContext(ctx, probePrivateChannel, "")
+			return err
+		},
+	},
+	{
+		scope:    "channels:history",
+		expected: []string{"channel_not_found"},
+		call: func(ctx context.Context, client *slack.Client) error {
+			_, err := client.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+				ChannelID: probeChannel,
+				Limit:     1,
+			})
+			return err
+		},
+	},
+	{
+		scope:    "users:read",
+		expected: []string{"user_not_found"},
+		call: func(ctx context.Context, client *slack.Client) error {
+			_, err := client.GetUserInfoContext(ctx, probeUser)
+			return err
+		},
+	},
+	{
+		scope:    "users:read.email",
+		expected: []string{"users_not_found"},
+		call: func(ctx context.Context, client *slack.Client) error {
+			_, err := client.GetUserByEmailContext(ctx, probeEmail)
+			return err
+		},
+	},
+}
+
+// CheckSlackScopes checks the token of the client has the scopes the bot uses, by making calls which fail without
+// changing anything once the scope has been checked
+func CheckSlackScopes(ctx context.Context, client *slack.Client) []ScopeCheck {
+	checks := make([]ScopeCheck, 0, len(scopeProbes))
+	for _, probe := range scopeProbes {
+		check := ScopeCheck{Scope: probe.scope, Essential: probe.essential}
+		err := probe.call(ctx, client)
+		switch {
+		case err == nil:
+		case err.Error() == ErrMissingScope.Error():
+			check.Err = ErrMissingScope
+		case !util.Contains(probe.expected, err.Error()):
+			check.Err = err
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
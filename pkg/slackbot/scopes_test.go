@@ -0,0 +1,51 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSlackScopes(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+		switch call.Method {
+		case "chat.delete", "reactions.add":
+			fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+		case "conversations.list":
+			fmt.Fprint(w, `{"ok":true,"channels":[]}`)
+		case "pins.remove", "users.lookupByEmail":
+			fmt.Fprint(w, `{"ok":false,"error":"missing_scope"}`)
+		case "users.info":
+			fmt.Fprint(w, `{"ok":false,"error":"ratelimited"}`)
+		default:
+			return false
+		}
+		return true
+	}
+
+	checks := CheckSlackScopes(context.Background(), recorder.client())
+	results := map[string]error{}
+	for _, check := range checks {
+		results[check.Scope] = check.Err
+		assert.Equal(t, check.Scope == "chat:write", check.Essential, "only chat:write is essential")
+	}
+	require.Len(t, results, len(scopeProbes))
+	// the calls failing once the scope has been checked, or succeeding, mean the token has the scope
+	assert.NoError(t, results["chat:write"])
+	assert.NoError(t, results["channels:read"])
+	assert.NoError(t, results["reactions:write"])
+	assert.Equal(t, ErrMissingScope, results["pins:write"])
+	assert.Equal(t, ErrMissingScope, results["users:read.email"])
+	assert.EqualError(t, results["users:read"], "ratelimited")
+	// the direct messages are opened with a user which doesn't exist
+	opens := recorder.callsTo("conversations.open")
+	require.Len(t, opens, 1)
+	assert.Equal(t, probeUser, opens[0].Values.Get("users"))
+	assert.Empty(t, recorder.callsTo("chat.postMessage"), "nothing should be posted")
+}
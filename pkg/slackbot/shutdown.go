@@ -0,0 +1,48 @@
+package slackbot
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// DefaultShutdownGracePeriod is how long the pending updates are given to be sent when the bot is terminated
+const DefaultShutdownGracePeriod = 30 * time.Second
+
+// shutdownPollInterval is how often Shutdown checks whether the Slack API calls being made have completed
+const shutdownPollInterval = 50 * time.Millisecond
+
+// Shutdown sends the debounced updates straight away, then waits for the Slack API calls being made and the messages
+// queued for the webhooks to complete or for the context to be done, whichever comes first. The events received once
+// the bot is shut down are still handled, so the caller should stop receiving them first.
+func (o *SlackBotOptions) Shutdown(ctx context.Context) error {
+	flushed := make(chan error, 1)
+	go func() {
+		flushed <- o.Debouncer.Flush()
+	}()
+	select {
+	case err := <-flushed:
+		if err != nil {
+			return errors.Wrapf(err, "flushing the pending updates of %s", o.Name)
+		}
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "flushing the pending updates of %s", o.Name)
+	}
+	for {
+		calls := atomic.LoadInt32(&o.inFlight)
+		webhooks := atomic.LoadInt32(&o.pendingWebhooks)
+		if calls == 0 && webhooks == 0 {
+			log.Logger().Infof("SlackBot %s shut down\n", o.Name)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "waiting for %d Slack API calls and %d webhook messages of %s to complete",
+				calls, webhooks, o.Name)
+		case <-time.After(shutdownPollInterval):
+		}
+	}
+}
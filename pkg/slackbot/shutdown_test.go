@@ -0,0 +1,51 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_Shutdown(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Debouncer:   NewDebouncer(time.Hour),
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	act.Status = v1alpha1.RunningState
+	o.storeMessageReference("#cheese", act.Name, &MessageReference{ChannelID: "C0001", Timestamp: "1.000100"})
+	attachments := []slack.Attachment{{Text: "running"}}
+
+	// the update of the running pipeline is debounced
+	err = o.postMessage("#cheese", false, pipelineMessageType, act, nil, attachments, nil, true)
+	require.NoError(t, err)
+	assert.Empty(t, client.callsTo("chat.update"))
+
+	err = o.Shutdown(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, client.callsTo("chat.update"), 1)
+
+	// calls which don't complete within the grace period are reported
+	release := make(chan struct{})
+	go func() {
+		_ = o.postWithRetry(context.Background(), "posting cheese", func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+	}()
+	defer close(release)
+	assert.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		return o.Shutdown(ctx) != nil
+	}, time.Second, 10*time.Millisecond)
+}
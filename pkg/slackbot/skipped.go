@@ -0,0 +1,28 @@
+package slackbot
+
+import (
+	"strconv"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+)
+
+// SkippedAnnotation is set to true on a PipelineActivity whose pipeline was skipped on purpose, such as when the
+// changed paths don't need a build, so that its pull request isn't shown as pending forever
+const SkippedAnnotation = "slack.apps.jenkins-x.io/skipped"
+
+// pipelineSkipped returns true if the pipeline of the activity was skipped, as recorded on its PipelineActivity. Only
+// the pipelines which haven't started can be skipped.
+func pipelineSkipped(activity *record.ActivityRecord, pa *jenkinsv1.PipelineActivity) bool {
+	switch activity.Status {
+	case "", v1alpha1.TriggeredState, v1alpha1.PendingState:
+	default:
+		return false
+	}
+	if pa == nil {
+		return false
+	}
+	skipped, _ := strconv.ParseBool(pa.Annotations[SkippedAnnotation])
+	return skipped
+}
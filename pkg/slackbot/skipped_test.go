@@ -0,0 +1,43 @@
+package slackbot
+
+import (
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSlackBotOptions_createReviewersMessage_skipped(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Status = v1alpha1.PendingState
+	act.Stages = nil
+	pa := &jenkinsv1.PipelineActivity{ObjectMeta: metav1.ObjectMeta{Name: act.Name, Namespace: "jx"}}
+	clients, resolver := newReviewClients(jxfake.NewSimpleClientset(pa), nil)
+	o := &SlackBotOptions{GlobalClients: clients}
+	pr := &gits.GitPullRequest{URL: "https://github.com/jenkins-x-labs/jxl/pull/83", Title: "Fix the README"}
+
+	_, _, buildStatus, err := o.createReviewersMessage(act, pa, pr, resolver, o.Statuses,
+		reviewMessageOptions{mention: true})
+	require.NoError(t, err)
+	assert.Equal(t, defaultStatuses.Pending, buildStatus)
+
+	pa.Annotations = map[string]string{SkippedAnnotation: "true"}
+	attachments, _, buildStatus, err := o.createReviewersMessage(act, pa, pr, resolver, o.Statuses,
+		reviewMessageOptions{mention: true})
+	require.NoError(t, err)
+	assert.Equal(t, defaultStatuses.Skipped, buildStatus)
+	assert.Equal(t, ":fast_forward: build skipped", attachments[0].Fields[1].Value)
+
+	// a pipeline which started anyway isn't skipped
+	act.Status = v1alpha1.RunningState
+	_, _, buildStatus, err = o.createReviewersMessage(act, pa, pr, resolver, o.Statuses,
+		reviewMessageOptions{mention: true})
+	require.NoError(t, err)
+	assert.Equal(t, defaultStatuses.Running, buildStatus)
+}
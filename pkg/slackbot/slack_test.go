@@ -1,9 +1,20 @@
 package slackbot
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
 )
 
 const (
@@ -20,3 +31,267 @@ func startServer() {
 	serverAddr = server.Listener.Addr().String()
 	log.Print("Test WebSocket server listening on ", serverAddr)
 }
+
+// slackCall is a Slack API call received by a slackRecorder
+type slackCall struct {
+	Method string
+	Values url.Values
+}
+
+// slackRecorder is a fake Slack API which records the calls made to it
+type slackRecorder struct {
+	sync.Mutex
+	server *httptest.Server
+	calls  []slackCall
+	ts     int
+	// handler can be set to override the response for a call, returning false falls back to the default response
+	handler func(call slackCall, w http.ResponseWriter) bool
+}
+
+func newSlackRecorder(t *testing.T) *slackRecorder {
+	r := &slackRecorder{}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseForm(); err != nil {
+			t.Errorf("failed to parse slack request: %v", err)
+		}
+		call := slackCall{
+			Method: strings.TrimPrefix(req.URL.Path, "/"),
+			Values: req.Form,
+		}
+		r.Lock()
+		r.calls = append(r.calls, call)
+		r.ts++
+		ts := fmt.Sprintf("%d.000100", r.ts)
+		handler := r.handler
+		r.Unlock()
+		if handler != nil && handler(call, w) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch call.Method {
+		case "chat.update":
+			ts = call.Values.Get("ts")
+		case "conversations.open":
+			fmt.Fprint(w, `{"ok":true,"channel":{"id":"D0001"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"ok":true,"channel":"C0001","ts":"%s"}`, ts)
+	}))
+	return r
+}
+
+// Close shuts down the fake Slack API
+func (r *slackRecorder) Close() {
+	r.server.Close()
+}
+
+// client returns a Slack client which talks to the recorder
+func (r *slackRecorder) client() *slack.Client {
+	return slack.New(validToken, slack.OptionAPIURL(r.server.URL+"/"))
+}
+
+// callsTo returns the recorded calls to the Slack API method
+func (r *slackRecorder) callsTo(method string) []slackCall {
+	r.Lock()
+	defer r.Unlock()
+	calls := []slackCall{}
+	for _, c := range r.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// fakeSlackClient is an in-memory SlackClienter which records the calls made to it
+type fakeSlackClient struct {
+	sync.Mutex
+	calls []slackCall
+	ts    int
+	// err is returned by every call when set
+	err error
+	// delay is how long every call takes
+	delay time.Duration
+	// avatars are the profile images of the users, keyed by ID
+	avatars map[string]string
+	// users are the IDs of the users, keyed by email
+	users map[string]string
+	// history are the messages of the channels
+	history []slack.Message
+}
+
+var _ SlackClienter = &fakeSlackClient{}
+var _ MessageScheduler = &fakeSlackClient{}
+var _ UserInfoGetter = &fakeSlackClient{}
+var _ TopicSetter = &fakeSlackClient{}
+var _ UserByEmailGetter = &fakeSlackClient{}
+var _ HistoryReader = &fakeSlackClient{}
+
+func (f *fakeSlackClient) record(method string, values url.Values) (string, error) {
+	time.Sleep(f.delay)
+	f.Lock()
+	defer f.Unlock()
+	f.calls = append(f.calls, slackCall{Method: method, Values: values})
+	f.ts++
+	return fmt.Sprintf("%d.000100", f.ts), f.err
+}
+
+// callsTo returns the recorded calls to the Slack API method
+func (f *fakeSlackClient) callsTo(method string) []slackCall {
+	f.Lock()
+	defer f.Unlock()
+	calls := []slackCall{}
+	for _, c := range f.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (f *fakeSlackClient) SendMessageContext(ctx context.Context, channelID string,
+	options ...slack.MsgOption) (string, string, string, error) {
+	// without an API URL the endpoint is the name of the method, chat.postMessage or chat.update
+	method, values, err := slack.UnsafeApplyMsgOptions("", channelID, "", options...)
+	if err != nil {
+		return "", "", "", err
+	}
+	ts, err := f.record(method, values)
+	if err != nil {
+		return "", "", "", err
+	}
+	if method == "chat.update" {
+		ts = values.Get("ts")
+	}
+	if strings.HasPrefix(channelID, "#") {
+		channelID = "C0001"
+	}
+	return channelID, ts, values.Get("text"), nil
+}
+
+func (f *fakeSlackClient) OpenConversationContext(ctx context.Context,
+	params *slack.OpenConversationParameters) (*slack.Channel, bool, bool, error) {
+	_, err := f.record("conversations.open", url.Values{"users": {strings.Join(params.Users, ",")}})
+	if err != nil {
+		return nil, false, false, err
+	}
+	channel := &slack.Channel{}
+	channel.ID = "D0001"
+	return channel, false, false, nil
+}
+
+func (f *fakeSlackClient) AddReactionContext(ctx context.Context, name string, item slack.ItemRef) error {
+	_, err := f.record("reactions.add", url.Values{"name": {name}, "channel": {item.Channel},
+		"timestamp": {item.Timestamp}})
+	return err
+}
+
+func (f *fakeSlackClient) RemoveReactionContext(ctx context.Context, name string, item slack.ItemRef) error {
+	_, err := f.record("reactions.remove", url.Values{"name": {name}, "channel": {item.Channel},
+		"timestamp": {item.Timestamp}})
+	return err
+}
+
+func (f *fakeSlackClient) PostEphemeralContext(ctx context.Context, channelID string, userID string,
+	options ...slack.MsgOption) (string, error) {
+	_, values, err := slack.UnsafeApplyMsgOptions("", channelID, "", options...)
+	if err != nil {
+		return "", err
+	}
+	values.Set("user", userID)
+	return f.record("chat.postEphemeral", values)
+}
+
+func (f *fakeSlackClient) AddPinContext(ctx context.Context, channel string, item slack.ItemRef) error {
+	_, err := f.record("pins.add", url.Values{"channel": {channel}, "timestamp": {item.Timestamp}})
+	return err
+}
+
+func (f *fakeSlackClient) RemovePinContext(ctx context.Context, channel string, item slack.ItemRef) error {
+	_, err := f.record("pins.remove", url.Values{"channel": {channel}, "timestamp": {item.Timestamp}})
+	return err
+}
+
+func (f *fakeSlackClient) DeleteMessageContext(ctx context.Context, channel string,
+	messageTimestamp string) (string, string, error) {
+	_, err := f.record("chat.delete", url.Values{"channel": {channel}, "ts": {messageTimestamp}})
+	return channel, messageTimestamp, err
+}
+
+func (f *fakeSlackClient) GetConversationsContext(ctx context.Context,
+	params *slack.GetConversationsParameters) ([]slack.Channel, string, error) {
+	_, err := f.record("conversations.list", url.Values{"cursor": {params.Cursor}})
+	return nil, "", err
+}
+
+func (f *fakeSlackClient) GetConversationHistoryContext(ctx context.Context,
+	params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+	_, err := f.record("conversations.history", url.Values{"channel": {params.ChannelID}, "oldest": {params.Oldest},
+		"latest": {params.Latest}})
+	if err != nil {
+		return nil, err
+	}
+	f.Lock()
+	defer f.Unlock()
+	return &slack.GetConversationHistoryResponse{Messages: f.history}, nil
+}
+
+func (f *fakeSlackClient) GetUserInfoContext(ctx context.Context, user string) (*slack.User, error) {
+	_, err := f.record("users.info", url.Values{"user": {user}})
+	if err != nil {
+		return nil, err
+	}
+	answer := &slack.User{ID: user}
+	answer.Profile.Image48 = f.avatars[user]
+	return answer, nil
+}
+
+func (f *fakeSlackClient) GetUserByEmailContext(ctx context.Context, email string) (*slack.User, error) {
+	_, err := f.record("users.lookupByEmail", url.Values{"email": {email}})
+	if err != nil {
+		return nil, err
+	}
+	id, ok := f.users[email]
+	if !ok {
+		return nil, errors.New("users_not_found")
+	}
+	return &slack.User{ID: id}, nil
+}
+
+func (f *fakeSlackClient) SetTopicOfConversationContext(ctx context.Context, channelID,
+	topic string) (*slack.Channel, error) {
+	_, err := f.record("conversations.setTopic", url.Values{"channel": {channelID}, "topic": {topic}})
+	if err != nil {
+		return nil, err
+	}
+	channel := &slack.Channel{}
+	channel.ID = channelID
+	channel.Topic.Value = topic
+	return channel, nil
+}
+
+func (f *fakeSlackClient) AuthTestContext(ctx context.Context) (*slack.AuthTestResponse, error) {
+	_, err := f.record("auth.test", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return &slack.AuthTestResponse{}, nil
+}
+
+func (f *fakeSlackClient) ScheduleMessageContext(ctx context.Context, channelID string, postAt time.Time,
+	options ...slack.MsgOption) (string, string, error) {
+	_, values, err := slack.UnsafeApplyMsgOptions("", channelID, "", options...)
+	if err != nil {
+		return "", "", err
+	}
+	values.Set("post_at", strconv.FormatInt(postAt.Unix(), 10))
+	ts, err := f.record("chat.scheduleMessage", values)
+	return "C0001", "Q" + ts, err
+}
+
+func (f *fakeSlackClient) DeleteScheduledMessageContext(ctx context.Context,
+	params *slack.DeleteScheduledMessageParameters) (bool, error) {
+	_, err := f.record("chat.deleteScheduledMessage", url.Values{"channel": {params.Channel},
+		"scheduled_message_id": {params.ScheduledMessageID}})
+	return err == nil, err
+}
@@ -0,0 +1,239 @@
+package slashcommand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// DefaultResponseTimeout is how long the handler waits for a lookup before replying asynchronously, leaving some
+	// margin within the 3 seconds Slack waits for a response
+	DefaultResponseTimeout = 2 * time.Second
+	// DefaultLookupTimeout is how long an asynchronous lookup can take before it is abandoned, Slack accepts
+	// responses on the response_url for 30 minutes
+	DefaultLookupTimeout = 5 * time.Minute
+
+	usage = "Usage: `%s status <owner>/<repository>`"
+)
+
+// StatusLookup returns the attachments describing the latest pipeline of the repository
+type StatusLookup func(ctx context.Context, owner string, repo string) ([]slack.Attachment, error)
+
+// EphemeralPoster posts messages only visible to a user of a channel
+type EphemeralPoster interface {
+	PostEphemeralContext(ctx context.Context, channelID string, userID string, options ...slack.MsgOption) (string,
+		error)
+}
+
+// Handler handles Slack slash commands
+type Handler struct {
+	// SigningSecret is used to verify that the requests come from Slack
+	SigningSecret string
+	// Lookup looks up the latest pipeline status for a repository
+	Lookup StatusLookup
+	// ResponseTimeout is how long to wait for the lookup before replying asynchronously using the response_url
+	ResponseTimeout time.Duration
+	// LookupTimeout is how long an asynchronous lookup can take
+	LookupTimeout time.Duration
+	// HTTPClient is used to post asynchronous responses
+	HTTPClient *http.Client
+	// SlackClient posts the replies only the user who invoked the command should see, such as errors, as ephemeral
+	// messages. They are sent as ephemeral responses to the command if it is nil
+	SlackClient EphemeralPoster
+	// Context is the parent of the contexts of the lookups, which are abandoned once it is done. The lookups outlive
+	// the requests when they are answered asynchronously, so it is context.Background() if nil
+	Context context.Context
+}
+
+// ServeHTTP verifies and handles a slash command
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	command, err := h.parse(r)
+	if err != nil {
+		log.Logger().Warnf("rejecting slash command: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	args := strings.Fields(command.Text)
+	if len(args) != 2 || args[0] != "status" || strings.Count(args[1], "/") != 1 {
+		h.respondEphemeral(w, command, &slack.Msg{
+			ResponseType: slack.ResponseTypeEphemeral,
+			Text:         fmt.Sprintf(usage, command.Command),
+		})
+		return
+	}
+	parts := strings.SplitN(args[1], "/", 2)
+	owner, repo := parts[0], parts[1]
+
+	responses := make(chan *slack.Msg, 1)
+	ctx, cancel := context.WithTimeout(h.context(), h.lookupTimeout())
+	go func() {
+		defer cancel()
+		responses <- h.lookup(ctx, owner, repo)
+	}()
+
+	select {
+	case msg := <-responses:
+		if msg.ResponseType == slack.ResponseTypeEphemeral {
+			h.respondEphemeral(w, command, msg)
+		} else {
+			h.respond(w, msg)
+		}
+	case <-time.After(h.responseTimeout()):
+		h.respond(w, &slack.Msg{
+			ResponseType: slack.ResponseTypeEphemeral,
+			Text:         fmt.Sprintf("Looking up the status of %s/%s...", owner, repo),
+		})
+		go h.respondLater(command, responses)
+	}
+}
+
+// parse verifies the signature of the request and parses the slash command
+func (h *Handler) parse(r *http.Request) (slack.SlashCommand, error) {
+	verifier, err := slack.NewSecretsVerifier(r.Header, h.SigningSecret)
+	if err != nil {
+		return slack.SlashCommand{}, err
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return slack.SlashCommand{}, err
+	}
+	_, err = verifier.Write(body)
+	if err != nil {
+		return slack.SlashCommand{}, err
+	}
+	err = verifier.Ensure()
+	if err != nil {
+		return slack.SlashCommand{}, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return slack.SlashCommandParse(r)
+}
+
+func (h *Handler) lookup(ctx context.Context, owner string, repo string) *slack.Msg {
+	attachments, err := h.Lookup(ctx, owner, repo)
+	if err != nil {
+		log.Logger().Warnf("failed to look up the status of %s/%s: %v", owner, repo, err)
+		return &slack.Msg{
+			ResponseType: slack.ResponseTypeEphemeral,
+			Text:         fmt.Sprintf("Failed to look up the status of %s/%s", owner, repo),
+		}
+	}
+	if len(attachments) == 0 {
+		return &slack.Msg{
+			ResponseType: slack.ResponseTypeEphemeral,
+			Text:         fmt.Sprintf("No pipelines found for %s/%s", owner, repo),
+		}
+	}
+	return &slack.Msg{
+		ResponseType: slack.ResponseTypeInChannel,
+		Attachments:  attachments,
+	}
+}
+
+// PostEphemeral posts the message to the channel the slash command was invoked in, only visible to the user who invoked
+// it
+func (h *Handler) PostEphemeral(ctx context.Context, command slack.SlashCommand, msg *slack.Msg) error {
+	if h.SlackClient == nil {
+		return fmt.Errorf("no Slack client to post ephemeral messages with")
+	}
+	options := []slack.MsgOption{slack.MsgOptionText(msg.Text, false)}
+	if len(msg.Attachments) > 0 {
+		options = append(options, slack.MsgOptionAttachments(msg.Attachments...))
+	}
+	_, err := h.SlackClient.PostEphemeralContext(ctx, command.ChannelID, command.UserID, options...)
+	return err
+}
+
+// respondEphemeral replies with a message only visible to the user who invoked the command, posting it with the Slack
+// client if there is one and falling back to an ephemeral response
+func (h *Handler) respondEphemeral(w http.ResponseWriter, command slack.SlashCommand, msg *slack.Msg) {
+	if h.SlackClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), h.responseTimeout())
+		defer cancel()
+		err := h.PostEphemeral(ctx, command, msg)
+		if err == nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		log.Logger().Warnf("failed to post ephemeral message to %s in %s: %v", command.UserID, command.ChannelID,
+			err)
+	}
+	h.respond(w, msg)
+}
+
+func (h *Handler) respond(w http.ResponseWriter, msg *slack.Msg) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(msg)
+	if err != nil {
+		log.Logger().Warnf("failed to write slash command response: %v", err)
+	}
+}
+
+// respondLater waits for the lookup and posts the result to the response_url of the slash command, or as an ephemeral
+// message if only the user who invoked the command should see it
+func (h *Handler) respondLater(command slack.SlashCommand, responses <-chan *slack.Msg) {
+	msg := <-responses
+	if msg.ResponseType == slack.ResponseTypeEphemeral && h.SlackClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), h.responseTimeout())
+		defer cancel()
+		err := h.PostEphemeral(ctx, command, msg)
+		if err == nil {
+			return
+		}
+		log.Logger().Warnf("failed to post ephemeral message to %s in %s: %v", command.UserID, command.ChannelID,
+			err)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Logger().Warnf("failed to marshal slash command response: %v", err)
+		return
+	}
+	client := h.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(command.ResponseURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Logger().Warnf("failed to post slash command response: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Logger().Warnf("failed to post slash command response: %s", resp.Status)
+	}
+}
+
+func (h *Handler) responseTimeout() time.Duration {
+	if h.ResponseTimeout > 0 {
+		return h.ResponseTimeout
+	}
+	return DefaultResponseTimeout
+}
+
+func (h *Handler) context() context.Context {
+	if h.Context != nil {
+		return h.Context
+	}
+	return context.Background()
+}
+
+func (h *Handler) lookupTimeout() time.Duration {
+	if h.LookupTimeout > 0 {
+		return h.LookupTimeout
+	}
+	return DefaultLookupTimeout
+}
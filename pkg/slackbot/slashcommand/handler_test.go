@@ -0,0 +1,218 @@
+package slashcommand
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const signingSecret = "cheese"
+
+func newSlashCommandRequest(t *testing.T, text string, responseURL string, secret string) *http.Request {
+	body := url.Values{
+		"command":      {"/jx"},
+		"text":         {text},
+		"response_url": {responseURL},
+		"channel_id":   {"C024BE91L"},
+		"user_id":      {"U2147483697"},
+	}.Encode()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, err := mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/commands", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func lookupAfter(delay time.Duration) StatusLookup {
+	return func(ctx context.Context, owner string, repo string) ([]slack.Attachment, error) {
+		time.Sleep(delay)
+		return []slack.Attachment{{Title: fmt.Sprintf("%s/%s succeeded", owner, repo)}}, nil
+	}
+}
+
+// fakeEphemeralPoster records the ephemeral messages posted
+type fakeEphemeralPoster struct {
+	sync.Mutex
+	posts []url.Values
+	err   error
+}
+
+func (f *fakeEphemeralPoster) PostEphemeralContext(ctx context.Context, channelID string, userID string,
+	options ...slack.MsgOption) (string, error) {
+	_, values, err := slack.UnsafeApplyMsgOptions("", channelID, "", options...)
+	if err != nil {
+		return "", err
+	}
+	values.Set("user", userID)
+	f.Lock()
+	defer f.Unlock()
+	f.posts = append(f.posts, values)
+	return "1.000100", f.err
+}
+
+func (f *fakeEphemeralPoster) messages() []url.Values {
+	f.Lock()
+	defer f.Unlock()
+	return append([]url.Values{}, f.posts...)
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	h := &Handler{
+		SigningSecret: signingSecret,
+		Lookup:        lookupAfter(0),
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newSlashCommandRequest(t, "status cheese/wine", "", signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	msg := slack.Msg{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, slack.ResponseTypeInChannel, msg.ResponseType)
+	require.Len(t, msg.Attachments, 1)
+	assert.Equal(t, "cheese/wine succeeded", msg.Attachments[0].Title)
+}
+
+func TestHandler_ServeHTTPContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h := &Handler{
+		SigningSecret: signingSecret,
+		Lookup: func(ctx context.Context, owner string, repo string) ([]slack.Attachment, error) {
+			return nil, ctx.Err()
+		},
+		Context: ctx,
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newSlashCommandRequest(t, "status cheese/wine", "", signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	msg := slack.Msg{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, "Failed to look up the status of cheese/wine", msg.Text)
+}
+
+func TestHandler_ServeHTTPUsage(t *testing.T) {
+	h := &Handler{
+		SigningSecret: signingSecret,
+		Lookup:        lookupAfter(0),
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newSlashCommandRequest(t, "status", "", signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	msg := slack.Msg{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, slack.ResponseTypeEphemeral, msg.ResponseType)
+	assert.Equal(t, "Usage: `/jx status <owner>/<repository>`", msg.Text)
+}
+
+func TestHandler_ServeHTTPInvalidSignature(t *testing.T) {
+	h := &Handler{
+		SigningSecret: signingSecret,
+		Lookup:        lookupAfter(0),
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newSlashCommandRequest(t, "status cheese/wine", "", "wine"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestHandler_ServeHTTPSlowLookup(t *testing.T) {
+	responses := make(chan slack.Msg, 1)
+	responseServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		msg := slack.Msg{}
+		require.NoError(t, json.Unmarshal(data, &msg))
+		responses <- msg
+	}))
+	defer responseServer.Close()
+
+	h := &Handler{
+		SigningSecret:   signingSecret,
+		Lookup:          lookupAfter(100 * time.Millisecond),
+		ResponseTimeout: 10 * time.Millisecond,
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newSlashCommandRequest(t, "status cheese/wine", responseServer.URL, signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Looking up the status of cheese/wine")
+
+	select {
+	case msg := <-responses:
+		require.Len(t, msg.Attachments, 1)
+		assert.Equal(t, "cheese/wine succeeded", msg.Attachments[0].Title)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no response posted to the response_url")
+	}
+}
+
+func TestHandler_ServeHTTPEphemeral(t *testing.T) {
+	poster := &fakeEphemeralPoster{}
+	h := &Handler{
+		SigningSecret: signingSecret,
+		Lookup:        lookupAfter(0),
+		SlackClient:   poster,
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newSlashCommandRequest(t, "status", "", signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	posts := poster.messages()
+	require.Len(t, posts, 1)
+	assert.Equal(t, "C024BE91L", posts[0].Get("channel"))
+	assert.Equal(t, "U2147483697", posts[0].Get("user"))
+	assert.Equal(t, "Usage: `/jx status <owner>/<repository>`", posts[0].Get("text"))
+
+	// the lookup failures are only shown to the user
+	h.Lookup = func(ctx context.Context, owner string, repo string) ([]slack.Attachment, error) {
+		return nil, fmt.Errorf("no cheese")
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, newSlashCommandRequest(t, "status cheese/wine", "", signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	posts = poster.messages()
+	require.Len(t, posts, 2)
+	assert.Equal(t, "Failed to look up the status of cheese/wine", posts[1].Get("text"))
+
+	// the status cards are still posted in the channel
+	h.Lookup = lookupAfter(0)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, newSlashCommandRequest(t, "status cheese/wine", "", signingSecret))
+	msg := slack.Msg{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, slack.ResponseTypeInChannel, msg.ResponseType)
+	assert.Len(t, poster.messages(), 2)
+}
+
+func TestHandler_ServeHTTPEphemeralFailure(t *testing.T) {
+	h := &Handler{
+		SigningSecret: signingSecret,
+		Lookup:        lookupAfter(0),
+		SlackClient:   &fakeEphemeralPoster{err: fmt.Errorf("channel_not_found")},
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, newSlashCommandRequest(t, "status", "", signingSecret))
+	require.Equal(t, http.StatusOK, w.Code)
+	msg := slack.Msg{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &msg))
+	assert.Equal(t, slack.ResponseTypeEphemeral, msg.ResponseType)
+	assert.Equal(t, "Usage: `/jx status <owner>/<repository>`", msg.Text)
+}
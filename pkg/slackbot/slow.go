@@ -0,0 +1,68 @@
+package slackbot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	"github.com/slack-go/slack"
+)
+
+// slowBuildMessageKey is the key of the reply warning the channel that the build of the activity is slow, so that the
+// channel is only warned once per build
+func slowBuildMessageKey(activityName string) string {
+	return fmt.Sprintf("%s/slow", activityName)
+}
+
+// slowBuild returns how long the build of the activity has been running, and true if it is still running and has been
+// running for longer than SlowBuildThreshold
+func (o *SlackBotOptions) slowBuild(activity *record.ActivityRecord, now time.Time) (time.Duration, bool) {
+	if o.SlowBuildThreshold <= 0 || activity.StartTime == nil || pipelineStatus(activity) != v1alpha1.RunningState {
+		return 0, false
+	}
+	elapsed := now.Sub(activity.StartTime.Time)
+	return elapsed, elapsed > o.SlowBuildThreshold
+}
+
+// slowBuildField returns the field warning that the build of the activity is slow, nil if it isn't
+func (o *SlackBotOptions) slowBuildField(activity *record.ActivityRecord) []slack.AttachmentField {
+	elapsed, slow := o.slowBuild(activity, time.Now())
+	if !slow {
+		return nil
+	}
+	return []slack.AttachmentField{{
+		Title: "Slow build",
+		Value: fmt.Sprintf(":hourglass: running for %s, longer than %s", elapsed.Round(time.Minute),
+			o.SlowBuildThreshold),
+		Short: true,
+	}}
+}
+
+// pingSlowBuild warns the channel with a reply to the message of the activity, broadcast to the channel, once its
+// build has been running for longer than SlowBuildThreshold. The channel is only warned once per build, without naming
+// the repository if the channel is private.
+func (o *SlackBotOptions) pingSlowBuild(channel string, activity *record.ActivityRecord, private bool) error {
+	if !o.PingSlowBuilds {
+		return nil
+	}
+	elapsed, slow := o.slowBuild(activity, time.Now())
+	if !slow {
+		return nil
+	}
+	parent := o.messageReference(channel, activity.Name)
+	key := slowBuildMessageKey(activity.Name)
+	if parent == nil || o.messageReference(channel, key) != nil {
+		return nil
+	}
+	repo := repositoryName(activity)
+	if private {
+		repo = privateRepositoryName(activity)
+	}
+	text := fmt.Sprintf("<!here> :hourglass: %s has been running for %s", repo, elapsed.Round(time.Minute))
+	if o.DryRun {
+		return logDryRun(channel, activity, []slack.Attachment{{Text: text}}, nil)
+	}
+	return o.postThreadReply(channel, false, pipelineMessageType, parent, key, text,
+		[]slack.MsgOption{slack.MsgOptionText(text, false), slack.MsgOptionBroadcast()})
+}
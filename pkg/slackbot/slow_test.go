@@ -0,0 +1,79 @@
+package slackbot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSlackBotOptions_slowBuild(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+	now := time.Now()
+	act.StartTime = &metav1.Time{Time: now.Add(-time.Hour)}
+	act.Status = v1alpha1.RunningState
+	o := &SlackBotOptions{}
+
+	// builds are never slow without a threshold
+	_, slow := o.slowBuild(act, now)
+	assert.False(t, slow)
+
+	o.SlowBuildThreshold = 30 * time.Minute
+	elapsed, slow := o.slowBuild(act, now)
+	assert.True(t, slow)
+	assert.Equal(t, time.Hour, elapsed)
+
+	o.SlowBuildThreshold = 2 * time.Hour
+	_, slow = o.slowBuild(act, now)
+	assert.False(t, slow)
+
+	// completed builds aren't slow anymore
+	o.SlowBuildThreshold = 30 * time.Minute
+	act.Status = v1alpha1.SuccessState
+	_, slow = o.slowBuild(act, now)
+	assert.False(t, slow)
+}
+
+func TestSlackBotOptions_PipelineMessage_slowBuild(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient:        client,
+		Timestamps:         make(map[string]map[string]*MessageReference),
+		Pipelines:          []slackapp.SlackBotMode{{Channel: "#cheese"}},
+		SlowBuildThreshold: 30 * time.Minute,
+		PingSlowBuilds:     true,
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+	act.Status = v1alpha1.RunningState
+	act.StartTime = &metav1.Time{Time: time.Now().Add(-time.Hour)}
+
+	require.NoError(t, o.PipelineMessage(act))
+	posts := client.callsTo("chat.postMessage")
+	require.Len(t, posts, 2)
+	assert.Contains(t, posts[0].Values.Get("attachments"), "Slow build")
+	assert.Equal(t, "true", posts[1].Values.Get("reply_broadcast"))
+	assert.Contains(t, posts[1].Values.Get("text"), "has been running for 1h0m0s")
+
+	// the channel is only warned once
+	act.LogURL = "https://dashboard.example.com/logs"
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 2)
+	assert.Len(t, client.callsTo("chat.update"), 1)
+
+	// the private channels are warned without naming the repository
+	o.Pipelines = []slackapp.SlackBotMode{{Channel: "#wine", Private: true}}
+	require.NoError(t, o.PipelineMessage(act))
+	posts = client.callsTo("chat.postMessage")
+	require.Len(t, posts, 4)
+	assert.Contains(t, posts[3].Values.Get("text"), "|private repository>")
+	assert.NotContains(t, posts[3].Values.Get("text"), "|"+act.Repo+">")
+}
@@ -0,0 +1,56 @@
+package slackbot
+
+import (
+	"context"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/lighthouse/pkg/jx"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+// LatestPipelineStatus renders the most recently started pipeline of the repository the same way as the pipeline
+// messages, or returns no attachments if the repository has no pipelines
+func (o *SlackBotOptions) LatestPipelineStatus(ctx context.Context, owner string, repo string) ([]slack.Attachment,
+	error) {
+	acts, err := o.getRepositoryPipelineActivities(ctx, owner, repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing pipeline activities for %s/%s", owner, repo)
+	}
+	var latest *jenkinsv1.PipelineActivity
+	for i := range acts.Items {
+		act := &acts.Items[i]
+		if latest == nil || startedAfter(act, latest) {
+			latest = act
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	ar, err := jx.ConvertPipelineActivity(latest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "converting pipeline activity %s", latest.Name)
+	}
+	// use the statuses of the first pipeline config listing the org of the repository
+	statuses := o.localizedStatuses()
+	private := false
+	for _, cfg := range o.Pipelines {
+		if len(matchingOrgs(cfg, ar)) > 0 {
+			statuses = o.statusesFor(cfg, ar)
+			private = cfg.Private
+			break
+		}
+	}
+	attachments, _, err := o.createPipelineMessage(ar, latest, nil, statuses, private)
+	return attachments, err
+}
+
+func startedAfter(a *jenkinsv1.PipelineActivity, b *jenkinsv1.PipelineActivity) bool {
+	if a.Spec.StartedTimestamp == nil {
+		return false
+	}
+	if b.Spec.StartedTimestamp == nil {
+		return true
+	}
+	return b.Spec.StartedTimestamp.Before(a.Spec.StartedTimestamp)
+}
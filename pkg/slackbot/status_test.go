@@ -0,0 +1,68 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSlackBotOptions_LatestPipelineStatus(t *testing.T) {
+	activity := func(name string, build string, started time.Time) *jenkinsv1.PipelineActivity {
+		startedTimestamp := metav1.NewTime(started)
+		return &jenkinsv1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "jx",
+				Labels: map[string]string{
+					"owner":      "cheese",
+					"repository": "wine",
+					"branch":     "master",
+					"build":      build,
+				},
+			},
+			Spec: jenkinsv1.PipelineActivitySpec{
+				Pipeline:         "cheese/wine/master",
+				Build:            build,
+				GitOwner:         "cheese",
+				GitRepository:    "wine",
+				GitBranch:        "master",
+				StartedTimestamp: &startedTimestamp,
+				Status:           jenkinsv1.ActivityStatusTypeSucceeded,
+			},
+		}
+	}
+	now := time.Now()
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: "jx",
+			JXClient: jxfake.NewSimpleClientset(
+				activity("cheese-wine-master-1", "1", now.Add(-time.Hour)),
+				activity("cheese-wine-master-2", "2", now),
+			),
+		},
+	}
+
+	attachments, err := o.LatestPipelineStatus(context.Background(), "cheese", "wine")
+	require.NoError(t, err)
+	require.NotEmpty(t, attachments)
+	assert.Equal(t, "pipelineactivity:cheese-wine-master-2", attachments[0].CallbackID)
+
+	attachments, err = o.LatestPipelineStatus(context.Background(), "cheese", "cheddar")
+	require.NoError(t, err)
+	assert.Empty(t, attachments)
+
+	// the repository is typed by the users, it can't widen the selector to the pipelines of other repositories
+	_, err = o.LatestPipelineStatus(context.Background(), "cheese", "wine,owner!=cheese")
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = o.LatestPipelineStatus(ctx, "cheese", "wine")
+	assert.Error(t, err)
+}
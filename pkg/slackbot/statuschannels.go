@@ -0,0 +1,37 @@
+package slackbot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+)
+
+// pipelineChannels returns the channels to post the pipeline message of the activity to: the channels of the mode,
+// the channel the StatusChannels configure for the status of the pipeline, and the status channels the activity was
+// already posted to, so that their messages are still updated once the status changes again, such as when a failed
+// pipeline is restarted
+func (o *SlackBotOptions) pipelineChannels(activity *record.ActivityRecord, cfg slackapp.SlackBotMode) []string {
+	channels := activityChannels(activity, cfg)
+	if len(cfg.StatusChannels) == 0 {
+		return channels
+	}
+	status := string(pipelineStatus(activity))
+	var statuses []string
+	for s := range cfg.StatusChannels {
+		statuses = append(statuses, s)
+	}
+	sort.Strings(statuses)
+	for _, s := range statuses {
+		channel := workspaceChannel(cfg.Workspace, channelName(cfg.StatusChannels[s]))
+		if util.Contains(channels, channel) {
+			continue
+		}
+		if strings.EqualFold(s, status) || o.messageReference(channel, activity.Name) != nil {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
@@ -0,0 +1,51 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_PipelineMessage_statusChannels(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines: []slackapp.SlackBotMode{{
+			Channel:        "#cheese",
+			StatusChannels: map[string]string{"failure": "#ci-failures", "aborted": "#ci-failures"},
+		}},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+	channels := func(method string) []string {
+		var channels []string
+		for _, call := range client.callsTo(method) {
+			channels = append(channels, call.Values.Get("channel"))
+		}
+		return channels
+	}
+
+	// the statuses without a channel fall back to the channel of the mode
+	act.Status = v1alpha1.RunningState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Equal(t, []string{"#cheese"}, channels("chat.postMessage"))
+
+	// the failure is also posted to its own channel, while the message of the mode is updated
+	act.Status = v1alpha1.FailureState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Equal(t, []string{"#cheese", "#ci-failures"}, channels("chat.postMessage"))
+	assert.Len(t, client.callsTo("chat.update"), 1)
+	assert.NotNil(t, o.messageReference("#ci-failures", act.Name))
+
+	// the failure message is still updated once the pipeline is restarted
+	act.Status = v1alpha1.SuccessState
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 2)
+	assert.Len(t, client.callsTo("chat.update"), 3)
+}
@@ -0,0 +1,56 @@
+package slackbot
+
+import (
+	"reflect"
+
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+)
+
+// statusesFor returns the statuses of the messages for the activity sent for the config: the statuses of the org of
+// the activity override the statuses of the SlackBot, and getStatus falls back to the default statuses for the ones
+// neither configures
+func (o *SlackBotOptions) statusesFor(cfg slackapp.SlackBotMode, activity *record.ActivityRecord) slackapp.Statuses {
+	statuses := o.localizedStatuses()
+	for _, org := range matchingOrgs(cfg, activity) {
+		statuses = mergeStatuses(org.Statuses, statuses)
+	}
+	return statuses
+}
+
+// matchingOrgs returns the orgs of the config which include the repository of the activity
+func matchingOrgs(cfg slackapp.SlackBotMode, activity *record.ActivityRecord) []slackapp.Org {
+	var orgs []slackapp.Org
+	for _, org := range cfg.Orgs {
+		if org.Name != activity.Owner {
+			continue
+		}
+		if len(org.Repos) > 0 && !containsRepo(org.Repos, activity.Repo) {
+			continue
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs
+}
+
+// mergeStatuses returns the statuses, with the ones set in overrides replacing them
+func mergeStatuses(overrides slackapp.Statuses, statuses slackapp.Statuses) slackapp.Statuses {
+	merged := statuses
+	o := reflect.ValueOf(overrides)
+	m := reflect.ValueOf(&merged).Elem()
+	for i := 0; i < o.NumField(); i++ {
+		if status, ok := o.Field(i).Interface().(*slackapp.Status); ok && status != nil {
+			m.Field(i).Set(o.Field(i))
+		}
+	}
+	return merged
+}
+
+func containsRepo(repos []slackapp.Repo, name string) bool {
+	for _, r := range repos {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_statusesFor(t *testing.T) {
+	o := &SlackBotOptions{
+		Statuses: slackapp.Statuses{
+			Succeeded: &slackapp.Status{Emoji: ":large_green_circle:"},
+			Failed:    &slackapp.Status{Emoji: ":red_circle:"},
+		},
+	}
+	cfg := slackapp.SlackBotMode{
+		Orgs: []slackapp.Org{
+			{Name: "cheese", Statuses: slackapp.Statuses{Succeeded: &slackapp.Status{Emoji: ":white_check_mark:"}}},
+			{Name: "wine", Repos: []slackapp.Repo{{Name: "bordeaux"}},
+				Statuses: slackapp.Statuses{Failed: &slackapp.Status{Emoji: ":wine_glass:"}}},
+		},
+	}
+
+	// the org statuses override the global ones, which are used for the statuses the org doesn't configure, then the
+	// ones of the locale
+	statuses := o.statusesFor(cfg, &record.ActivityRecord{Owner: "cheese", Repo: "brie"})
+	assert.Equal(t, ":white_check_mark:", statuses.Succeeded.Emoji)
+	assert.Equal(t, ":red_circle:", statuses.Failed.Emoji)
+	assert.Equal(t, defaultStatuses.Running, statuses.Running)
+
+	statuses = o.statusesFor(cfg, &record.ActivityRecord{Owner: "wine", Repo: "bordeaux"})
+	assert.Equal(t, ":wine_glass:", statuses.Failed.Emoji)
+	assert.Equal(t, ":large_green_circle:", statuses.Succeeded.Emoji)
+
+	// the org only applies to its repositories
+	assert.Equal(t, o.localizedStatuses(), o.statusesFor(cfg, &record.ActivityRecord{Owner: "wine", Repo: "burgundy"}))
+	assert.Equal(t, o.localizedStatuses(), o.statusesFor(cfg, &record.ActivityRecord{Owner: "beer", Repo: "stout"}))
+	// the global statuses aren't modified
+	assert.Equal(t, ":large_green_circle:", o.Statuses.Succeeded.Emoji)
+}
+
+func TestSlackBotOptions_PipelineMessage_orgStatuses(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines: []slackapp.SlackBotMode{
+			{
+				Channel: "#cheese",
+				Orgs: []slackapp.Org{{Name: "jenkins-x-labs", Statuses: slackapp.Statuses{
+					Succeeded: &slackapp.Status{Emoji: ":large_green_circle:", Color: "#2EB67D"},
+				}}},
+			},
+		},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+	act.Status = v1alpha1.SuccessState
+
+	err = o.PipelineMessage(act)
+	require.NoError(t, err)
+	posts := client.callsTo("chat.postMessage")
+	require.Len(t, posts, 1)
+	assert.Contains(t, posts[0].Values.Get("attachments"), "#2EB67D")
+}
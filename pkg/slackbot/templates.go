@@ -0,0 +1,71 @@
+package slackbot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// reviewMessageData is the data available to a review message template
+type reviewMessageData struct {
+	// Mentions are the space separated mentions (or links) of the requested reviewers
+	Mentions string
+	// PRLink is a link to the pull request
+	PRLink string
+	// Repo is the name of the repository
+	Repo string
+	// Author is the mention (or link) of the author of the pull request
+	Author string
+	// Status is the review status, e.g. ":+1: approved"
+	Status string
+	// State is merged or closed once the pull request is closed, empty while it is open
+	State string
+}
+
+// parseReviewMessageTemplate parses a review message template, executing it against empty data so that references to
+// unknown fields are reported straight away rather than when the first message is sent
+func parseReviewMessageTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("review-message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing review message template")
+	}
+	err = tmpl.Execute(ioutil.Discard, reviewMessageData{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "validating review message template, available fields are .Mentions, "+
+			".PRLink, .Repo, .Author, .Status and .State")
+	}
+	return tmpl, nil
+}
+
+// reviewMessageText renders the text of the review message, using the ReviewMessageTemplate if one is configured and
+// the template of the locale otherwise.
+// Once the pull request is merged or closed the ClosedReviewMessageTemplate is used instead, so that reviewers aren't
+// asked to review a pull request which can't be merged anymore.
+func (o *SlackBotOptions) reviewMessageText(data reviewMessageData) (string, error) {
+	catalog := o.messageCatalog()
+	if data.State != "" {
+		if o.ClosedReviewMessageTemplate == "" {
+			return executeReviewMessageTemplate(catalog.closedReviewMessageTemplate, data)
+		}
+		return executeReviewMessageTemplate(o.ClosedReviewMessageTemplate, data)
+	}
+	if o.ReviewMessageTemplate == "" {
+		return executeReviewMessageTemplate(catalog.reviewMessageTemplate, data)
+	}
+	return executeReviewMessageTemplate(o.ReviewMessageTemplate, data)
+}
+
+func executeReviewMessageTemplate(text string, data reviewMessageData) (string, error) {
+	tmpl, err := parseReviewMessageTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", errors.Wrapf(err, "executing review message template")
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,101 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackBotOptions_reviewMessageText(t *testing.T) {
+	data := reviewMessageData{
+		Mentions: "<@U1>",
+		PRLink:   "<https://github.com/cheese/wine/pull/1|Pull Request #1 (Add cheddar)>",
+		Repo:     "cheese/wine",
+		Author:   "<@U2>",
+		Status:   ":+1: approved",
+	}
+	tests := []struct {
+		name           string
+		template       string
+		closedTemplate string
+		locale         string
+		state          string
+		want           string
+		wantErr        bool
+	}{
+		{
+			name: "default",
+			want: "<@U1> please review <https://github.com/cheese/wine/pull/1|Pull Request #1 (Add cheddar)> " +
+				"created on cheese/wine by <@U2>",
+		},
+		{
+			name:     "custom",
+			template: "{{ .Mentions }} :eyes: {{ .PRLink }} in {{ .Repo }} ({{ .Status }}), see the checklist",
+			want: "<@U1> :eyes: <https://github.com/cheese/wine/pull/1|Pull Request #1 (Add cheddar)> in " +
+				"cheese/wine (:+1: approved), see the checklist",
+		},
+		{
+			name:     "merged",
+			template: "{{ .Mentions }} :eyes: {{ .PRLink }}",
+			state:    "merged",
+			want: "<https://github.com/cheese/wine/pull/1|Pull Request #1 (Add cheddar)> on cheese/wine by <@U2> " +
+				"was merged",
+		},
+		{
+			name:           "closed_custom",
+			closedTemplate: "~{{ .PRLink }}~ {{ .State }}",
+			state:          "closed",
+			want:           "~<https://github.com/cheese/wine/pull/1|Pull Request #1 (Add cheddar)>~ closed",
+		},
+		{
+			name:   "french",
+			locale: "fr",
+			want: "<@U1> merci de relire <https://github.com/cheese/wine/pull/1|Pull Request #1 (Add cheddar)> " +
+				"créée sur cheese/wine par <@U2>",
+		},
+		{
+			name:   "french_merged",
+			locale: "fr",
+			state:  "merged",
+			want: "<https://github.com/cheese/wine/pull/1|Pull Request #1 (Add cheddar)> sur cheese/wine par <@U2> " +
+				"a été fusionnée",
+		},
+		{
+			name:   "unsupported_locale",
+			locale: "xx",
+			want: "<@U1> please review <https://github.com/cheese/wine/pull/1|Pull Request #1 (Add cheddar)> " +
+				"created on cheese/wine by <@U2>",
+		},
+		{
+			name:     "unknown_field",
+			template: "{{ .Reviewers }} please review",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &SlackBotOptions{
+				ReviewMessageTemplate:       tt.template,
+				ClosedReviewMessageTemplate: tt.closedTemplate,
+				Locale:                      tt.locale,
+			}
+			data.State = tt.state
+			got, err := o.reviewMessageText(data)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseReviewMessageTemplate(t *testing.T) {
+	_, err := parseReviewMessageTemplate("{{ .Author }} opened {{ .PRLink }}")
+	assert.NoError(t, err)
+	_, err = parseReviewMessageTemplate("{{ .Author ")
+	assert.Error(t, err)
+	_, err = parseReviewMessageTemplate("{{ .Title }}")
+	assert.Error(t, err)
+}
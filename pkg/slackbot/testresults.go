@@ -0,0 +1,68 @@
+package slackbot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/slack-go/slack"
+)
+
+const (
+	// TestResultsAnnotationPrefix is the prefix of the PipelineActivity annotations pipelines record their test
+	// results with
+	TestResultsAnnotationPrefix = "tests.slack.apps.jenkins-x.io"
+	// TestsPassedAnnotation is the number of tests which passed
+	TestsPassedAnnotation = TestResultsAnnotationPrefix + "/passed"
+	// TestsFailedAnnotation is the number of tests which failed
+	TestsFailedAnnotation = TestResultsAnnotationPrefix + "/failed"
+	// CoverageAnnotation is the percentage of the code covered by the tests, such as 83.5
+	CoverageAnnotation = TestResultsAnnotationPrefix + "/coverage"
+)
+
+// testResultFields returns the fields showing the test results and coverage recorded in the annotations of the
+// PipelineActivity. No field is returned if the pipeline didn't record any.
+func testResultFields(pa *jenkinsv1.PipelineActivity) []slack.AttachmentField {
+	if pa == nil {
+		return nil
+	}
+	return testResultFieldsFromAnnotations(pa.Annotations)
+}
+
+func testResultFieldsFromAnnotations(annotations map[string]string) []slack.AttachmentField {
+	var fields []slack.AttachmentField
+	var results []string
+	if passed, ok := annotationCount(annotations, TestsPassedAnnotation); ok {
+		results = append(results, fmt.Sprintf(":white_check_mark: %d passed", passed))
+	}
+	if failed, ok := annotationCount(annotations, TestsFailedAnnotation); ok {
+		results = append(results, fmt.Sprintf(":x: %d failed", failed))
+	}
+	if len(results) > 0 {
+		fields = append(fields, slack.AttachmentField{
+			Title: "Tests",
+			Value: strings.Join(results, ", "),
+			Short: true,
+		})
+	}
+	coverage, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(annotations[CoverageAnnotation]), "%"),
+		64)
+	if err == nil && coverage >= 0 && coverage <= 100 {
+		fields = append(fields, slack.AttachmentField{
+			Title: "Coverage",
+			Value: strconv.FormatFloat(coverage, 'f', -1, 64) + "%",
+			Short: true,
+		})
+	}
+	return fields
+}
+
+// annotationCount returns the count recorded in the annotation, and false if it is missing or isn't a count
+func annotationCount(annotations map[string]string, key string) (int, bool) {
+	count, err := strconv.Atoi(strings.TrimSpace(annotations[key]))
+	if err != nil || count < 0 {
+		return 0, false
+	}
+	return count, true
+}
@@ -0,0 +1,128 @@
+package slackbot
+
+import (
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_testResultFieldsFromAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        []slack.AttachmentField
+	}{
+		{
+			name: "none",
+		},
+		{
+			name: "all",
+			annotations: map[string]string{
+				TestsPassedAnnotation: "120",
+				TestsFailedAnnotation: "2",
+				CoverageAnnotation:    "83.5",
+			},
+			want: []slack.AttachmentField{
+				{Title: "Tests", Value: ":white_check_mark: 120 passed, :x: 2 failed", Short: true},
+				{Title: "Coverage", Value: "83.5%", Short: true},
+			},
+		},
+		{
+			name:        "coverage_only",
+			annotations: map[string]string{CoverageAnnotation: "70%"},
+			want:        []slack.AttachmentField{{Title: "Coverage", Value: "70%", Short: true}},
+		},
+		{
+			name: "invalid",
+			annotations: map[string]string{
+				TestsPassedAnnotation: "lots",
+				TestsFailedAnnotation: "-1",
+				CoverageAnnotation:    "120",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, testResultFieldsFromAnnotations(tt.annotations))
+		})
+	}
+}
+
+func TestSlackBotOptions_createPipelineMessage_testResults(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	pa := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        act.Name,
+			Namespace:   "jx",
+			Annotations: map[string]string{TestsPassedAnnotation: "12"},
+		},
+	}
+	o := &SlackBotOptions{}
+
+	attachments, _, err := o.createPipelineMessage(act, pa, nil, o.Statuses, false)
+	require.NoError(t, err)
+	assert.Empty(t, attachments[0].Fields)
+
+	o.ShowTestResults = true
+	attachments, _, err = o.createPipelineMessage(act, pa, nil, o.Statuses, false)
+	require.NoError(t, err)
+	assert.Contains(t, attachments[0].Fields, slack.AttachmentField{
+		Title: "Tests",
+		Value: ":white_check_mark: 12 passed",
+		Short: true,
+	})
+}
+
+func TestSlackBotOptions_PipelineMessage_readsPipelineActivityOnce(t *testing.T) {
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	pa := &jenkinsv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        act.Name,
+			Namespace:   "jx",
+			Annotations: map[string]string{TestsPassedAnnotation: "12"},
+		},
+		Spec: jenkinsv1.PipelineActivitySpec{
+			LastCommitMessage: "Add cheddar",
+			Attachments: []jenkinsv1.Attachment{
+				{Name: "reports", URLs: []string{"https://artifacts.example.com/slack/1"}},
+			},
+		},
+	}
+	jxClient := jxfake.NewSimpleClientset(pa)
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: "jx",
+			JXClient:  jxClient,
+		},
+		SlackClient:       client,
+		Timestamps:        make(map[string]map[string]*MessageReference),
+		ShowTestResults:   true,
+		ShowCommitMessage: true,
+		PipelineButtons:   []slackapp.PipelineButton{{Name: ButtonArtifacts}},
+		Pipelines: []slackapp.SlackBotMode{
+			{Channel: "cheese"},
+			{Channel: "wine"},
+		},
+	}
+
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 2)
+	gets := 0
+	for _, action := range jxClient.Actions() {
+		if action.GetVerb() == "get" && action.GetResource().Resource == "pipelineactivities" {
+			gets++
+		}
+	}
+	assert.Equal(t, 1, gets, "the PipelineActivity should be read once for all the channels and fields")
+}
@@ -0,0 +1,214 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+// stageReply is the threaded reply rendered for a single pipeline stage
+type stageReply struct {
+	Name        string
+	Attachments []slack.Attachment
+}
+
+// createStageReplies renders a reply for each stage of the activity
+func (o *SlackBotOptions) createStageReplies(activity *record.ActivityRecord,
+	statuses slackapp.Statuses) []stageReply {
+	replies := []stageReply{}
+	for i, stage := range activity.Stages {
+		attachments := o.createAttachments(activity, stage, statuses)
+		if len(attachments) == 0 {
+			continue
+		}
+		name := stage.Name
+		if name == "" {
+			name = fmt.Sprintf("stage-%d", i)
+		}
+		replies = append(replies, stageReply{
+			Name:        name,
+			Attachments: attachments,
+		})
+	}
+	return replies
+}
+
+// stageMessageKey returns the key used to track the threaded reply for a stage of an activity
+func stageMessageKey(activityName string, stageName string) string {
+	return fmt.Sprintf("%s/%s", activityName, stageName)
+}
+
+// postStageReplies posts each stage of the activity as a threaded reply to the message already posted for the activity
+// in the channel, updating the replies in place on subsequent calls
+func (o *SlackBotOptions) postStageReplies(channel string, directMessage bool, activity *record.ActivityRecord,
+	statuses slackapp.Statuses) error {
+	if o.DryRun {
+		for _, reply := range o.createStageReplies(activity, statuses) {
+			err := logDryRun(channel, activity, reply.Attachments, nil)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	parent := o.messageReference(channel, activity.Name)
+	if parent == nil {
+		// the parent message was not created so there is nothing to reply to
+		return nil
+	}
+	for _, reply := range o.createStageReplies(activity, statuses) {
+		options := []slack.MsgOption{}
+		if o.UseBlockKit {
+			options = append(options, slack.MsgOptionBlocks(attachmentsToBlocks(reply.Attachments)...))
+		} else {
+			options = append(options, slack.MsgOptionAttachments(reply.Attachments...))
+		}
+		err := o.postThreadReply(channel, directMessage, pipelineMessageType, parent,
+			stageMessageKey(activity.Name, reply.Name), "", options)
+		if err != nil {
+			return errors.Wrapf(err, "posting stage %s reply for %s", reply.Name, activity.Name)
+		}
+	}
+	return nil
+}
+
+// reviewersMessageKey returns the key used to track the threaded reply mentioning the reviewers of an activity
+func reviewersMessageKey(activityName string) string {
+	return fmt.Sprintf("%s/reviewers", activityName)
+}
+
+// reviewerThreadReplyText returns the text mentioning the reviewers with the format of the locale, sorted so the same
+// set of reviewers always results in the same text
+func reviewerThreadReplyText(format string, reviewers []*slack.User) string {
+	ids := make([]string, 0)
+	for _, r := range reviewers {
+		if r != nil && r.ID != "" && !util.Contains(ids, r.ID) {
+			ids = append(ids, r.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	sort.Strings(ids)
+	mentions := make([]string, 0)
+	for _, id := range ids {
+		mentions = append(mentions, mentionUser(id))
+	}
+	return fmt.Sprintf(format, strings.Join(mentions, " "))
+}
+
+// postReviewerThreadReply mentions the reviewers in a threaded reply to the review request message already posted for
+// the activity in the channel. The reply is only updated when the set of reviewers changes
+func (o *SlackBotOptions) postReviewerThreadReply(channel string, activity *record.ActivityRecord,
+	reviewers []*slack.User) error {
+	text := reviewerThreadReplyText(o.messageCatalog().reviewerThreadReply, reviewers)
+	if text == "" {
+		return nil
+	}
+	if o.DryRun {
+		return logDryRun(channel, activity, []slack.Attachment{{Text: text}}, nil)
+	}
+	parent := o.messageReference(channel, activity.Name)
+	if parent == nil {
+		// the review request was not posted so there is nothing to reply to
+		return nil
+	}
+	key := reviewersMessageKey(activity.Name)
+	if messageRef := o.messageReference(channel, key); messageRef != nil && messageRef.Text == text {
+		log.Logger().Infof("Reviewers of %s already notified in %s\n", activity.Name, channel)
+		return nil
+	}
+	return o.postThreadReply(channel, false, pullRequestReviewMessageType, parent, key, text,
+		[]slack.MsgOption{slack.MsgOptionText(text, false)})
+}
+
+// postThreadReply posts a threaded reply to the parent message, updating the reply already posted under the key if
+// there is one
+func (o *SlackBotOptions) postThreadReply(channel string, directMessage bool, messageType string,
+	parent *MessageReference, key string, text string, options []slack.MsgOption) error {
+	if parent.Timestamp == "" {
+		// the messages posted with incoming webhooks can't be replied to
+		return nil
+	}
+	options = append(options, slack.MsgOptionTS(parent.Timestamp))
+	messageRef := o.messageReference(channel, key)
+	method := "chat.postMessage"
+	if messageRef != nil {
+		options = append(options, slack.MsgOptionUpdate(messageRef.Timestamp))
+		method = "chat.update"
+		log.Logger().Infof("Updating reply %s with timestamp %s\n", key, messageRef.Timestamp)
+	} else {
+		log.Logger().Infof("Creating reply %s\n", key)
+	}
+	ctx := context.Background()
+	err := o.RateLimiter.Wait(ctx, channel, directMessage)
+	if err != nil {
+		return errors.Wrapf(err, "waiting to post to %s", channel)
+	}
+	var channelID, timestamp string
+	err = o.postWithRetry(ctx, "posting reply", func(ctx context.Context) error {
+		defer observeSlackAPICall(method, time.Now())
+		var err error
+		client := o.slackClientFor(parent.Workspace)
+		channelID, timestamp, _, err = client.SendMessageContext(ctx, parent.ChannelID, options...)
+		return err
+	})
+	if err != nil {
+		messagesFailed.WithLabelValues(messageType).Inc()
+		return errors.Wrap(err, fmt.Sprintf("(post reply channelId: %s, thread: %s)", parent.ChannelID,
+			parent.Timestamp))
+	}
+	if messageRef != nil {
+		messagesUpdated.WithLabelValues(messageType).Inc()
+	} else {
+		messagesCreated.WithLabelValues(messageType).Inc()
+	}
+	o.storeMessageReference(channel, key, &MessageReference{
+		Workspace:       parent.Workspace,
+		ChannelID:       channelID,
+		Timestamp:       timestamp,
+		ThreadTimestamp: parent.Timestamp,
+		Text:            text,
+	})
+	return nil
+}
+
+// terminalStatusMessageKey returns the key used to track the reply broadcasting the final status of an activity
+func terminalStatusMessageKey(activityName string) string {
+	return fmt.Sprintf("%s/final", activityName)
+}
+
+// broadcastTerminalStatus posts the final status of the pipeline as a reply to the message already posted for the
+// activity in the channel, broadcast to the channel so that it isn't missed among the threaded stages
+func (o *SlackBotOptions) broadcastTerminalStatus(channel string, activity *record.ActivityRecord,
+	statuses slackapp.Statuses, private bool) error {
+	status := statusForState(statuses, pipelineStatus(activity))
+	if status == nil {
+		return nil
+	}
+	repo := repositoryName(activity)
+	if private {
+		repo = privateRepositoryName(activity)
+	}
+	text := fmt.Sprintf("%s %s (Build %s) %s", status.Emoji, repo, buildNumber(activity), status.Text)
+	if o.DryRun {
+		return logDryRun(channel, activity, []slack.Attachment{{Text: text}}, nil)
+	}
+	parent := o.messageReference(channel, activity.Name)
+	key := terminalStatusMessageKey(activity.Name)
+	if parent == nil || o.messageReference(channel, key) != nil {
+		// nothing to reply to, or the final status was already broadcast
+		return nil
+	}
+	return o.postThreadReply(channel, false, pipelineMessageType, parent, key, text,
+		[]slack.MsgOption{slack.MsgOptionText(text, false), slack.MsgOptionBroadcast()})
+}
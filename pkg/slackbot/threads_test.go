@@ -0,0 +1,125 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_postStageReplies(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		SlackClient:  recorder.client(),
+		ThreadStages: true,
+		Timestamps:   make(map[string]map[string]*MessageReference),
+	}
+	act, err := getPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	channel := "#test"
+	err = o.postMessage(channel, false, pipelineMessageType, act, nil, nil, nil, true)
+	require.NoError(t, err)
+	parent := o.Timestamps[channel][act.Name]
+	require.NotNil(t, parent)
+
+	err = o.postStageReplies(channel, false, act, o.Statuses)
+	require.NoError(t, err)
+	posts := recorder.callsTo("chat.postMessage")
+	require.Len(t, posts, 1+len(act.Stages))
+	for _, p := range posts[1:] {
+		assert.Equal(t, parent.Timestamp, p.Values.Get("thread_ts"))
+	}
+	for _, stage := range act.Stages {
+		ref := o.Timestamps[channel][stageMessageKey(act.Name, stage.Name)]
+		require.NotNil(t, ref, "no reply recorded for stage %s", stage.Name)
+		assert.Equal(t, parent.Timestamp, ref.ThreadTimestamp)
+	}
+
+	// posting again updates the replies in place
+	err = o.postStageReplies(channel, false, act, o.Statuses)
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("chat.postMessage"), 1+len(act.Stages))
+	assert.Len(t, recorder.callsTo("chat.update"), len(act.Stages))
+}
+
+func TestSlackBotOptions_postReviewerThreadReply(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	o := &SlackBotOptions{
+		SlackClient: recorder.client(),
+		Timestamps:  make(map[string]map[string]*MessageReference),
+	}
+	act, err := getPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	channel := "#test"
+	err = o.postMessage(channel, false, pullRequestReviewMessageType, act, nil, nil, nil, true)
+	require.NoError(t, err)
+	parent := o.Timestamps[channel][act.Name]
+	require.NotNil(t, parent)
+
+	reviewers := []*slack.User{{ID: "U2"}, {ID: "U1"}}
+	err = o.postReviewerThreadReply(channel, act, reviewers)
+	require.NoError(t, err)
+	posts := recorder.callsTo("chat.postMessage")
+	require.Len(t, posts, 2)
+	assert.Equal(t, parent.Timestamp, posts[1].Values.Get("thread_ts"))
+	assert.Equal(t, "<@U1> <@U2> please review", posts[1].Values.Get("text"))
+
+	// the same reviewers in a different order are not notified again
+	err = o.postReviewerThreadReply(channel, act, []*slack.User{{ID: "U1"}, {ID: "U2"}})
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("chat.postMessage"), 2)
+	assert.Len(t, recorder.callsTo("chat.update"), 0)
+
+	// a new reviewer updates the reply in place
+	err = o.postReviewerThreadReply(channel, act, []*slack.User{{ID: "U1"}, {ID: "U3"}})
+	require.NoError(t, err)
+	assert.Len(t, recorder.callsTo("chat.postMessage"), 2)
+	updates := recorder.callsTo("chat.update")
+	require.Len(t, updates, 1)
+	assert.Equal(t, "<@U1> <@U3> please review", updates[0].Values.Get("text"))
+}
+
+func Test_reviewerThreadReplyText(t *testing.T) {
+	assert.Equal(t, "", reviewerThreadReplyText("%s please review", nil))
+	assert.Equal(t, "<@U1> please review", reviewerThreadReplyText("%s please review", []*slack.User{{ID: "U1"}, nil, {ID: "U1"}}))
+}
+
+func TestSlackBotOptions_PipelineMessage_broadcastTerminalStatus(t *testing.T) {
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient:                client,
+		Timestamps:                 make(map[string]map[string]*MessageReference),
+		Pipelines:                  []slackapp.SlackBotMode{{Channel: "#cheese"}},
+		ThreadStages:               true,
+		BroadcastTerminalToChannel: true,
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+	act.Stages = nil
+
+	// the intermediate updates aren't broadcast
+	act.Status = v1alpha1.RunningState
+	require.NoError(t, o.PipelineMessage(act))
+	require.Len(t, client.callsTo("chat.postMessage"), 1)
+
+	act.Status = v1alpha1.FailureState
+	require.NoError(t, o.PipelineMessage(act))
+	posts := client.callsTo("chat.postMessage")
+	require.Len(t, posts, 2)
+	assert.Equal(t, "true", posts[1].Values.Get("reply_broadcast"))
+	assert.NotEmpty(t, posts[1].Values.Get("thread_ts"))
+	assert.Contains(t, posts[1].Values.Get("text"), "build failed")
+
+	// the final status is only broadcast once
+	act.LogURL = "https://dashboard.example.com/logs"
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 2)
+}
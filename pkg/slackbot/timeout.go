@@ -0,0 +1,64 @@
+package slackbot
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultExternalCallTimeout is how long each call to Kubernetes, the Git provider or Slack may take by default
+const DefaultExternalCallTimeout = 30 * time.Second
+
+// withTimeout returns a context which is cancelled once the ExternalCallTimeout has elapsed, or only when the parent
+// is cancelled if there is no timeout
+func (o *SlackBotOptions) withTimeout(parent context.Context) (context.Context, context.CancelFunc) {
+	if o.ExternalCallTimeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, o.ExternalCallTimeout)
+}
+
+// withTimeout returns a context for the calls made on behalf of all the bots, which is cancelled once the largest
+// ExternalCallTimeout of the bots has elapsed, or only when the parent is cancelled if one of them has no timeout
+func (s *SlackBots) withTimeout(parent context.Context) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(0)
+	for _, o := range s.Items {
+		if o.ExternalCallTimeout <= 0 {
+			return context.WithCancel(parent)
+		}
+		if o.ExternalCallTimeout > timeout {
+			timeout = o.ExternalCallTimeout
+		}
+	}
+	if timeout == 0 {
+		timeout = DefaultExternalCallTimeout
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// callWithContext runs call, returning as soon as the context is done if call hasn't returned by then. The clients
+// used for Kubernetes and the Git providers don't take a context, so call carries on in the background and its
+// result is discarded.
+func callWithContext(ctx context.Context, description string, call func() error) error {
+	if ctx.Err() != nil {
+		return contextError(ctx, description)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- call()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return contextError(ctx, description)
+	}
+}
+
+func contextError(ctx context.Context, description string) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Wrapf(ctx.Err(), "timed out %s", description)
+	}
+	return errors.Wrapf(ctx.Err(), "cancelled %s", description)
+}
@@ -0,0 +1,108 @@
+package slackbot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_callWithContext(t *testing.T) {
+	// calls which return in time report their own result
+	err := callWithContext(context.Background(), "getting cheese", func() error {
+		return errors.New("no cheese")
+	})
+	assert.EqualError(t, err, "no cheese")
+
+	// calls which are already cancelled aren't made
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err = callWithContext(ctx, "getting cheese", func() error {
+		called = true
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+	assert.False(t, called)
+
+	// slow calls are abandoned
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	release := make(chan struct{})
+	defer close(release)
+	err = callWithContext(ctx, "getting cheese", func() error {
+		<-release
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	assert.Contains(t, err.Error(), "timed out getting cheese")
+}
+
+func TestSlackBots_withTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts []time.Duration
+		want     time.Duration
+	}{
+		{name: "no_bots", want: DefaultExternalCallTimeout},
+		{name: "largest_timeout", timeouts: []time.Duration{time.Minute, 2 * time.Minute}, want: 2 * time.Minute},
+		{name: "no_timeout", timeouts: []time.Duration{time.Minute, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SlackBots{}
+			for _, timeout := range tt.timeouts {
+				s.Items = append(s.Items, &SlackBotOptions{ExternalCallTimeout: timeout})
+			}
+			ctx, cancel := s.withTimeout(context.Background())
+			defer cancel()
+			deadline, ok := ctx.Deadline()
+			if tt.want == 0 {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(tt.want), deadline, time.Second)
+		})
+	}
+}
+
+func TestSlackBotOptions_timeouts(t *testing.T) {
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: "jx",
+			JXClient:  jxfake.NewSimpleClientset(),
+		},
+		ExternalCallTimeout: time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := o.getPipelineActivities(ctx, "cheese", "wine", 1)
+	require.Error(t, err)
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+
+	calls := 0
+	err = o.postWithRetry(ctx, "posting cheese", func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, 0, calls)
+
+	// Slack calls which time out aren't retried
+	err = o.postWithRetry(context.Background(), "posting cheese", func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out posting cheese")
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,341 @@
+package slackbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	timestampsConfigMapKey = "timestamps.json"
+
+	// DefaultTimestampTTL is how long the references to the messages are kept after they were last updated
+	DefaultTimestampTTL = 7 * 24 * time.Hour
+	// minTimestampGCInterval is the minimum interval between two evictions of the expired references
+	minTimestampGCInterval = time.Minute
+)
+
+// TimestampStore persists the references to the messages posted for each channel and activity so that messages can
+// be updated rather than re-created after a restart
+type TimestampStore interface {
+	// Get returns the reference stored for the channel and activity name, or nil if there is none
+	Get(channel string, name string) (*MessageReference, error)
+	// Set stores the reference for the channel and activity name
+	Set(channel string, name string, ref *MessageReference) error
+	// Delete removes the reference stored for the channel and activity name
+	Delete(channel string, name string) error
+	// List returns all the stored references keyed by channel and activity name
+	List() (map[string]map[string]*MessageReference, error)
+}
+
+// MemoryTimestampStore is a TimestampStore which only keeps references in memory
+type MemoryTimestampStore struct {
+	lock       sync.RWMutex
+	timestamps map[string]map[string]*MessageReference
+}
+
+// NewMemoryTimestampStore creates an empty MemoryTimestampStore
+func NewMemoryTimestampStore() *MemoryTimestampStore {
+	return &MemoryTimestampStore{
+		timestamps: make(map[string]map[string]*MessageReference),
+	}
+}
+
+// Get returns the reference stored for the channel and activity name, or nil if there is none
+func (s *MemoryTimestampStore) Get(channel string, name string) (*MessageReference, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.timestamps[channel][name], nil
+}
+
+// Set stores the reference for the channel and activity name
+func (s *MemoryTimestampStore) Set(channel string, name string, ref *MessageReference) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.timestamps[channel]; !ok {
+		s.timestamps[channel] = make(map[string]*MessageReference)
+	}
+	s.timestamps[channel][name] = ref
+	return nil
+}
+
+// Delete removes the reference stored for the channel and activity name
+func (s *MemoryTimestampStore) Delete(channel string, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.timestamps[channel], name)
+	return nil
+}
+
+// List returns all the stored references keyed by channel and activity name
+func (s *MemoryTimestampStore) List() (map[string]map[string]*MessageReference, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return copyTimestamps(s.timestamps), nil
+}
+
+// ConfigMapTimestampStore is a TimestampStore which persists references as JSON in a Kubernetes ConfigMap
+type ConfigMapTimestampStore struct {
+	KubeClient kubernetes.Interface
+	Namespace  string
+	Name       string
+	// lock serializes the writes, which read and update the whole ConfigMap
+	lock sync.Mutex
+}
+
+// NewConfigMapTimestampStore creates a TimestampStore backed by the named ConfigMap
+func NewConfigMapTimestampStore(kubeClient kubernetes.Interface, namespace string, name string) *ConfigMapTimestampStore {
+	return &ConfigMapTimestampStore{
+		KubeClient: kubeClient,
+		Namespace:  namespace,
+		Name:       name,
+	}
+}
+
+// Get returns the reference stored for the channel and activity name, or nil if there is none
+func (s *ConfigMapTimestampStore) Get(channel string, name string) (*MessageReference, error) {
+	timestamps, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return timestamps[channel][name], nil
+}
+
+// Set stores the reference for the channel and activity name
+func (s *ConfigMapTimestampStore) Set(channel string, name string, ref *MessageReference) error {
+	return s.update(func(timestamps map[string]map[string]*MessageReference) bool {
+		if _, ok := timestamps[channel]; !ok {
+			timestamps[channel] = make(map[string]*MessageReference)
+		}
+		timestamps[channel][name] = ref
+		return true
+	})
+}
+
+// Delete removes the reference stored for the channel and activity name
+func (s *ConfigMapTimestampStore) Delete(channel string, name string) error {
+	return s.update(func(timestamps map[string]map[string]*MessageReference) bool {
+		if _, ok := timestamps[channel][name]; !ok {
+			return false
+		}
+		delete(timestamps[channel], name)
+		if len(timestamps[channel]) == 0 {
+			delete(timestamps, channel)
+		}
+		return true
+	})
+}
+
+// update applies the change to the stored references, and saves them unless the change returns false. The writes of
+// the store are serialized, and retried with the latest ConfigMap if another writer, such as a previous replica of
+// the bot, changed it in the meantime
+func (s *ConfigMapTimestampStore) update(change func(timestamps map[string]map[string]*MessageReference) bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		err = errors.Cause(err)
+		return kubeerrors.IsConflict(err) || kubeerrors.IsAlreadyExists(err)
+	}, func() error {
+		timestamps, cm, err := s.load()
+		if err != nil {
+			return err
+		}
+		if !change(timestamps) {
+			return nil
+		}
+		return s.save(timestamps, cm)
+	})
+}
+
+// List returns all the stored references keyed by channel and activity name
+func (s *ConfigMapTimestampStore) List() (map[string]map[string]*MessageReference, error) {
+	timestamps, _, err := s.load()
+	return timestamps, err
+}
+
+// load reads the references from the ConfigMap, returning a nil ConfigMap if it does not exist yet
+func (s *ConfigMapTimestampStore) load() (map[string]map[string]*MessageReference, *corev1.ConfigMap, error) {
+	timestamps := make(map[string]map[string]*MessageReference)
+	cm, err := s.KubeClient.CoreV1().ConfigMaps(s.Namespace).Get(s.Name, metav1.GetOptions{})
+	if err != nil {
+		if kubeerrors.IsNotFound(err) {
+			return timestamps, nil, nil
+		}
+		return nil, nil, errors.Wrapf(err, "getting ConfigMap %s in namespace %s", s.Name, s.Namespace)
+	}
+	data := cm.Data[timestampsConfigMapKey]
+	if data != "" {
+		err = json.Unmarshal([]byte(data), &timestamps)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unmarshaling %s in ConfigMap %s", timestampsConfigMapKey, s.Name)
+		}
+	}
+	return timestamps, cm, nil
+}
+
+// save writes the references to the ConfigMap, creating it if needed
+func (s *ConfigMapTimestampStore) save(timestamps map[string]map[string]*MessageReference, cm *corev1.ConfigMap) error {
+	data, err := json.Marshal(timestamps)
+	if err != nil {
+		return errors.Wrapf(err, "marshaling timestamps for ConfigMap %s", s.Name)
+	}
+	configMaps := s.KubeClient.CoreV1().ConfigMaps(s.Namespace)
+	if cm == nil {
+		_, err = configMaps.Create(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.Name,
+				Namespace: s.Namespace,
+			},
+			Data: map[string]string{
+				timestampsConfigMapKey: string(data),
+			},
+		})
+		return errors.Wrapf(err, "creating ConfigMap %s in namespace %s", s.Name, s.Namespace)
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[timestampsConfigMapKey] = string(data)
+	_, err = configMaps.Update(cm)
+	return errors.Wrapf(err, "updating ConfigMap %s in namespace %s", s.Name, s.Namespace)
+}
+
+// timestampsConfigMapName returns the name of the ConfigMap used to store the timestamps for the bot
+func timestampsConfigMapName(botName string) string {
+	return fmt.Sprintf("slack-timestamps-%s", botName)
+}
+
+func copyTimestamps(timestamps map[string]map[string]*MessageReference) map[string]map[string]*MessageReference {
+	answer := make(map[string]map[string]*MessageReference, len(timestamps))
+	for channel, refs := range timestamps {
+		answer[channel] = make(map[string]*MessageReference, len(refs))
+		for name, ref := range refs {
+			answer[channel][name] = ref
+		}
+	}
+	return answer
+}
+
+// storeMessageReference records the reference to the message posted for the name in the channel, persisting it in
+// the TimestampStore if there is one
+func (o *SlackBotOptions) storeMessageReference(channel string, name string, ref *MessageReference) {
+	ref.LastUpdated = time.Now()
+	o.timestampsLock.Lock()
+	if _, ok := o.Timestamps[channel]; !ok {
+		o.Timestamps[channel] = make(map[string]*MessageReference)
+	}
+	o.Timestamps[channel][name] = ref
+	o.timestampsLock.Unlock()
+	if o.TimestampStore != nil {
+		err := o.TimestampStore.Set(channel, name, ref)
+		if err != nil {
+			log.Logger().Warnf("failed to persist message reference for %s in %s: %v", name, channel, err)
+		}
+	}
+}
+
+// removeMessageReference forgets the reference to the message posted for the name in the channel, removing it from
+// the TimestampStore if there is one
+func (o *SlackBotOptions) removeMessageReference(channel string, name string) {
+	o.timestampsLock.Lock()
+	delete(o.Timestamps[channel], name)
+	o.timestampsLock.Unlock()
+	if o.TimestampStore != nil {
+		err := o.TimestampStore.Delete(channel, name)
+		if err != nil {
+			log.Logger().Warnf("failed to remove message reference for %s in %s: %v", name, channel, err)
+		}
+	}
+}
+
+// messageReference returns the reference to the message posted for the name in the channel, or nil if there is none
+func (o *SlackBotOptions) messageReference(channel string, name string) *MessageReference {
+	o.timestampsLock.RLock()
+	defer o.timestampsLock.RUnlock()
+	return o.Timestamps[channel][name]
+}
+
+// messageReferencesWithPrefix returns the references to the messages posted in the channel whose name starts with
+// the prefix
+func (o *SlackBotOptions) messageReferencesWithPrefix(channel string, prefix string) map[string]*MessageReference {
+	o.timestampsLock.RLock()
+	defer o.timestampsLock.RUnlock()
+	answer := make(map[string]*MessageReference)
+	for name, ref := range o.Timestamps[channel] {
+		if strings.HasPrefix(name, prefix) {
+			answer[name] = ref
+		}
+	}
+	return answer
+}
+
+// evictExpiredMessageReferences forgets the references which were last updated more than TimestampTTL before now,
+// returning the number of references evicted
+func (o *SlackBotOptions) evictExpiredMessageReferences(now time.Time) int {
+	if o.TimestampTTL <= 0 {
+		return 0
+	}
+	type expiredRef struct {
+		channel string
+		name    string
+	}
+	var expired []expiredRef
+	o.timestampsLock.Lock()
+	for channel, refs := range o.Timestamps {
+		for name, ref := range refs {
+			if ref != nil && !ref.LastUpdated.IsZero() && now.Sub(ref.LastUpdated) > o.TimestampTTL {
+				delete(refs, name)
+				expired = append(expired, expiredRef{channel: channel, name: name})
+			}
+		}
+		if len(refs) == 0 {
+			delete(o.Timestamps, channel)
+		}
+	}
+	o.timestampsLock.Unlock()
+
+	// the store is updated outside of the lock as it may be slow
+	if o.TimestampStore != nil {
+		for _, ref := range expired {
+			err := o.TimestampStore.Delete(ref.channel, ref.name)
+			if err != nil {
+				log.Logger().Warnf("failed to remove expired message reference for %s in %s: %v", ref.name,
+					ref.channel, err)
+			}
+		}
+	}
+	return len(expired)
+}
+
+// RunTimestampGC periodically evicts the references to the messages older than TimestampTTL, until stop is closed
+func (o *SlackBotOptions) RunTimestampGC(stop <-chan struct{}) {
+	if o.TimestampTTL <= 0 {
+		return
+	}
+	interval := o.TimestampTTL / 10
+	if interval < minTimestampGCInterval {
+		interval = minTimestampGCInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if evicted := o.evictExpiredMessageReferences(now); evicted > 0 {
+				log.Logger().Infof("Evicted %d expired message references for %s\n", evicted, o.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package slackbot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestConfigMapTimestampStore(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	store := NewConfigMapTimestampStore(kubeClient, testNs, timestampsConfigMapName("test-bot"))
+
+	ref, err := store.Get("#test", "activity-1")
+	require.NoError(t, err)
+	assert.Nil(t, ref)
+
+	err = store.Set("#test", "activity-1", &MessageReference{ChannelID: "C0001", Timestamp: "1.000100"})
+	require.NoError(t, err)
+	err = store.Set("#test", "activity-2", &MessageReference{ChannelID: "C0001", Timestamp: "2.000100"})
+	require.NoError(t, err)
+
+	// a new store reading the same ConfigMap sees the persisted references, as the bot would after a restart
+	restarted := NewConfigMapTimestampStore(kubeClient, testNs, timestampsConfigMapName("test-bot"))
+	ref, err = restarted.Get("#test", "activity-1")
+	require.NoError(t, err)
+	assert.Equal(t, &MessageReference{ChannelID: "C0001", Timestamp: "1.000100"}, ref)
+
+	timestamps, err := restarted.List()
+	require.NoError(t, err)
+	assert.Len(t, timestamps["#test"], 2)
+
+	err = restarted.Delete("#test", "activity-1")
+	require.NoError(t, err)
+	ref, err = store.Get("#test", "activity-1")
+	require.NoError(t, err)
+	assert.Nil(t, ref)
+}
+
+func TestConfigMapTimestampStore_concurrentSet(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	store := NewConfigMapTimestampStore(kubeClient, testNs, timestampsConfigMapName("test-bot"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ref := &MessageReference{ChannelID: "C0001", Timestamp: fmt.Sprintf("%d.000100", i)}
+			assert.NoError(t, store.Set("#test", fmt.Sprintf("activity-%d", i), ref))
+		}(i)
+	}
+	wg.Wait()
+
+	timestamps, err := store.List()
+	require.NoError(t, err)
+	assert.Len(t, timestamps["#test"], 20)
+}
+
+func TestConfigMapTimestampStore_retryOnConflict(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	store := NewConfigMapTimestampStore(kubeClient, testNs, timestampsConfigMapName("test-bot"))
+	require.NoError(t, store.Set("#test", "activity-1", &MessageReference{ChannelID: "C0001", Timestamp: "1.000100"}))
+
+	// another writer updated the ConfigMap in the meantime: the first update is rejected
+	conflicts := 0
+	kubeClient.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts > 0 {
+			return false, nil, nil
+		}
+		conflicts++
+		return true, nil, kubeerrors.NewConflict(corev1.Resource("configmaps"), store.Name, errors.New("modified"))
+	})
+
+	require.NoError(t, store.Set("#test", "activity-2", &MessageReference{ChannelID: "C0001", Timestamp: "2.000100"}))
+	assert.Equal(t, 1, conflicts)
+	timestamps, err := store.List()
+	require.NoError(t, err)
+	assert.Len(t, timestamps["#test"], 2)
+}
+
+func TestSlackBotOptions_storeMessageReference(t *testing.T) {
+	store := NewMemoryTimestampStore()
+	o := &SlackBotOptions{
+		Timestamps:     make(map[string]map[string]*MessageReference),
+		TimestampStore: store,
+	}
+	ref := &MessageReference{ChannelID: "C0001", Timestamp: "1.000100"}
+	o.storeMessageReference("#test", "activity-1", ref)
+
+	assert.Equal(t, ref, o.Timestamps["#test"]["activity-1"])
+	stored, err := store.Get("#test", "activity-1")
+	require.NoError(t, err)
+	assert.Equal(t, ref, stored)
+}
+
+func TestSlackBotOptions_evictExpiredMessageReferences(t *testing.T) {
+	store := NewMemoryTimestampStore()
+	o := &SlackBotOptions{
+		Timestamps:     make(map[string]map[string]*MessageReference),
+		TimestampStore: store,
+		TimestampTTL:   time.Hour,
+	}
+	o.storeMessageReference("#test", "activity-1", &MessageReference{ChannelID: "C0001", Timestamp: "1.000100"})
+	o.storeMessageReference("#other", "activity-2", &MessageReference{ChannelID: "C0002", Timestamp: "2.000100"})
+	o.Timestamps["#test"]["activity-1"].LastUpdated = time.Now().Add(-2 * time.Hour)
+
+	evicted := o.evictExpiredMessageReferences(time.Now())
+	assert.Equal(t, 1, evicted)
+	assert.Nil(t, o.messageReference("#test", "activity-1"))
+	assert.NotContains(t, o.Timestamps, "#test")
+	assert.NotNil(t, o.messageReference("#other", "activity-2"))
+	stored, err := store.Get("#test", "activity-1")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	// references are kept forever without a TTL
+	o.TimestampTTL = 0
+	evicted = o.evictExpiredMessageReferences(time.Now().Add(365 * 24 * time.Hour))
+	assert.Equal(t, 0, evicted)
+	assert.NotNil(t, o.messageReference("#other", "activity-2"))
+}
+
+func TestSlackBotOptions_postMessageConcurrently(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	store := NewMemoryTimestampStore()
+	o := &SlackBotOptions{
+		SlackClient:    recorder.client(),
+		Timestamps:     make(map[string]map[string]*MessageReference),
+		TimestampStore: store,
+		TimestampTTL:   time.Hour,
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Stages = nil
+
+	const count = 50
+	channel := "#cheese"
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*count)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			activity := *act
+			activity.Name = fmt.Sprintf("%s-%d", act.Name, i)
+			attachments := []slack.Attachment{{Text: activity.Name}}
+			errs <- o.postMessage(channel, false, pipelineMessageType, &activity, nil, attachments, nil, true)
+			// update the message while other activities are posted and the expired references evicted
+			attachments = []slack.Attachment{{Text: activity.Name + " updated"}}
+			errs <- o.postMessage(channel, false, pipelineMessageType, &activity, nil, attachments, nil, true)
+			o.evictExpiredMessageReferences(time.Now())
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < count; i++ {
+		assert.NotNil(t, o.messageReference(channel, fmt.Sprintf("%s-%d", act.Name, i)))
+	}
+	stored, err := store.List()
+	require.NoError(t, err)
+	assert.Len(t, stored[channel], count)
+	assert.Len(t, recorder.callsTo("chat.postMessage"), count)
+	assert.Len(t, recorder.callsTo("chat.update"), count)
+}
@@ -0,0 +1,101 @@
+package slackbot
+
+import (
+	"context"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// tokenWatchRetryInterval is how long to wait before watching the token Secret again once the watch ended
+const tokenWatchRetryInterval = 10 * time.Second
+
+// slackClient returns the Slack client, which may be replaced concurrently when the token is rotated
+func (o *SlackBotOptions) slackClient() SlackClienter {
+	o.slackClientLock.RLock()
+	defer o.slackClientLock.RUnlock()
+	return o.SlackClient
+}
+
+// updateSlackToken replaces the Slack clients of the bot and its user resolver if the token changed, returning true if
+// it did
+func (o *SlackBotOptions) updateSlackToken(token string) bool {
+	o.slackClientLock.Lock()
+	if token == "" || token == o.token {
+		o.slackClientLock.Unlock()
+		return false
+	}
+	client := o.newSlackClient(token)
+	o.SlackClient = client
+	o.token = token
+	o.slackClientLock.Unlock()
+
+	if o.SlackUserResolver != nil {
+		o.SlackUserResolver.setSlackClient(client)
+	}
+	log.Logger().Infof("Reloaded the Slack token of %s from Secret %s\n", o.Name, o.TokenSecretName)
+	return true
+}
+
+// ReadinessCheck checks the Slack API can be reached with the current token of the bot
+func (o *SlackBotOptions) ReadinessCheck() ReadinessCheck {
+	return func(ctx context.Context) error {
+		if o.webAPIDisabled() {
+			// the incoming webhooks can't be checked without posting
+			return nil
+		}
+		return SlackReadinessCheck(o.slackClient())(ctx)
+	}
+}
+
+// WatchTokenSecret reloads the Slack client whenever the token in the Secret is rotated, until stop is closed
+func (o *SlackBotOptions) WatchTokenSecret(stop <-chan struct{}) {
+	if o.TokenSecretName == "" {
+		return
+	}
+	for {
+		err := o.watchTokenSecret(stop)
+		if err != nil {
+			log.Logger().Warnf("failed to watch the token Secret %s of %s: %v", o.TokenSecretName, o.Name, err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(tokenWatchRetryInterval):
+		}
+	}
+}
+
+// watchTokenSecret watches the token Secret until the watch ends or stop is closed
+func (o *SlackBotOptions) watchTokenSecret(stop <-chan struct{}) error {
+	w, err := o.KubeClient.CoreV1().Secrets(o.GlobalClients.Namespace).Watch(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", o.TokenSecretName).String(),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "watching Secret %s", o.TokenSecretName)
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+			secret, ok := event.Object.(*corev1.Secret)
+			if !ok || secret.Name != o.TokenSecretName {
+				continue
+			}
+			o.updateSlackToken(string(secret.Data[o.TokenSecretKey]))
+		}
+	}
+}
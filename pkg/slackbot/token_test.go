@@ -0,0 +1,82 @@
+package slackbot
+
+import (
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_tokenSecret(t *testing.T) {
+	slackBot := &slackapp.SlackBot{
+		Spec: slackapp.SlackBotSpec{
+			TokenReference: jenkinsv1.ResourceReference{Kind: "Secret", Name: "slack-token"},
+		},
+	}
+	name, key, err := tokenSecret(slackBot)
+	require.NoError(t, err)
+	assert.Equal(t, "slack-token", name)
+	assert.Equal(t, DefaultTokenSecretKey, key)
+
+	slackBot.Spec.TokenSecretRef = &slackapp.SecretKeyReference{Name: "external-secret", Key: "bot-token"}
+	name, key, err = tokenSecret(slackBot)
+	require.NoError(t, err)
+	assert.Equal(t, "external-secret", name)
+	assert.Equal(t, "bot-token", key)
+
+	slackBot.Spec.TokenSecretRef = nil
+	slackBot.Spec.TokenReference.Kind = "ConfigMap"
+	_, _, err = tokenSecret(slackBot)
+	assert.Error(t, err)
+}
+
+func TestSlackBotOptions_WatchTokenSecret(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	watcher := watch.NewFake()
+	kubeClient.PrependWatchReactor("secrets", k8stesting.DefaultWatchReactor(watcher, nil))
+	resolver := NewSlackUserResolver(nil, nil, "jx")
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace:         "jx",
+			KubeClient:        kubeClient,
+			slackClientHelper: &slackWrapper{},
+		},
+		TokenSecretName:   "slack-token",
+		TokenSecretKey:    "token",
+		SlackUserResolver: &resolver,
+		token:             "xoxb-1",
+	}
+	original := o.slackClient()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		o.WatchTokenSecret(stop)
+		close(done)
+	}()
+	secret := func(name string, token string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "jx"},
+			Data:       map[string][]byte{"token": []byte(token)},
+		}
+	}
+	// the fake watcher blocks until each event is consumed, so the previous one has been handled once the next is sent
+	watcher.Modify(secret("slack-token", "xoxb-1"))
+	watcher.Modify(secret("other", "xoxb-other"))
+	watcher.Modify(secret("slack-token", "xoxb-2"))
+	watcher.Delete(secret("slack-token", "xoxb-2"))
+	close(stop)
+	<-done
+
+	assert.Equal(t, "xoxb-2", o.token)
+	assert.NotNil(t, o.slackClient())
+	assert.NotEqual(t, original, o.slackClient())
+	assert.Equal(t, o.slackClient(), resolver.SlackClient)
+}
@@ -0,0 +1,148 @@
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/slack-go/slack"
+)
+
+// topicUpdateInterval is the minimum time between two updates of the topic of a channel. Slack heavily rate limits
+// the topic changes, and each of them is announced in the channel, so the later releases are deferred until it elapsed.
+const topicUpdateInterval = 10 * time.Minute
+
+// channelTopic is the topic last set by the bot in a channel
+type channelTopic struct {
+	topic   string
+	updated time.Time
+	// pending is the latest topic deferred until topicUpdateInterval elapsed, empty if there is none
+	pending string
+	// timer sets the pending topic
+	timer *time.Timer
+}
+
+// TopicSetter sets the topic of the Slack channels, implemented by *slack.Client
+type TopicSetter interface {
+	SetTopicOfConversationContext(ctx context.Context, channelID, topic string) (*slack.Channel, error)
+}
+
+// releaseTopic returns the topic of a channel showing the release of the activity, prefixed with the emoji of the
+// successful status
+func releaseTopic(activity *record.ActivityRecord, version string, success *slackapp.Status) string {
+	release := activity.Repo
+	if version != "" {
+		release = fmt.Sprintf("%s %s", release, version)
+	}
+	emoji := ""
+	if success != nil {
+		emoji = success.Emoji
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s released", emoji, release))
+}
+
+// updateChannelTopic sets the topic of the channel the promotion message for the activity was posted to. The topic
+// is updated at most once per topicUpdateInterval for each channel, and not at all when it wouldn't change. The topics
+// of the releases in between are deferred, only the latest one is set once the interval elapsed.
+func (o *SlackBotOptions) updateChannelTopic(channel string, activity *record.ActivityRecord, topic string) error {
+	messageRef := o.messageReference(channel, activity.Name)
+	if messageRef == nil || messageRef.ChannelID == "" {
+		// messages posted with incoming webhooks don't know the ID of their channel
+		return nil
+	}
+	setter, ok := o.slackClientFor(messageRef.Workspace).(TopicSetter)
+	if !ok {
+		return nil
+	}
+	logger := messageLogger(activity, channel, promotionMessageType)
+	channelID := messageRef.ChannelID
+	now := time.Now()
+	o.topicsLock.Lock()
+	if o.topics == nil {
+		o.topics = make(map[string]*channelTopic)
+	}
+	last := o.topics[channelID]
+	if last != nil && last.topic == topic {
+		// a topic deferred for an earlier release would replace the one of this release
+		last.pending = ""
+		o.topicsLock.Unlock()
+		logger.Debugf("Not updating the topic of %s to %s, it is unchanged\n", channel, topic)
+		return nil
+	}
+	if last != nil && now.Sub(last.updated) < topicUpdateInterval {
+		last.pending = topic
+		if last.timer == nil {
+			last.timer = time.AfterFunc(last.updated.Add(topicUpdateInterval).Sub(now), func() {
+				o.setPendingTopic(channel, channelID, setter)
+			})
+		}
+		o.topicsLock.Unlock()
+		logger.Infof("Deferring the update of the topic of %s to %s, it was last updated at %s\n", channel, topic,
+			last.updated)
+		return nil
+	}
+	if last != nil && last.timer != nil {
+		last.timer.Stop()
+	}
+	// the update is reserved before calling Slack, so that concurrent promotions don't both update the topic
+	o.topics[channelID] = &channelTopic{topic: topic, updated: now}
+	o.topicsLock.Unlock()
+
+	err := o.setChannelTopic(channel, channelID, setter, topic, last)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Topic of %s set to %s\n", channel, topic)
+	return nil
+}
+
+// setPendingTopic sets the topic deferred by updateChannelTopic, unless a later update already replaced it
+func (o *SlackBotOptions) setPendingTopic(channel string, channelID string, setter TopicSetter) {
+	o.topicsLock.Lock()
+	last := o.topics[channelID]
+	if last == nil || last.pending == "" {
+		if last != nil {
+			last.timer = nil
+		}
+		o.topicsLock.Unlock()
+		return
+	}
+	topic := last.pending
+	last.pending, last.timer = "", nil
+	o.topics[channelID] = &channelTopic{topic: topic, updated: time.Now()}
+	o.topicsLock.Unlock()
+
+	err := o.setChannelTopic(channel, channelID, setter, topic, last)
+	if err != nil {
+		log.Logger().Warnf("failed to set the deferred topic of %s: %v", channel, err)
+		return
+	}
+	log.Logger().Infof("Topic of %s set to %s\n", channel, topic)
+}
+
+// setChannelTopic sets the topic of the channel, the last topic is restored if it fails so that the topic is updated
+// again with the next release
+func (o *SlackBotOptions) setChannelTopic(channel string, channelID string, setter TopicSetter, topic string,
+	last *channelTopic) error {
+	err := o.postWithRetry(context.Background(), "setting topic", func(ctx context.Context) error {
+		defer observeSlackAPICall("conversations.setTopic", time.Now())
+		_, err := setter.SetTopicOfConversationContext(ctx, channelID, topic)
+		return err
+	})
+	if err != nil {
+		o.topicsLock.Lock()
+		if last != nil {
+			o.topics[channelID] = last
+		} else {
+			delete(o.topics, channelID)
+		}
+		o.topicsLock.Unlock()
+		return errors.Wrapf(err, "setting the topic of %s", channel)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package slackbot
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/users"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	gitProviderKeyPrefix = "jenkins.io/git-"
+	gitProviderKeySuffix = "-userid"
+	unknownGitProvider   = "unknown"
+)
+
+// UnmappedUser is a Git user who authored recent pipelines but can't be resolved to a Slack user
+type UnmappedUser struct {
+	Login    string
+	Provider string
+	// Activities is the number of recent PipelineActivities authored by the user
+	Activities int
+}
+
+// gitProviderKind returns the kind of the Git provider of the user, from the account the GitUserResolver added to the
+// user, or unknown
+func gitProviderKind(user *jenkinsv1.User) string {
+	for _, a := range user.Spec.Accounts {
+		if strings.HasPrefix(a.Provider, gitProviderKeyPrefix) && strings.HasSuffix(a.Provider, gitProviderKeySuffix) {
+			return strings.TrimSuffix(strings.TrimPrefix(a.Provider, gitProviderKeyPrefix), gitProviderKeySuffix)
+		}
+	}
+	return unknownGitProvider
+}
+
+// UnmappedUsers returns the authors of the PipelineActivities created since the given time who can't be resolved to
+// a Slack user, sorted by login, so that the missing user mappings can be added
+func (o *SlackBotOptions) UnmappedUsers(since time.Time) ([]UnmappedUser, error) {
+	acts, err := o.JXClient.JenkinsV1().PipelineActivities(o.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing PipelineActivities in namespace %s", o.Namespace)
+	}
+	var errs []error
+	resolvers := make(map[string]*users.GitUserResolver)
+	unmapped := make(map[string]*UnmappedUser)
+	resolved := make(map[string]bool)
+	for _, pa := range acts.Items {
+		login, gitURL := pa.Spec.Author, pa.Spec.GitURL
+		if login == "" || gitURL == "" || pa.CreationTimestamp.Time.Before(since) {
+			continue
+		}
+		gitInfo, err := gits.ParseGitURL(gitURL)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "parsing the Git URL of %s", pa.Name))
+			continue
+		}
+		key := gitInfo.Host + "/" + login
+		if resolved[key] {
+			continue
+		}
+		if user, ok := unmapped[key]; ok {
+			user.Activities++
+			continue
+		}
+		resolver, ok := resolvers[gitInfo.Host]
+		if !ok {
+			gitProvider, _, err := o.createGitProviderForURL(gitURL)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "creating the Git provider of %s", gitInfo.Host))
+				continue
+			}
+			resolver = &users.GitUserResolver{Namespace: o.Namespace, GitProvider: gitProvider, JXClient: o.JXClient}
+			resolvers[gitInfo.Host] = resolver
+		}
+		id, err := o.resolveGitUserToSlackUser("", &gits.GitUser{Login: login}, resolver)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "resolving the Slack user of %s", login))
+			continue
+		}
+		if id != "" {
+			resolved[key] = true
+			continue
+		}
+		unmapped[key] = &UnmappedUser{Login: login, Provider: resolver.GitProvider.Kind(), Activities: 1}
+	}
+	answer := make([]UnmappedUser, 0, len(unmapped))
+	for _, user := range unmapped {
+		answer = append(answer, *user)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		if answer[i].Login != answer[j].Login {
+			return answer[i].Login < answer[j].Login
+		}
+		return answer[i].Provider < answer[j].Provider
+	})
+	return answer, utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,97 @@
+package slackbot
+
+import (
+	"testing"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// usersGitProvider knows every Git user by their login, the other methods of the provider aren't implemented
+type usersGitProvider struct {
+	gits.GitProvider
+}
+
+func (p *usersGitProvider) UserInfo(login string) *gits.GitUser {
+	return &gits.GitUser{Login: login}
+}
+
+func (p *usersGitProvider) Kind() string {
+	return gits.KindGitHub
+}
+
+func Test_gitProviderKind(t *testing.T) {
+	user := &jenkinsv1.User{Spec: jenkinsv1.UserDetails{Accounts: []jenkinsv1.AccountReference{
+		{Provider: "slack.apps.jenkins-x.com/userid", ID: "U1"},
+		{Provider: "jenkins.io/git-gitlab-userid", ID: "cheese"},
+	}}}
+	assert.Equal(t, "gitlab", gitProviderKind(user))
+	assert.Equal(t, "unknown", gitProviderKind(&jenkinsv1.User{}))
+}
+
+func TestSlackBotOptions_slackUserID_unresolved(t *testing.T) {
+	resolver := NewSlackUserResolver(nil, nil, "jx")
+	resolver.LookupByEmail = false
+	o := &SlackBotOptions{SlackUserResolver: &resolver}
+	user := &jenkinsv1.User{Spec: jenkinsv1.UserDetails{Login: "cheese", Accounts: []jenkinsv1.AccountReference{
+		{Provider: "jenkins.io/git-github-userid", ID: "cheese"},
+	}}}
+	unresolved := testutil.ToFloat64(unresolvedSlackUsers.WithLabelValues("github"))
+
+	id, err := o.slackUserID("", user)
+	require.NoError(t, err)
+	assert.Empty(t, id)
+	assert.Equal(t, unresolved+1, testutil.ToFloat64(unresolvedSlackUsers.WithLabelValues("github")))
+
+	// the users with a Slack account aren't counted
+	user.Spec.Accounts = append(user.Spec.Accounts, jenkinsv1.AccountReference{
+		Provider: resolver.SlackProviderKey(), ID: "U1"})
+	id, err = o.slackUserID("", user)
+	require.NoError(t, err)
+	assert.Equal(t, "U1", id)
+	assert.Equal(t, unresolved+1, testutil.ToFloat64(unresolvedSlackUsers.WithLabelValues("github")))
+}
+
+func TestSlackBotOptions_UnmappedUsers(t *testing.T) {
+	now := time.Now()
+	activity := func(name string, author string, created time.Time) *jenkinsv1.PipelineActivity {
+		return &jenkinsv1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "jx", CreationTimestamp: metav1.NewTime(created)},
+			Spec: jenkinsv1.PipelineActivitySpec{
+				Author: author,
+				GitURL: "https://github.com/cheese/wine.git",
+			},
+		}
+	}
+	jxClient := jxfake.NewSimpleClientset(
+		activity("cheese-wine-pr-1-1", "brie", now),
+		activity("cheese-wine-pr-1-2", "brie", now),
+		activity("cheese-wine-pr-2-1", "cheddar", now),
+		activity("cheese-wine-pr-3-1", "comte", now.Add(-30*24*time.Hour)),
+		activity("cheese-wine-master-1", "", now),
+	)
+	resolver := NewSlackUserResolver(nil, jxClient, "jx")
+	resolver.LookupByEmail = false
+	o := &SlackBotOptions{
+		GlobalClients: &GlobalClients{
+			Namespace: "jx",
+			JXClient:  jxClient,
+			gitProviderForURL: func(gitURL string) (gits.GitProvider, *gits.GitRepository, error) {
+				return &usersGitProvider{}, nil, nil
+			},
+		},
+		Namespace:         "jx",
+		SlackUserResolver: &resolver,
+		UserMappings:      map[string]string{"cheddar": "U1"},
+	}
+
+	unmapped, err := o.UnmappedUsers(now.Add(-7 * 24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []UnmappedUser{{Login: "brie", Provider: gits.KindGitHub, Activities: 2}}, unmapped)
+}
@@ -2,9 +2,11 @@ package slackbot
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/jenkins-x/jx-logging/pkg/log"
 
@@ -27,14 +29,27 @@ type SlackUserResolver struct {
 	JXClient     jenkninsv1client.Interface
 	Namespace    string
 	UserMappings map[string]string
+	// LookupByEmail enables looking up Slack users by email in the Slack directory
+	LookupByEmail bool
+
+	// emailCache caches the Slack IDs of the emails keyed by workspace, the default workspace being empty. The lock
+	// also guards SlackClient, which is replaced when the token is rotated
+	emailCache     map[string]map[string]string
+	emailCacheLock sync.Mutex
+}
+
+// UserByEmailGetter looks up the Slack users by email, implemented by *slack.Client
+type UserByEmailGetter interface {
+	GetUserByEmailContext(ctx context.Context, email string) (*slack.User, error)
 }
 
 // NewSlackUserResolver creates a new struct to work with resolving slack user details
 func NewSlackUserResolver(slackClient *slack.Client, jenkinsClient jenkninsv1client.Interface, namespace string) SlackUserResolver {
 	return SlackUserResolver{
-		SlackClient: slackClient,
-		JXClient:    jenkinsClient,
-		Namespace:   namespace,
+		SlackClient:   slackClient,
+		JXClient:      jenkinsClient,
+		Namespace:     namespace,
+		LookupByEmail: true,
 	}
 }
 
@@ -45,7 +60,7 @@ func (r *SlackUserResolver) SlackUserLogin(user *jenkinsv1.User) (string, error)
 			return a.ID, nil
 		}
 	}
-	if user.Spec.Email != "" {
+	if user.Spec.Email != "" && r.LookupByEmail {
 
 		// Attempt to lookup by email and associate
 		email, err := r.getSlackEmailFromMapping(user.Spec.Email, userMappingfile)
@@ -54,20 +69,57 @@ func (r *SlackUserResolver) SlackUserLogin(user *jenkinsv1.User) (string, error)
 			email = user.Spec.Email
 			log.Logger().Warnf("no mapped email address so using git user email %s to find id in slack", email)
 		}
-		slackUser, err := r.SlackClient.GetUserByEmail(email)
+		r.emailCacheLock.Lock()
+		client := r.SlackClient
+		r.emailCacheLock.Unlock()
+		id, err := r.lookupSlackUserIDByEmail("", client, email)
 		if err != nil {
 			return "", errors.Wrapf(err, "could not find Slack ID using email %s", email)
 		}
 		user.Spec.Accounts = append(user.Spec.Accounts, jenkinsv1.AccountReference{
 			Provider: r.SlackProviderKey(),
-			ID:       slackUser.ID,
+			ID:       id,
 		})
 		_, err = r.JXClient.JenkinsV1().Users(r.Namespace).Update(user)
-		return slackUser.ID, nil
+		return id, nil
 	}
 	return "", nil
 }
 
+// lookupSlackUserIDByEmail finds the Slack ID of the user with the email in the directory of the workspace of the
+// client, caching the result. The directory is looked up without holding the lock, so that the lookups of other users
+// aren't held back.
+func (r *SlackUserResolver) lookupSlackUserIDByEmail(workspace string, client UserByEmailGetter,
+	email string) (string, error) {
+	r.emailCacheLock.Lock()
+	id, ok := r.emailCache[workspace][email]
+	r.emailCacheLock.Unlock()
+	if ok {
+		return id, nil
+	}
+	slackUser, err := client.GetUserByEmailContext(context.Background(), email)
+	if err != nil {
+		return "", err
+	}
+	r.emailCacheLock.Lock()
+	defer r.emailCacheLock.Unlock()
+	if r.emailCache == nil {
+		r.emailCache = make(map[string]map[string]string)
+	}
+	if r.emailCache[workspace] == nil {
+		r.emailCache[workspace] = make(map[string]string)
+	}
+	r.emailCache[workspace][email] = slackUser.ID
+	return slackUser.ID, nil
+}
+
+// setSlackClient replaces the Slack client used to look up users, e.g. once the token is rotated
+func (r *SlackUserResolver) setSlackClient(client *slack.Client) {
+	r.emailCacheLock.Lock()
+	defer r.emailCacheLock.Unlock()
+	r.SlackClient = client
+}
+
 // SlackProviderKey returns the provider key for this SlackUserResolver
 func (r *SlackUserResolver) SlackProviderKey() string {
 	return fmt.Sprintf("slack.apps.jenkins-x.com/userid")
@@ -1,11 +1,18 @@
 package slackbot
 
 import (
+	"fmt"
+	"net/http"
 	"path"
 	"strings"
 	"testing"
 
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
 	"github.com/prometheus/common/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestSlackUserResolver_getSlackEmailFromMapping(t *testing.T) {
@@ -83,3 +90,37 @@ func TestSlackUserResolver_getSlackEmailFromMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestSlackUserResolver_SlackUserLoginByEmail(t *testing.T) {
+	recorder := newSlackRecorder(t)
+	defer recorder.Close()
+	recorder.handler = func(call slackCall, w http.ResponseWriter) bool {
+		if call.Method == "users.lookupByEmail" {
+			fmt.Fprint(w, `{"ok":true,"user":{"id":"U0001"}}`)
+			return true
+		}
+		return false
+	}
+	user := func() *jenkinsv1.User {
+		return &jenkinsv1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "wine"},
+			Spec:       jenkinsv1.UserDetails{Email: "wine@yummy.com"},
+		}
+	}
+
+	r := NewSlackUserResolver(recorder.client(), jxfake.NewSimpleClientset(), "jx")
+	r.UserMappings = map[string]string{"beer@yummy.com": "margarita@yummy.com"}
+	for i := 0; i < 2; i++ {
+		id, err := r.SlackUserLogin(user())
+		require.NoError(t, err)
+		assert.Equal(t, "U0001", id)
+	}
+	assert.Len(t, recorder.callsTo("users.lookupByEmail"), 1, "lookups should be cached")
+
+	r.LookupByEmail = false
+	r.emailCache = nil
+	id, err := r.SlackUserLogin(user())
+	require.NoError(t, err)
+	assert.Equal(t, "", id)
+	assert.Len(t, recorder.callsTo("users.lookupByEmail"), 1, "lookups should be disabled")
+}
@@ -1,29 +1,54 @@
 package slackbot
 
 import (
+	"math"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	jxkube "github.com/jenkins-x/jx/v2/pkg/kube"
 )
 
-type byBuildNumber []jenkinsv1.PipelineActivity
-
-func (s byBuildNumber) Len() int {
-	return len(s)
+// activityLess returns true if the activity a comes before b, the earliest activity of a pull request being the one
+// its review message is keyed on. The activities are ordered by start time, then by build number, compared
+// numerically so that build 10 comes after build 9, the missing or non-numeric build numbers coming last, and then by
+// name, so that any two activities are always ordered the same way
+func activityLess(a *jenkinsv1.PipelineActivity, b *jenkinsv1.PipelineActivity) bool {
+	startA, startB := startTime(a), startTime(b)
+	if !startA.Equal(startB) {
+		return startA.Before(startB)
+	}
+	na, nb := activityBuildNumber(a), activityBuildNumber(b)
+	if na != nb {
+		return na < nb
+	}
+	return a.Name < b.Name
 }
 
-func (s byBuildNumber) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
+// activityBuildNumber returns the build number of the activity, or math.MaxInt64 if it is missing or isn't a number
+func activityBuildNumber(activity *jenkinsv1.PipelineActivity) int64 {
+	n, err := strconv.ParseInt(jxkube.CreatePipelineDetails(activity).Build, 10, 64)
+	if err != nil {
+		return math.MaxInt64
+	}
+	return n
 }
 
-func (s byBuildNumber) getBuildNumber(activity jenkinsv1.PipelineActivity) string {
-	details := jxkube.CreatePipelineDetails(&activity)
-	return details.Build
+// startTime returns when the pipeline of the activity started, or when the activity was created if it hasn't
+func startTime(activity *jenkinsv1.PipelineActivity) time.Time {
+	if activity.Spec.StartedTimestamp != nil {
+		return activity.Spec.StartedTimestamp.Time
+	}
+	return activity.CreationTimestamp.Time
 }
 
-func (s byBuildNumber) Less(i, j int) bool {
-	return s.getBuildNumber(s[i]) < s.getBuildNumber(s[j])
+// sortPipelineActivities sorts the activities from the earliest to the latest
+func sortPipelineActivities(activities []jenkinsv1.PipelineActivity) {
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activityLess(&activities[i], &activities[j])
+	})
 }
 
 func containsIgnoreCase(s []string, e string) bool {
@@ -34,3 +59,38 @@ func containsIgnoreCase(s []string, e string) bool {
 	}
 	return false
 }
+
+// httpsGitURL returns the HTTPS URL of a Git repository cloned over SSH, such as git@github.com:org/repo.git or
+// ssh://git@github.com/org/repo.git, so that it can be linked to. Other URLs are returned unchanged.
+func httpsGitURL(gitURL string) string {
+	for _, scheme := range []string{"ssh://", "git+ssh://", "ssh+git://"} {
+		if strings.HasPrefix(gitURL, scheme) {
+			hostAndPath := strings.TrimPrefix(gitURL, scheme)
+			if idx := strings.Index(hostAndPath, "@"); idx >= 0 && idx < strings.Index(hostAndPath+"/", "/") {
+				hostAndPath = hostAndPath[idx+1:]
+			}
+			// the port is the one of the SSH server, not of the web server
+			host, path := hostAndPath, ""
+			if idx := strings.Index(hostAndPath, "/"); idx >= 0 {
+				host, path = hostAndPath[:idx], hostAndPath[idx:]
+			}
+			if idx := strings.Index(host, ":"); idx >= 0 {
+				host = host[:idx]
+			}
+			return "https://" + host + path
+		}
+	}
+	if strings.Contains(gitURL, "://") {
+		return gitURL
+	}
+	// scp-like syntax: [user@]host:path
+	idx := strings.Index(gitURL, ":")
+	if idx <= 0 || strings.Contains(gitURL[:idx], "/") {
+		return gitURL
+	}
+	host := gitURL[:idx]
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	return "https://" + host + "/" + strings.TrimPrefix(gitURL[idx+1:], "/")
+}
@@ -0,0 +1,324 @@
+package slackbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// slackChannelNameRegex matches the channel names Slack allows, once prefixed with #
+var slackChannelNameRegex = regexp.MustCompile(`^#[a-z0-9_-]{1,80}$`)
+
+// knownPipelineStates are the statuses of the pipelines
+var knownPipelineStates = []string{string(v1alpha1.TriggeredState), string(v1alpha1.PendingState),
+	string(v1alpha1.RunningState), string(v1alpha1.SuccessState), string(v1alpha1.FailureState),
+	string(v1alpha1.AbortedState)}
+
+// hexColorRegex matches the hex codes Slack allows for attachment colors
+var hexColorRegex = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// LoadSlackBot parses a SlackBot from YAML, failing on fields which are not part of the SlackBot resource (such as a
+// misspelt status)
+func LoadSlackBot(data []byte) (*slackapp.SlackBot, error) {
+	slackBot := slackapp.SlackBot{}
+	err := yaml.Unmarshal(data, &slackBot, func(d *json.Decoder) *json.Decoder {
+		d.DisallowUnknownFields()
+		return d
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing SlackBot")
+	}
+	return &slackBot, nil
+}
+
+// ValidateSlackBot checks the configuration of a SlackBot for common mistakes, returning all the problems found
+func ValidateSlackBot(slackBot *slackapp.SlackBot) []error {
+	var errs []error
+	modes := map[string][]slackapp.SlackBotMode{
+		"pipelines":    slackBot.Spec.Pipelines,
+		"pullRequests": slackBot.Spec.PullRequests,
+	}
+	for _, kind := range []string{"pipelines", "pullRequests"} {
+		for i, cfg := range modes[kind] {
+			errs = append(errs, validateSlackBotMode(fmt.Sprintf("%s[%d]", kind, i), cfg)...)
+		}
+	}
+	for i, cfg := range slackBot.Spec.Promotions {
+		path := fmt.Sprintf("promotions[%d]", i)
+		errs = append(errs, validateSlackBotMode(path, cfg.SlackBotMode)...)
+		for _, err := range validateStatuses(cfg.Statuses) {
+			errs = append(errs, errors.Wrap(err, path))
+		}
+		if cfg.UpdateTopic && slackBot.Spec.DeliveryMode == DeliveryModeWebhook {
+			errs = append(errs, fmt.Errorf("%s: updateTopic needs the %s delivery mode", path, DeliveryModeToken))
+		}
+	}
+	errs = append(errs, validateStatuses(slackBot.Spec.Statuses)...)
+	for stage := range slackBot.Spec.StageEmojis {
+		if !containsIgnoreCase(knownPipelineStageTypes, stage) {
+			errs = append(errs, fmt.Errorf("stageEmojis: unknown stage type %s, must be one of %s", stage,
+				strings.Join(knownPipelineStageTypes, ", ")))
+		}
+	}
+	for i, webhook := range slackBot.Spec.Webhooks {
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("webhooks[%d]: invalid URL %s, expected an http or https URL", i,
+				webhook.URL))
+		}
+		for _, messageType := range webhook.MessageTypes {
+			if !util.Contains(webhookMessageTypes, messageType) {
+				errs = append(errs, fmt.Errorf("webhooks[%d]: unknown message type %s, must be one of %s", i,
+					messageType, strings.Join(webhookMessageTypes, ", ")))
+			}
+		}
+	}
+	switch slackBot.Spec.DeliveryMode {
+	case "", DeliveryModeToken:
+	case DeliveryModeWebhook:
+		if slackBot.Spec.IncomingWebhooksSecret == "" {
+			errs = append(errs, fmt.Errorf("incomingWebhooksSecret: required by the %s delivery mode",
+				DeliveryModeWebhook))
+		}
+		if len(slackBot.Spec.Workspaces) > 0 {
+			errs = append(errs, fmt.Errorf("workspaces: need the %s delivery mode, the incoming webhooks are "+
+				"configured per channel", DeliveryModeToken))
+		}
+		if slackBot.Spec.UseBlockKit {
+			errs = append(errs, fmt.Errorf("useBlockKit: needs the %s delivery mode, the incoming webhooks only "+
+				"post attachments", DeliveryModeToken))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("deliveryMode: unknown delivery mode %s, must be one of %s",
+			slackBot.Spec.DeliveryMode, strings.Join(deliveryModes, ", ")))
+	}
+	if locale := slackBot.Spec.Locale; locale != "" && !util.Contains(supportedLocales(), locale) {
+		errs = append(errs, fmt.Errorf("locale: unsupported locale %s, must be one of %s", locale,
+			strings.Join(supportedLocales(), ", ")))
+	}
+	if ref := slackBot.Spec.TokenSecretRef; ref != nil && ref.Name == "" {
+		errs = append(errs, fmt.Errorf("tokenSecretRef: the name of the Secret is required"))
+	}
+	errs = append(errs, validateWorkspaces(slackBot)...)
+	for i, window := range slackBot.Spec.MaintenanceWindows {
+		if !window.Start.Before(&window.End) {
+			errs = append(errs, fmt.Errorf("maintenanceWindows[%d]: the end must be after the start", i))
+		}
+	}
+	if slackBot.Spec.ShowReviewerAvatars && !slackBot.Spec.UseBlockKit {
+		errs = append(errs, fmt.Errorf("showReviewerAvatars: requires useBlockKit"))
+	}
+	for i, button := range slackBot.Spec.PipelineButtons {
+		if !util.Contains(pipelineButtonNames, button.Name) {
+			errs = append(errs, fmt.Errorf("pipelineButtons[%d]: unknown button %s, must be one of %s", i,
+				button.Name, strings.Join(pipelineButtonNames, ", ")))
+		}
+	}
+	if tracker := slackBot.Spec.IssueTracker; tracker != nil {
+		u, err := url.Parse(tracker.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("issueTracker: invalid URL %s, expected an http or https URL", tracker.URL))
+		}
+		if _, err := compileIssueKeyPattern(tracker); err != nil {
+			errs = append(errs, errors.Wrap(err, "issueTracker"))
+		}
+	}
+	if digest := slackBot.Spec.ReviewDigest; digest != nil {
+		if _, _, err := parseReviewDigestTime(digest); err != nil {
+			errs = append(errs, errors.Wrap(err, "reviewDigest"))
+		}
+		for _, channel := range digest.Channels {
+			channel = channelName(channel)
+			if !isChannelID(channel) && !slackChannelNameRegex.MatchString(channel) {
+				errs = append(errs, fmt.Errorf("reviewDigest: invalid channel %s", channel))
+			}
+		}
+	}
+	if alerting := slackBot.Spec.Alerting; alerting != nil && alerting.PagerDuty != nil {
+		ref := alerting.PagerDuty.RoutingKeyReference
+		if ref.Kind != "Secret" || ref.Name == "" {
+			errs = append(errs, fmt.Errorf("alerting.pagerDuty.routingKeyReference: expected the name of a Secret"))
+		}
+	}
+	if slackBot.Spec.ReviewMessageTemplate != "" {
+		_, err := parseReviewMessageTemplate(slackBot.Spec.ReviewMessageTemplate)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "reviewMessageTemplate"))
+		}
+	}
+	if slackBot.Spec.ClosedReviewMessageTemplate != "" {
+		_, err := parseReviewMessageTemplate(slackBot.Spec.ClosedReviewMessageTemplate)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "closedReviewMessageTemplate"))
+		}
+	}
+	if slackBot.Spec.QuietHours != nil {
+		_, _, _, err := parseQuietHours(slackBot.Spec.QuietHours)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "quietHours"))
+		}
+	}
+	return errs
+}
+
+// validateWorkspaces checks the workspaces are named uniquely and the modes only reference the workspaces defined
+func validateWorkspaces(slackBot *slackapp.SlackBot) []error {
+	var errs []error
+	workspaces := make(map[string]bool)
+	for i, workspace := range slackBot.Spec.Workspaces {
+		switch {
+		case workspace.Name == "" || strings.Contains(workspace.Name, workspaceSeparator):
+			errs = append(errs, fmt.Errorf("workspaces[%d]: invalid name %q, it is required and can't contain %s", i,
+				workspace.Name, workspaceSeparator))
+		case workspaces[workspace.Name]:
+			errs = append(errs, fmt.Errorf("workspaces[%d]: duplicate workspace %s", i, workspace.Name))
+		}
+		workspaces[workspace.Name] = true
+		if workspace.TokenSecretRef.Name == "" {
+			errs = append(errs, fmt.Errorf("workspaces[%d].tokenSecretRef: the name of the Secret is required", i))
+		}
+	}
+	checkWorkspace := func(path string, cfg slackapp.SlackBotMode) {
+		if cfg.Workspace != "" && !workspaces[cfg.Workspace] {
+			errs = append(errs, fmt.Errorf("%s: unknown workspace %s", path, cfg.Workspace))
+		}
+	}
+	for i, cfg := range slackBot.Spec.Pipelines {
+		checkWorkspace(fmt.Sprintf("pipelines[%d]", i), cfg)
+	}
+	for i, cfg := range slackBot.Spec.PullRequests {
+		checkWorkspace(fmt.Sprintf("pullRequests[%d]", i), cfg)
+	}
+	for i, cfg := range slackBot.Spec.Promotions {
+		checkWorkspace(fmt.Sprintf("promotions[%d]", i), cfg.SlackBotMode)
+	}
+	return errs
+}
+
+func validateSlackBotMode(path string, cfg slackapp.SlackBotMode) []error {
+	var errs []error
+	channels := configChannels(cfg)
+	if len(channels) == 0 && !cfg.DirectMessage && !allReposHaveChannels(cfg) {
+		errs = append(errs, fmt.Errorf("%s: no channel configured and direct messages are disabled", path))
+	}
+	for _, channel := range channels {
+		if !isChannelID(channel) && !slackChannelNameRegex.MatchString(channel) {
+			errs = append(errs, fmt.Errorf("%s: invalid channel %s, channel names must be lowercase and only "+
+				"contain letters, numbers, hyphens and underscores", path, channel))
+		}
+	}
+	if cfg.RepoPattern != "" {
+		if _, err := regexp.Compile(cfg.RepoPattern); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid repoPattern %s: %v", path, cfg.RepoPattern, err))
+		}
+	}
+	for _, status := range cfg.CreateOnStatuses {
+		if !containsIgnoreCase(knownPipelineStates, status) {
+			errs = append(errs, fmt.Errorf("%s: unknown status %s in createOnStatuses, must be one of %s", path,
+				status, strings.Join(knownPipelineStates, ", ")))
+		}
+	}
+	for status := range cfg.StatusChannels {
+		if !containsIgnoreCase(knownPipelineStates, status) {
+			errs = append(errs, fmt.Errorf("%s: unknown status %s in statusChannels, must be one of %s", path,
+				status, strings.Join(knownPipelineStates, ", ")))
+		}
+	}
+	if cfg.MentionPolicy != "" && !util.Contains(mentionPolicies, cfg.MentionPolicy) {
+		errs = append(errs, fmt.Errorf("%s: unknown mentionPolicy %s, must be one of %s", path, cfg.MentionPolicy,
+			strings.Join(mentionPolicies, ", ")))
+	}
+	if cfg.EscalateAfterFailures < 0 {
+		errs = append(errs, fmt.Errorf("%s: escalateAfterFailures must not be negative", path))
+	}
+	if cfg.EscalationMention != "" && !util.Contains(escalationMentions, cfg.EscalationMention) {
+		errs = append(errs, fmt.Errorf("%s: unknown escalationMention %s, must be one of %s", path,
+			cfg.EscalationMention, strings.Join(escalationMentions, ", ")))
+	}
+	for _, author := range cfg.IgnoreAuthors {
+		if !isValidAuthorPattern(author) {
+			errs = append(errs, fmt.Errorf("%s: invalid ignored author pattern %s", path, author))
+		}
+	}
+	seen := make(map[string]bool)
+	for _, org := range cfg.Orgs {
+		if org.Name == "" {
+			errs = append(errs, fmt.Errorf("%s: org without a name", path))
+			continue
+		}
+		for _, err := range validateStatuses(org.Statuses) {
+			errs = append(errs, fmt.Errorf("%s: org %s: %v", path, org.Name, err))
+		}
+		if len(org.Repos) == 0 {
+			if seen[org.Name] {
+				errs = append(errs, fmt.Errorf("%s: duplicate org %s", path, org.Name))
+			}
+			seen[org.Name] = true
+		}
+		for _, repo := range org.Repos {
+			key := fmt.Sprintf("%s/%s", org.Name, repo.Name)
+			if seen[key] {
+				errs = append(errs, fmt.Errorf("%s: duplicate repo %s", path, key))
+			}
+			seen[key] = true
+			repoChannel := channelName(repo.Channel)
+			if repo.Channel != "" && !isChannelID(repoChannel) && !slackChannelNameRegex.MatchString(repoChannel) {
+				errs = append(errs, fmt.Errorf("%s: invalid channel name %s for repo %s", path, repo.Channel, key))
+			}
+		}
+	}
+	return errs
+}
+
+// allReposHaveChannels returns true if the mode is restricted to repositories which all have their own channel
+func allReposHaveChannels(cfg slackapp.SlackBotMode) bool {
+	if len(cfg.Orgs) == 0 {
+		return false
+	}
+	for _, org := range cfg.Orgs {
+		if len(org.Repos) == 0 {
+			return false
+		}
+		for _, repo := range org.Repos {
+			if repo.Channel == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validateStatuses checks the configured statuses have a valid color
+func validateStatuses(statuses slackapp.Statuses) []error {
+	var errs []error
+	v := reflect.ValueOf(statuses)
+	for i := 0; i < v.NumField(); i++ {
+		status, ok := v.Field(i).Interface().(*slackapp.Status)
+		if !ok || status == nil || status.Color == "" {
+			continue
+		}
+		if !isValidColor(status.Color) {
+			name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			errs = append(errs, fmt.Errorf("statuses.%s: invalid color %s, colors must be good, warning, danger "+
+				"or a hex code such as #3AA3E3", name, status.Color))
+		}
+	}
+	return errs
+}
+
+func isValidColor(color string) bool {
+	switch color {
+	case "good", "warning", "danger":
+		return true
+	}
+	return hexColorRegex.MatchString(color)
+}
@@ -0,0 +1,373 @@
+package slackbot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSlackBot(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		wantErrs int
+	}{
+		{
+			name: "valid",
+			yaml: `
+apiVersion: slack.apps.jenkins-x.io/v1alpha1
+kind: SlackBot
+metadata:
+  name: cheese
+spec:
+  pipelines:
+  - channel: builds
+    orgs:
+    - name: cheese
+      repos: [wine, cheddar]
+  pullRequests:
+  - directMessage: true
+    notifyReviewers: true
+  statuses:
+    merged:
+      emoji: ":tada:"
+    failed:
+      color: "#E01E5A"
+    succeeded:
+      color: good
+  reviewMessageTemplate: "{{ .Mentions }} please review {{ .PRLink }}"
+`,
+		},
+		{
+			name: "repo channels",
+			yaml: `
+spec:
+  pipelines:
+  - channel: builds
+    orgs:
+    - name: cheese
+      repos:
+      - wine
+      - name: cheddar
+        channel: team-cheddar
+  pullRequests:
+  - orgs:
+    - name: cheese
+      repos:
+      - name: brie
+        channel: team-brie
+      - name: camembert
+        channel: C0123ABCD
+`,
+		},
+		{
+			name: "unknown stage type",
+			yaml: `
+spec:
+  pipelines:
+  - channel: builds
+  stageEmojis:
+    build: ":hammer:"
+    deploy: ":rocket:"
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "unsupported locale",
+			yaml: `
+spec:
+  locale: de
+  pipelines:
+  - channel: builds
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "invalid alerting",
+			yaml: `
+spec:
+  pipelines:
+  - channel: builds
+  alerting:
+    environments: [production]
+    pagerDuty:
+      routingKeyReference:
+        kind: ConfigMap
+        name: pagerduty
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "invalid ignored author",
+			yaml: `
+spec:
+  pullRequests:
+  - channel: reviews
+    ignoreAuthors: ["dependabot*", "[renovate"]
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "invalid review digest",
+			yaml: `
+spec:
+  reviewDigest:
+    time: "9h"
+    timeZone: Europe/Cheese
+    channels: ["Team Reviews"]
+`,
+			wantErrs: 2,
+		},
+		{
+			name: "invalid issue tracker",
+			yaml: `
+spec:
+  issueTracker:
+    url: issues.example.com/browse/
+    keyPattern: "JX-(\\d+"
+`,
+			wantErrs: 2,
+		},
+		{
+			name: "unknown create on status",
+			yaml: `
+spec:
+  pipelines:
+  - channel: builds
+    createOnStatuses: [Running, started]
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "reviewer avatars without block kit",
+			yaml: `
+spec:
+  showReviewerAvatars: true
+  pipelines:
+  - channel: "#builds"
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "reviewer avatars with block kit",
+			yaml: `
+spec:
+  useBlockKit: true
+  showReviewerAvatars: true
+  pipelines:
+  - channel: "#builds"
+`,
+		},
+		{
+			name: "unknown pipeline button",
+			yaml: `
+spec:
+  pipelineButtons:
+  - name: logs
+    text: Logs
+  - name: coverage
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "unknown escalation mention",
+			yaml: `
+spec:
+  pipelines:
+  - channel: "#builds"
+    escalateAfterFailures: 3
+    escalationMention: everyone
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "unknown status channel",
+			yaml: `
+spec:
+  pipelines:
+  - channel: "#builds"
+    statusChannels:
+      failed: "#ci-failures"
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "unknown mention policy",
+			yaml: `
+spec:
+  pullRequests:
+  - channel: "#reviews"
+    mentionPolicy: sometimes
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "webhook delivery without incoming webhooks",
+			yaml: `
+spec:
+  deliveryMode: webhook
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "topic updates with webhook delivery",
+			yaml: `
+spec:
+  deliveryMode: webhook
+  incomingWebhooksSecret: slack-incoming-webhooks
+  promotions:
+  - channel: releases
+    updateTopic: true
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "workspaces and Block Kit with webhook delivery",
+			yaml: `
+spec:
+  deliveryMode: webhook
+  incomingWebhooksSecret: slack-incoming-webhooks
+  useBlockKit: true
+  workspaces:
+  - name: acme
+    tokenSecretRef:
+      name: acme-slack-token
+`,
+			wantErrs: 2,
+		},
+		{
+			name: "unknown delivery mode",
+			yaml: `
+spec:
+  deliveryMode: carrier-pigeon
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "maintenance window ending before it starts",
+			yaml: `
+spec:
+  maintenanceWindows:
+  - start: 2020-06-01T23:00:00Z
+    end: 2020-06-01T22:00:00Z
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "invalid repo pattern",
+			yaml: `
+spec:
+  pullRequests:
+  - channel: reviews
+    repoPattern: "team-(foo"
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "invalid webhooks",
+			yaml: `
+spec:
+  webhooks:
+  - url: https://dashboard.example.com/events
+    messageTypes: [pipeline, pr]
+  - url: dashboard.example.com
+    messageTypes: [review]
+`,
+			wantErrs: 2,
+		},
+		{
+			name: "invalid org status color",
+			yaml: `
+spec:
+  pipelines:
+  - channel: builds
+    orgs:
+    - name: cheese
+      statuses:
+        succeeded:
+          emoji: ":white_check_mark:"
+          color: green
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "invalid repo channel",
+			yaml: `
+spec:
+  pipelines:
+  - channel: builds
+    orgs:
+    - name: cheese
+      repos:
+      - name: wine
+        channel: "Team Wine"
+`,
+			wantErrs: 1,
+		},
+		{
+			name: "workspaces",
+			yaml: `
+spec:
+  workspaces:
+  - name: partners
+    tokenSecretRef:
+      name: partners-slack-token
+  pipelines:
+  - channel: builds
+    workspace: partners
+`,
+		},
+		{
+			name: "invalid workspaces",
+			yaml: `
+spec:
+  workspaces:
+  - name: partners
+    tokenSecretRef:
+      name: partners-slack-token
+  - name: partners
+  pipelines:
+  - channel: builds
+    workspace: customers
+`,
+			wantErrs: 3,
+		},
+		{
+			name: "invalid",
+			yaml: `
+spec:
+  pipelines:
+  - channel: "Team Builds"
+    orgs:
+    - name: cheese
+      repos: [wine, wine]
+  pullRequests:
+  - notifyReviewers: true
+  statuses:
+    running:
+      color: blue
+  reviewMessageTemplate: "{{ .Title }}"
+`,
+			wantErrs: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slackBot, err := LoadSlackBot([]byte(tt.yaml))
+			require.NoError(t, err)
+			errs := ValidateSlackBot(slackBot)
+			assert.Len(t, errs, tt.wantErrs, "%v", errs)
+		})
+	}
+}
+
+func TestLoadSlackBot_unknownStatus(t *testing.T) {
+	_, err := LoadSlackBot([]byte(`
+spec:
+  statuses:
+    merge:
+      emoji: ":tada:"
+`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,171 @@
+package slackbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	"github.com/jenkins-x/jx/v2/pkg/util"
+	"github.com/jenkins-x/lighthouse/pkg/record"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// webhookQueueSize is the number of events waiting to be sent to the webhooks, the later events are dropped when the
+// queue is full so that a slow webhook doesn't hold back the messages sent to Slack
+const webhookQueueSize = 100
+
+// webhookMessageTypes are the types of the messages which can be forwarded to a webhook
+var webhookMessageTypes = []string{pipelineMessageType, pullRequestReviewMessageType, promotionMessageType}
+
+// WebhookEvent is the JSON payload POSTed to the webhooks for each message sent to Slack
+type WebhookEvent struct {
+	MessageType   string `json:"messageType"`
+	Channel       string `json:"channel"`
+	DirectMessage bool   `json:"directMessage,omitempty"`
+	Activity      string `json:"activity"`
+	Owner         string `json:"owner,omitempty"`
+	Repo          string `json:"repo,omitempty"`
+	Branch        string `json:"branch,omitempty"`
+	Build         string `json:"build,omitempty"`
+	Context       string `json:"context,omitempty"`
+	Status        string `json:"status,omitempty"`
+	LogURL        string `json:"logURL,omitempty"`
+	LinkURL       string `json:"linkURL,omitempty"`
+	// Text is the text of the message as rendered in Slack
+	Text string `json:"text"`
+}
+
+// webhookDelivery is an event waiting in the queue to be sent to a webhook
+type webhookDelivery struct {
+	webhook slackapp.WebhookConfig
+	event   WebhookEvent
+	logger  *logrus.Entry
+}
+
+// emitWebhooks queues the message sent to the channel for the webhooks configured for its type, they are sent in the
+// background. Failing to deliver to a webhook is only logged, as Slack is the primary destination of the messages.
+func (o *SlackBotOptions) emitWebhooks(channel string, directMessage bool, messageType string,
+	activity *record.ActivityRecord, attachments []slack.Attachment, blocks []slack.Block) {
+	if len(o.Webhooks) == 0 {
+		return
+	}
+	event := WebhookEvent{
+		MessageType:   messageType,
+		Channel:       channel,
+		DirectMessage: directMessage,
+		Activity:      activity.Name,
+		Owner:         activity.Owner,
+		Repo:          activity.Repo,
+		Branch:        activity.Branch,
+		Build:         activity.BuildIdentifier,
+		Context:       activity.Context,
+		Status:        string(pipelineStatus(activity)),
+		LogURL:        activity.LogURL,
+		LinkURL:       activity.LinkURL,
+		Text:          renderedText(attachments, blocks),
+	}
+	for _, webhook := range o.Webhooks {
+		if len(webhook.MessageTypes) > 0 && !util.Contains(webhook.MessageTypes, messageType) {
+			continue
+		}
+		o.queueWebhook(webhookDelivery{
+			webhook: webhook,
+			event:   event,
+			logger:  messageLogger(activity, channel, messageType),
+		})
+	}
+}
+
+// queueWebhook adds the delivery to the queue of the webhooks, starting the worker sending them the first time
+func (o *SlackBotOptions) queueWebhook(delivery webhookDelivery) {
+	o.webhookQueueOnce.Do(func() {
+		o.webhookQueue = make(chan webhookDelivery, webhookQueueSize)
+		go o.sendWebhooks(o.webhookQueue)
+	})
+	atomic.AddInt32(&o.pendingWebhooks, 1)
+	select {
+	case o.webhookQueue <- delivery:
+	default:
+		atomic.AddInt32(&o.pendingWebhooks, -1)
+		delivery.logger.Warnf("dropping %s message for %s to webhook %s: %d events are already waiting",
+			delivery.event.MessageType, delivery.event.Activity, delivery.webhook.URL, webhookQueueSize)
+	}
+}
+
+// sendWebhooks sends the deliveries of the queue one at a time
+func (o *SlackBotOptions) sendWebhooks(queue <-chan webhookDelivery) {
+	for delivery := range queue {
+		err := o.postWebhook(delivery.webhook, delivery.event)
+		if err != nil {
+			delivery.logger.Warnf("failed to send %s message for %s to webhook %s: %v", delivery.event.MessageType,
+				delivery.event.Activity, delivery.webhook.URL, err)
+		}
+		atomic.AddInt32(&o.pendingWebhooks, -1)
+	}
+}
+
+func (o *SlackBotOptions) postWebhook(webhook slackapp.WebhookConfig, event WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "marshalling webhook event")
+	}
+	ctx, cancel := o.withTimeout(context.Background())
+	defer cancel()
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "creating webhook request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	client := o.WebhookClient
+	if client == nil {
+		client = o.httpClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "sending webhook event")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode, string(data))
+	}
+	log.Logger().Debugf("Sent %s message for %s to webhook %s", event.MessageType, event.Activity, webhook.URL)
+	return nil
+}
+
+// renderedText returns the text of the message, one line per title or text of the attachments or blocks
+func renderedText(attachments []slack.Attachment, blocks []slack.Block) string {
+	var lines []string
+	for _, a := range attachments {
+		for _, text := range []string{a.Pretext, a.Title, a.Text} {
+			if text != "" {
+				lines = append(lines, text)
+			}
+		}
+	}
+	for _, b := range blocks {
+		switch block := b.(type) {
+		case *slack.SectionBlock:
+			if block.Text != nil {
+				lines = append(lines, block.Text.Text)
+			}
+		case *slack.ContextBlock:
+			for _, element := range block.ContextElements.Elements {
+				if text, ok := element.(*slack.TextBlockObject); ok {
+					lines = append(lines, text.Text)
+				}
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,85 @@
+package slackbot
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackBotOptions_emitWebhooks(t *testing.T) {
+	var lock sync.Mutex
+	events := map[string][]WebhookEvent{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event WebhookEvent
+		err := json.NewDecoder(r.Body).Decode(&event)
+		require.NoError(t, err)
+		lock.Lock()
+		events[r.URL.Path] = append(events[r.URL.Path], event)
+		lock.Unlock()
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Webhooks: []slackapp.WebhookConfig{
+			{URL: server.URL + "/broken"},
+			{URL: server.URL + "/all"},
+			{URL: server.URL + "/reviews", MessageTypes: []string{pullRequestReviewMessageType}},
+		},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	attachments := []slack.Attachment{{Title: "Pipeline cheese/wine", Text: "build running"}}
+
+	// webhooks which fail don't prevent the message from being posted to Slack
+	err = o.postMessage("#cheese", false, pipelineMessageType, act, nil, attachments, nil, true)
+	require.NoError(t, err)
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(events["/all"]) == 1 && len(events["/broken"]) == 1
+	}, time.Second, 10*time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	event := events["/all"][0]
+	assert.Equal(t, pipelineMessageType, event.MessageType)
+	assert.Equal(t, "#cheese", event.Channel)
+	assert.Equal(t, act.Name, event.Activity)
+	assert.Equal(t, "Pipeline cheese/wine\nbuild running", event.Text)
+	assert.Empty(t, events["/reviews"])
+}
+
+func TestSlackBotOptions_emitWebhooks_queueFull(t *testing.T) {
+	o := &SlackBotOptions{
+		Webhooks: []slackapp.WebhookConfig{{URL: "http://dashboard.invalid/events"}},
+		// no worker sends the queued messages
+		webhookQueue: make(chan webhookDelivery),
+	}
+	o.webhookQueueOnce.Do(func() {})
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+
+	// the message is dropped rather than waiting for the queue
+	o.emitWebhooks("#cheese", false, pipelineMessageType, act, nil, nil)
+	assert.Equal(t, int32(0), o.pendingWebhooks)
+}
+
+func Test_renderedText(t *testing.T) {
+	blocks := attachmentsToBlocks([]slack.Attachment{{Title: "Pipeline cheese/wine"}, {Text: "build running"}})
+	assert.Equal(t, "Pipeline cheese/wine\nbuild running", renderedText(nil, blocks))
+}
@@ -0,0 +1,86 @@
+package slackbot
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx-logging/pkg/log"
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// workspaceSeparator separates the workspace from the channel, it can't be used in Slack channel names
+const workspaceSeparator = ":"
+
+// workspaceChannel qualifies the channel with the workspace, so that the messages of channels with the same name in
+// different workspaces are told apart. The channel is returned as is for the default workspace
+func workspaceChannel(workspace string, channel string) string {
+	if workspace == "" {
+		return channel
+	}
+	return workspace + workspaceSeparator + channel
+}
+
+// splitWorkspaceChannel returns the workspace and the channel of a channel qualified by workspaceChannel
+func splitWorkspaceChannel(channel string) (string, string) {
+	parts := strings.SplitN(channel, workspaceSeparator, 2)
+	if len(parts) < 2 {
+		return "", channel
+	}
+	return parts[0], parts[1]
+}
+
+// slackClientFor returns the Slack client of the workspace, the default Slack client is used for the default
+// workspace and the workspaces which aren't configured
+func (o *SlackBotOptions) slackClientFor(workspace string) SlackClienter {
+	if workspace == "" {
+		return o.slackClient()
+	}
+	if client, ok := o.Workspaces[workspace]; ok {
+		return client
+	}
+	log.Logger().Warnf("No Slack workspace named %s configured for %s, using the default workspace", workspace,
+		o.Name)
+	return o.slackClient()
+}
+
+// workspaceSlackUserID returns the ID of the user in the Slack workspace, looked up by email in its directory. The
+// Slack accounts linked to the Jenkins X users are the ones of the default workspace, so they aren't used.
+func (o *SlackBotOptions) workspaceSlackUserID(workspace string, user *jenkinsv1.User) (string, error) {
+	r := o.SlackUserResolver
+	if r == nil || !r.LookupByEmail || user.Spec.Email == "" {
+		return "", nil
+	}
+	client, ok := o.slackClientFor(workspace).(UserByEmailGetter)
+	if !ok {
+		return "", nil
+	}
+	email, err := r.getSlackEmailFromMapping(user.Spec.Email, userMappingfile)
+	if err != nil {
+		// the user may have the same email address in Git and Slack
+		email = user.Spec.Email
+	}
+	id, err := r.lookupSlackUserIDByEmail(workspace, client, email)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not find Slack ID in workspace %s using email %s", workspace, email)
+	}
+	return id, nil
+}
+
+// createWorkspaceClients creates the Slack clients of the workspaces of the SlackBot keyed by name
+func createWorkspaceClients(c *GlobalClients, slackBot *slackapp.SlackBot) (map[string]SlackClienter, error) {
+	clients := make(map[string]SlackClienter, len(slackBot.Spec.Workspaces))
+	for _, workspace := range slackBot.Spec.Workspaces {
+		key := workspace.TokenSecretRef.Key
+		if key == "" {
+			key = DefaultTokenSecretKey
+		}
+		ref := jenkinsv1.ResourceReference{Kind: "Secret", Name: workspace.TokenSecretRef.Name}
+		token, err := readSecretKey(c, ref, key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the Slack token of workspace %s", workspace.Name)
+		}
+		clients[workspace.Name] = c.newSlackClient(token)
+	}
+	return clients, nil
+}
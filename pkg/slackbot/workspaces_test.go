@@ -0,0 +1,106 @@
+package slackbot
+
+import (
+	"testing"
+
+	jenkinsv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	slackapp "github.com/jenkins-x/slack/pkg/apis/slack/v1alpha1"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitWorkspaceChannel(t *testing.T) {
+	workspace, channel := splitWorkspaceChannel(workspaceChannel("partners", "#cheese"))
+	assert.Equal(t, "partners", workspace)
+	assert.Equal(t, "#cheese", channel)
+
+	workspace, channel = splitWorkspaceChannel(workspaceChannel("", "#cheese"))
+	assert.Equal(t, "", workspace)
+	assert.Equal(t, "#cheese", channel)
+}
+
+func TestSlackBotOptions_slackClientFor(t *testing.T) {
+	client := &fakeSlackClient{}
+	partners := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Workspaces:  map[string]SlackClienter{"partners": partners},
+	}
+	assert.Equal(t, client, o.slackClientFor(""))
+	assert.Equal(t, partners, o.slackClientFor("partners"))
+	// unknown workspaces fall back to the default workspace
+	assert.Equal(t, client, o.slackClientFor("customers"))
+}
+
+func TestSlackBotOptions_PipelineMessage_workspaces(t *testing.T) {
+	client := &fakeSlackClient{}
+	partners := &fakeSlackClient{}
+	o := &SlackBotOptions{
+		SlackClient: client,
+		Workspaces:  map[string]SlackClienter{"partners": partners},
+		Timestamps:  make(map[string]map[string]*MessageReference),
+		Pipelines: []slackapp.SlackBotMode{
+			{Channel: "#cheese"},
+			{Channel: "#cheese", Workspace: "partners"},
+		},
+	}
+	act, err := getRecentPipelineActivity("stage_multiple_steps.yaml")
+	require.NoError(t, err, "failed to read files")
+	act.Branch = "master"
+
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.postMessage"), 1)
+	assert.Len(t, partners.callsTo("chat.postMessage"), 1)
+	ref := o.messageReference(workspaceChannel("partners", "#cheese"), act.Name)
+	require.NotNil(t, ref, "the message of the workspace should be stored separately")
+	assert.Equal(t, "partners", ref.Workspace)
+	require.NotNil(t, o.messageReference("#cheese", act.Name))
+
+	// each workspace updates its own message
+	act.LogURL = "https://dashboard.example.com/logs"
+	require.NoError(t, o.PipelineMessage(act))
+	assert.Len(t, client.callsTo("chat.update"), 1)
+	assert.Len(t, partners.callsTo("chat.update"), 1)
+}
+
+func TestSlackBotOptions_workspaceUsers(t *testing.T) {
+	client := &fakeSlackClient{avatars: map[string]string{"U1": "https://avatars.example.com/default/U1.png"}}
+	partners := &fakeSlackClient{
+		avatars: map[string]string{"U1": "https://avatars.example.com/partners/U1.png"},
+		users:   map[string]string{"brie@example.com": "W2"},
+	}
+	o := &SlackBotOptions{
+		SlackClient:       client,
+		Workspaces:        map[string]SlackClienter{"partners": partners},
+		SlackUserResolver: &SlackUserResolver{LookupByEmail: true},
+	}
+	user := &jenkinsv1.User{
+		Spec: jenkinsv1.UserDetails{
+			Login:    "brie",
+			Email:    "brie@example.com",
+			Accounts: []jenkinsv1.AccountReference{{Provider: "slack.apps.jenkins-x.com/userid", ID: "U2"}},
+		},
+	}
+
+	// the users are looked up in the directory of the workspace, not with the accounts of the default workspace
+	id, err := o.slackUserID("partners", user)
+	require.NoError(t, err)
+	assert.Equal(t, "W2", id)
+	id, err = o.slackUserID("", user)
+	require.NoError(t, err)
+	assert.Equal(t, "U2", id)
+	_, err = o.slackUserID("partners", user)
+	require.NoError(t, err)
+	assert.Len(t, partners.callsTo("users.lookupByEmail"), 1, "the lookups should be cached")
+	assert.Empty(t, client.callsTo("users.lookupByEmail"))
+
+	// the same ID has a different avatar in each workspace
+	assert.Equal(t, "https://avatars.example.com/default/U1.png", o.slackAvatar("", "U1"))
+	assert.Equal(t, "https://avatars.example.com/partners/U1.png", o.slackAvatar("partners", "U1"))
+	blocks := o.reviewerAvatarsBlock("partners", []*slack.User{{ID: "U1"}})
+	require.Len(t, blocks, 1)
+	image := blocks[0].(*slack.ContextBlock).ContextElements.Elements[0].(*slack.ImageBlockElement)
+	assert.Equal(t, "https://avatars.example.com/partners/U1.png", image.ImageURL)
+	assert.Len(t, partners.callsTo("users.info"), 1)
+}